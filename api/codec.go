@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// CodecName is the gRPC content-subtype of the onos-rsm specific services;
+// clients must call them with grpc.CallContentSubtype(CodecName). gRPC codecs are registered process-wide,
+// so the name is specific to onos-rsm to not replace a "json" codec registered by another package.
+const CodecName = "onos-rsm-json"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec encodes the messages of the onos-rsm specific services, which are plain Go types, as JSON
+type codec struct{}
+
+func (codec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (codec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (codec) Name() string {
+	return CodecName
+}
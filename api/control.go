@@ -0,0 +1,19 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+// ControlOutcome is the result of the E2 control message which decided the result of a request
+type ControlOutcome struct {
+	E2NodeID string `json:"e2NodeId"`
+	// Outcome is success, failure (the E2 node rejected the message), error (the message did not reach the E2 node) or timeout
+	Outcome string `json:"outcome"`
+	// CauseGroup is the E2AP cause group of a failure: ric, ric-service, protocol or misc
+	CauseGroup string `json:"causeGroup,omitempty"`
+	Cause      string `json:"cause,omitempty"`
+	Retryable  bool   `json:"retryable"`
+	// Retries is the number of times the control message was sent again; the outcome is the one of the last attempt
+	Retries int    `json:"retries"`
+	Message string `json:"message,omitempty"`
+}
@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// DiagnosticsServiceName is the full name of the diagnostics service
+const DiagnosticsServiceName = "onos.rsm.Diagnostics"
+
+// DiagnosticsServer is the server API for the diagnostics service
+type DiagnosticsServer interface {
+	GetDriftReport(context.Context, *GetDriftReportRequest) (*GetDriftReportResponse, error)
+	GetSubscriptionHealth(context.Context, *GetSubscriptionHealthRequest) (*GetSubscriptionHealthResponse, error)
+}
+
+// RegisterDiagnosticsServer registers the diagnostics service on the gRPC server
+func RegisterDiagnosticsServer(s *grpc.Server, srv DiagnosticsServer) {
+	s.RegisterService(&diagnosticsServiceDesc, srv)
+}
+
+func getDriftReportHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDriftReportRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiagnosticsServer).GetDriftReport(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + DiagnosticsServiceName + "/GetDriftReport",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiagnosticsServer).GetDriftReport(ctx, req.(*GetDriftReportRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getSubscriptionHealthHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSubscriptionHealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiagnosticsServer).GetSubscriptionHealth(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + DiagnosticsServiceName + "/GetSubscriptionHealth",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiagnosticsServer).GetSubscriptionHealth(ctx, req.(*GetSubscriptionHealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var diagnosticsServiceDesc = grpc.ServiceDesc{
+	ServiceName: DiagnosticsServiceName,
+	HandlerType: (*DiagnosticsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetDriftReport",
+			Handler:    getDriftReportHandler,
+		},
+		{
+			MethodName: "GetSubscriptionHealth",
+			Handler:    getSubscriptionHealthHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "onos-rsm/api/diagnostics",
+}
+
+// DiagnosticsClient is the client API for the diagnostics service
+type DiagnosticsClient interface {
+	GetDriftReport(ctx context.Context, in *GetDriftReportRequest, opts ...grpc.CallOption) (*GetDriftReportResponse, error)
+	GetSubscriptionHealth(ctx context.Context, in *GetSubscriptionHealthRequest, opts ...grpc.CallOption) (*GetSubscriptionHealthResponse, error)
+}
+
+// NewDiagnosticsClient creates a diagnostics client on the given connection
+func NewDiagnosticsClient(cc *grpc.ClientConn) DiagnosticsClient {
+	return &diagnosticsClient{
+		cc: cc,
+	}
+}
+
+type diagnosticsClient struct {
+	cc *grpc.ClientConn
+}
+
+func (c *diagnosticsClient) GetDriftReport(ctx context.Context, in *GetDriftReportRequest, opts ...grpc.CallOption) (*GetDriftReportResponse, error) {
+	out := new(GetDriftReportResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+DiagnosticsServiceName+"/GetDriftReport", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *diagnosticsClient) GetSubscriptionHealth(ctx context.Context, in *GetSubscriptionHealthRequest, opts ...grpc.CallOption) (*GetSubscriptionHealthResponse, error) {
+	out := new(GetSubscriptionHealthResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+DiagnosticsServiceName+"/GetSubscriptionHealth", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
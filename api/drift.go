@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import "time"
+
+// DriftKind is the kind of inconsistency found by the drift detector
+type DriftKind string
+
+const (
+	// DriftOrphanedSliceAssociation is a UE listed in a slice in onos-topo which no longer exists in UE-NIB
+	DriftOrphanedSliceAssociation DriftKind = "orphaned-slice-association"
+	// DriftMissingSlice is a slice referenced by a UE in UE-NIB which does not exist in onos-topo
+	DriftMissingSlice DriftKind = "missing-slice"
+	// DriftMissingUEAssociation is a UE listed in a slice in onos-topo whose slice list in UE-NIB does not have the slice
+	DriftMissingUEAssociation DriftKind = "missing-ue-association"
+	// DriftMissingTopoAssociation is a slice in the slice list of a UE in UE-NIB which does not list the UE in onos-topo
+	DriftMissingTopoAssociation DriftKind = "missing-topo-association"
+	// DriftUnknownUE is a UE reported attached in RSM indications which does not exist in UE-NIB
+	DriftUnknownUE DriftKind = "unknown-ue"
+	// DriftStaleUE is a UE reported detached in RSM indications which still exists in UE-NIB
+	DriftStaleUE DriftKind = "stale-ue"
+)
+
+// DriftFinding is a single inconsistency between onos-topo, UE-NIB and RSM indications
+type DriftFinding struct {
+	Kind        DriftKind `json:"kind"`
+	E2NodeID    string    `json:"e2NodeId,omitempty"`
+	SliceID     string    `json:"sliceId,omitempty"`
+	SliceType   string    `json:"sliceType,omitempty"`
+	GlobalUeID  string    `json:"globalUeId,omitempty"`
+	DuUeF1apID  int64     `json:"duUeF1apId,omitempty"`
+	DrbID       int32     `json:"drbId,omitempty"`
+	Description string    `json:"description"`
+	Repaired    bool      `json:"repaired,omitempty"`
+}
+
+// DriftReport is the result of a drift detection run
+type DriftReport struct {
+	Time     time.Time      `json:"time"`
+	Findings []DriftFinding `json:"findings"`
+	Errors   []string       `json:"errors,omitempty"`
+}
+
+// GetDriftReportRequest requests the drift report, optionally for a single E2 node
+type GetDriftReportRequest struct {
+	E2NodeID string `json:"e2NodeId,omitempty"`
+	// Refresh runs the drift detector instead of returning the latest periodic report
+	Refresh bool `json:"refresh,omitempty"`
+}
+
+// GetDriftReportResponse is the drift report
+type GetDriftReportResponse struct {
+	Report *DriftReport `json:"report"`
+}
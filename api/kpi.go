@@ -0,0 +1,49 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import "time"
+
+// KpiSample is the slice metrics a DU reported for a UE in an RSM metric indication; E2SM-RSM does not identify
+// the slice of the metrics, so a sample is the metrics of the UE's slice of the direction on the DU
+type KpiSample struct {
+	Time       time.Time `json:"time"`
+	E2NodeID   string    `json:"e2NodeId"`
+	SliceType  string    `json:"sliceType"`
+	GlobalUeID string    `json:"globalUeId,omitempty"`
+	DuUeF1apID int64     `json:"duUeF1apId"`
+	// PrbUtilization is the PRB usage of the slice in percent
+	PrbUtilization int32 `json:"prbUtilization"`
+	NumUes         int32 `json:"numUes"`
+	// Bler is the slice level block error rate in percent
+	Bler   int32 `json:"bler"`
+	AvgCqi int32 `json:"avgCqi"`
+}
+
+// KpiSummary aggregates the samples of a query
+type KpiSummary struct {
+	Samples           int     `json:"samples"`
+	AvgPrbUtilization float64 `json:"avgPrbUtilization"`
+	MaxPrbUtilization int32   `json:"maxPrbUtilization"`
+	MaxNumUes         int32   `json:"maxNumUes"`
+	AvgBler           float64 `json:"avgBler"`
+	AvgCqi            float64 `json:"avgCqi"`
+}
+
+// GetSliceKpisRequest requests the KPIs of a DU in a time window; the slice type and the UE narrow it down
+type GetSliceKpisRequest struct {
+	E2NodeID   string `json:"e2NodeId"`
+	SliceType  string `json:"sliceType,omitempty"`
+	GlobalUeID string `json:"globalUeId,omitempty"`
+	// From and To bound the window; the zero values leave it open
+	From time.Time `json:"from,omitempty"`
+	To   time.Time `json:"to,omitempty"`
+}
+
+// GetSliceKpisResponse is the samples of the window in time order and their summary
+type GetSliceKpisResponse struct {
+	Samples []KpiSample `json:"samples"`
+	Summary KpiSummary  `json:"summary"`
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// KpisServiceName is the full name of the KPI service
+const KpisServiceName = "onos.rsm.Kpis"
+
+// KpisServer is the server API for the KPI service
+type KpisServer interface {
+	GetSliceKpis(context.Context, *GetSliceKpisRequest) (*GetSliceKpisResponse, error)
+}
+
+// RegisterKpisServer registers the KPI service on the gRPC server
+func RegisterKpisServer(s *grpc.Server, srv KpisServer) {
+	s.RegisterService(&kpisServiceDesc, srv)
+}
+
+func getSliceKpisHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSliceKpisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KpisServer).GetSliceKpis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + KpisServiceName + "/GetSliceKpis",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KpisServer).GetSliceKpis(ctx, req.(*GetSliceKpisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var kpisServiceDesc = grpc.ServiceDesc{
+	ServiceName: KpisServiceName,
+	HandlerType: (*KpisServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetSliceKpis",
+			Handler:    getSliceKpisHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "onos-rsm/api/kpis",
+}
+
+// KpisClient is the client API for the KPI service
+type KpisClient interface {
+	GetSliceKpis(ctx context.Context, in *GetSliceKpisRequest, opts ...grpc.CallOption) (*GetSliceKpisResponse, error)
+}
+
+// NewKpisClient creates a KPI client on the given connection
+func NewKpisClient(cc *grpc.ClientConn) KpisClient {
+	return &kpisClient{
+		cc: cc,
+	}
+}
+
+type kpisClient struct {
+	cc *grpc.ClientConn
+}
+
+func (c *kpisClient) GetSliceKpis(ctx context.Context, in *GetSliceKpisRequest, opts ...grpc.CallOption) (*GetSliceKpisResponse, error) {
+	out := new(GetSliceKpisResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+KpisServiceName+"/GetSliceKpis", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
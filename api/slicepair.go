@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+)
+
+// SliceParameters are the scheduling parameters of one direction of a slice pair
+type SliceParameters struct {
+	SchedulerType rsmapi.SchedulerType `json:"schedulerType"`
+	Weight        string               `json:"weight"`
+}
+
+// SlicePair is a DL slice and a UL slice on a DU created together by CreateSlicePair, sharing the same slice ID and lifecycle
+type SlicePair struct {
+	E2NodeID string          `json:"e2NodeId"`
+	ID       string          `json:"id"`
+	Dl       SliceParameters `json:"dl"`
+	Ul       SliceParameters `json:"ul"`
+}
+
+// Ack is the result of a slice pair request
+type Ack struct {
+	Success bool            `json:"success"`
+	Cause   string          `json:"cause,omitempty"`
+	Control *ControlOutcome `json:"control,omitempty"`
+}
+
+type CreateSlicePairRequest struct {
+	SlicePair *SlicePair `json:"slicePair"`
+}
+
+type CreateSlicePairResponse struct {
+	Ack *Ack `json:"ack"`
+}
+
+type UpdateSlicePairRequest struct {
+	SlicePair *SlicePair `json:"slicePair"`
+}
+
+type UpdateSlicePairResponse struct {
+	Ack *Ack `json:"ack"`
+}
+
+type DeleteSlicePairRequest struct {
+	E2NodeID string `json:"e2NodeId"`
+	ID       string `json:"id"`
+}
+
+type DeleteSlicePairResponse struct {
+	Ack *Ack `json:"ack"`
+}
+
+type GetSlicePairsRequest struct {
+	// E2NodeID limits the slice pairs to a single DU
+	E2NodeID string `json:"e2NodeId,omitempty"`
+}
+
+type GetSlicePairsResponse struct {
+	SlicePairs []*SlicePair `json:"slicePairs"`
+}
+
+// SetUeSlicePairAssociationRequest associates UE bearers with both slices of a slice pair;
+// the UE and the bearers are named the same way as in SetUeSliceAssociationRequest
+type SetUeSlicePairAssociationRequest struct {
+	E2NodeID    string         `json:"e2NodeId,omitempty"`
+	UeID        []*rsmapi.UeId `json:"ueId"`
+	SlicePairID string         `json:"slicePairId"`
+	DrbID       string         `json:"drbId"`
+}
+
+type SetUeSlicePairAssociationResponse struct {
+	Ack *Ack `json:"ack"`
+}
+
+func (r *CreateSlicePairResponse) GetAck() *Ack {
+	if r == nil {
+		return nil
+	}
+	return r.Ack
+}
+
+func (r *UpdateSlicePairResponse) GetAck() *Ack {
+	if r == nil {
+		return nil
+	}
+	return r.Ack
+}
+
+func (r *DeleteSlicePairResponse) GetAck() *Ack {
+	if r == nil {
+		return nil
+	}
+	return r.Ack
+}
+
+func (r *SetUeSlicePairAssociationResponse) GetAck() *Ack {
+	if r == nil {
+		return nil
+	}
+	return r.Ack
+}
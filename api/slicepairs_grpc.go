@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// SlicePairsServiceName is the full name of the slice pair service
+const SlicePairsServiceName = "onos.rsm.SlicePairs"
+
+// SlicePairsServer is the server API for the slice pair service
+type SlicePairsServer interface {
+	CreateSlicePair(context.Context, *CreateSlicePairRequest) (*CreateSlicePairResponse, error)
+	UpdateSlicePair(context.Context, *UpdateSlicePairRequest) (*UpdateSlicePairResponse, error)
+	DeleteSlicePair(context.Context, *DeleteSlicePairRequest) (*DeleteSlicePairResponse, error)
+	GetSlicePairs(context.Context, *GetSlicePairsRequest) (*GetSlicePairsResponse, error)
+	SetUeSlicePairAssociation(context.Context, *SetUeSlicePairAssociationRequest) (*SetUeSlicePairAssociationResponse, error)
+}
+
+// RegisterSlicePairsServer registers the slice pair service on the gRPC server
+func RegisterSlicePairsServer(s *grpc.Server, srv SlicePairsServer) {
+	s.RegisterService(&slicePairsServiceDesc, srv)
+}
+
+func createSlicePairHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateSlicePairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SlicePairsServer).CreateSlicePair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + SlicePairsServiceName + "/CreateSlicePair",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SlicePairsServer).CreateSlicePair(ctx, req.(*CreateSlicePairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func updateSlicePairHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateSlicePairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SlicePairsServer).UpdateSlicePair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + SlicePairsServiceName + "/UpdateSlicePair",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SlicePairsServer).UpdateSlicePair(ctx, req.(*UpdateSlicePairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func deleteSlicePairHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteSlicePairRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SlicePairsServer).DeleteSlicePair(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + SlicePairsServiceName + "/DeleteSlicePair",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SlicePairsServer).DeleteSlicePair(ctx, req.(*DeleteSlicePairRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func getSlicePairsHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSlicePairsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SlicePairsServer).GetSlicePairs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + SlicePairsServiceName + "/GetSlicePairs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SlicePairsServer).GetSlicePairs(ctx, req.(*GetSlicePairsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func setUeSlicePairAssociationHandler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetUeSlicePairAssociationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SlicePairsServer).SetUeSlicePairAssociation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + SlicePairsServiceName + "/SetUeSlicePairAssociation",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SlicePairsServer).SetUeSlicePairAssociation(ctx, req.(*SetUeSlicePairAssociationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var slicePairsServiceDesc = grpc.ServiceDesc{
+	ServiceName: SlicePairsServiceName,
+	HandlerType: (*SlicePairsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateSlicePair",
+			Handler:    createSlicePairHandler,
+		},
+		{
+			MethodName: "UpdateSlicePair",
+			Handler:    updateSlicePairHandler,
+		},
+		{
+			MethodName: "DeleteSlicePair",
+			Handler:    deleteSlicePairHandler,
+		},
+		{
+			MethodName: "GetSlicePairs",
+			Handler:    getSlicePairsHandler,
+		},
+		{
+			MethodName: "SetUeSlicePairAssociation",
+			Handler:    setUeSlicePairAssociationHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "onos-rsm/api/slicepairs",
+}
+
+// SlicePairsClient is the client API for the slice pair service
+type SlicePairsClient interface {
+	CreateSlicePair(ctx context.Context, in *CreateSlicePairRequest, opts ...grpc.CallOption) (*CreateSlicePairResponse, error)
+	UpdateSlicePair(ctx context.Context, in *UpdateSlicePairRequest, opts ...grpc.CallOption) (*UpdateSlicePairResponse, error)
+	DeleteSlicePair(ctx context.Context, in *DeleteSlicePairRequest, opts ...grpc.CallOption) (*DeleteSlicePairResponse, error)
+	GetSlicePairs(ctx context.Context, in *GetSlicePairsRequest, opts ...grpc.CallOption) (*GetSlicePairsResponse, error)
+	SetUeSlicePairAssociation(ctx context.Context, in *SetUeSlicePairAssociationRequest, opts ...grpc.CallOption) (*SetUeSlicePairAssociationResponse, error)
+}
+
+// NewSlicePairsClient creates a slice pair client on the given connection
+func NewSlicePairsClient(cc *grpc.ClientConn) SlicePairsClient {
+	return &slicePairsClient{
+		cc: cc,
+	}
+}
+
+type slicePairsClient struct {
+	cc *grpc.ClientConn
+}
+
+func (c *slicePairsClient) CreateSlicePair(ctx context.Context, in *CreateSlicePairRequest, opts ...grpc.CallOption) (*CreateSlicePairResponse, error) {
+	out := new(CreateSlicePairResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+SlicePairsServiceName+"/CreateSlicePair", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *slicePairsClient) UpdateSlicePair(ctx context.Context, in *UpdateSlicePairRequest, opts ...grpc.CallOption) (*UpdateSlicePairResponse, error) {
+	out := new(UpdateSlicePairResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+SlicePairsServiceName+"/UpdateSlicePair", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *slicePairsClient) DeleteSlicePair(ctx context.Context, in *DeleteSlicePairRequest, opts ...grpc.CallOption) (*DeleteSlicePairResponse, error) {
+	out := new(DeleteSlicePairResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+SlicePairsServiceName+"/DeleteSlicePair", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *slicePairsClient) GetSlicePairs(ctx context.Context, in *GetSlicePairsRequest, opts ...grpc.CallOption) (*GetSlicePairsResponse, error) {
+	out := new(GetSlicePairsResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+SlicePairsServiceName+"/GetSlicePairs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *slicePairsClient) SetUeSlicePairAssociation(ctx context.Context, in *SetUeSlicePairAssociationRequest, opts ...grpc.CallOption) (*SetUeSlicePairAssociationResponse, error) {
+	out := new(SetUeSlicePairAssociationResponse)
+	opts = append([]grpc.CallOption{grpc.CallContentSubtype(CodecName)}, opts...)
+	err := c.cc.Invoke(ctx, "/"+SlicePairsServiceName+"/SetUeSlicePairAssociation", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import "time"
+
+// SubscriptionState is the state of the E2 subscription of a node
+type SubscriptionState string
+
+const (
+	// SubscriptionSubscribing is a subscription being created
+	SubscriptionSubscribing SubscriptionState = "subscribing"
+	// SubscriptionActive is a subscription whose indications are being received
+	SubscriptionActive SubscriptionState = "active"
+	// SubscriptionBackoff is a failed subscription waiting to be created again
+	SubscriptionBackoff SubscriptionState = "backoff"
+)
+
+// SubscriptionHealth is the health of the E2 subscription of a node
+type SubscriptionHealth struct {
+	E2NodeID    string `json:"e2NodeId"`
+	TriggerType string `json:"triggerType"`
+	// ReportingPeriodMs is the reporting period of a periodic metrics subscription
+	ReportingPeriodMs int32             `json:"reportingPeriodMs,omitempty"`
+	State             SubscriptionState `json:"state"`
+	// Since is the time the subscription entered its state
+	Since time.Time `json:"since"`
+	// Failures is the number of failures since the subscription was last active for long enough
+	Failures int `json:"failures"`
+	// Resubscriptions is the total number of times the subscription was created again
+	Resubscriptions int    `json:"resubscriptions"`
+	LastError       string `json:"lastError,omitempty"`
+	// NextAttempt is the time of the next attempt of a subscription in backoff
+	NextAttempt *time.Time `json:"nextAttempt,omitempty"`
+}
+
+// GetSubscriptionHealthRequest requests the subscription health, optionally of a single E2 node
+type GetSubscriptionHealthRequest struct {
+	E2NodeID string `json:"e2NodeId,omitempty"`
+}
+
+// GetSubscriptionHealthResponse is the subscription health of the E2 nodes
+type GetSubscriptionHealthResponse struct {
+	Subscriptions []SubscriptionHealth `json:"subscriptions"`
+}
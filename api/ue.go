@@ -0,0 +1,9 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+// GlobalUeIDPrefix marks the UE ID of an association request as the global UE ID of the UE in UE-NIB,
+// e.g. "global:1d2a9e2c"; the type of such a UE ID is ignored
+const GlobalUeIDPrefix = "global:"
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package main
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/northbound"
+	"github.com/onosproject/onos-rsm/pkg/recorder"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+)
+
+// replayCommands are the commands the replayed E2 nodes accept
+var replayCommands = []e2sm_rsm.E2SmRsmCommand{
+	e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE,
+	e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE,
+	e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE,
+	e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE,
+}
+
+// controlReplayer passes the recorded control messages to the slicing manager as NBI requests;
+// the control messages the slicing manager sends are acknowledged with the recorded outcome
+type controlReplayer struct {
+	ctrlDispatcher *e2.ControlDispatcher
+	rsmMsgCh       chan *northbound.RsmMsg
+	// rnibStore is the in-memory R-NIB the E2 nodes are added to; nil with onos-topo
+	rnibStore *rnib.MemoryStore
+	nodes     map[string]bool
+	// outcome is the recorded outcome of the control message being replayed
+	outcome recorder.Record
+}
+
+func newControlReplayer(ctrlDispatcher *e2.ControlDispatcher, rsmMsgCh chan *northbound.RsmMsg, rnibStore *rnib.MemoryStore) *controlReplayer {
+	return &controlReplayer{
+		ctrlDispatcher: ctrlDispatcher,
+		rsmMsgCh:       rsmMsgCh,
+		rnibStore:      rnibStore,
+		nodes:          make(map[string]bool),
+	}
+}
+
+// replay sends the NBI request of the recorded control message to the slicing manager and waits for its result
+func (r *controlReplayer) replay(ctx context.Context, codec e2.Codec, record recorder.Record, header *e2sm_rsm.E2SmRsmControlHeader, message *e2sm_rsm.E2SmRsmControlMessage) error {
+	if !r.nodes[record.NodeID] {
+		if err := r.addNode(ctx, topoapi.ID(record.NodeID)); err != nil {
+			return err
+		}
+		r.ctrlDispatcher.Register(ctx, topoapi.ID(record.NodeID), codec, replayCommands, r.acknowledge)
+		r.nodes[record.NodeID] = true
+	}
+	request, err := nbiRequest(record.NodeID, header, message)
+	if err != nil {
+		return err
+	}
+
+	r.outcome = record
+	ackCh := make(chan northbound.Ack)
+	r.rsmMsgCh <- &northbound.RsmMsg{
+		NodeID:  topoapi.ID(record.NodeID),
+		Message: request,
+		AckCh:   ackCh,
+	}
+	ack := <-ackCh
+	if !ack.Success {
+		return fmt.Errorf("%v", ack.Reason)
+	}
+	return nil
+}
+
+// addNode adds the E2 node to the in-memory R-NIB, which does not have it unless it was loaded from its snapshot
+func (r *controlReplayer) addNode(ctx context.Context, nodeID topoapi.ID) error {
+	if r.rnibStore == nil {
+		return nil
+	}
+	if _, err := r.rnibStore.Get(ctx, nodeID); err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+	return r.rnibStore.Create(ctx, &topoapi.Object{
+		ID:   nodeID,
+		Type: topoapi.Object_ENTITY,
+		Obj: &topoapi.Object_Entity{
+			Entity: &topoapi.Entity{
+				KindID: topoapi.E2NODE,
+			},
+		},
+	})
+}
+
+// acknowledge is the control handler of the replayed E2 nodes
+func (r *controlReplayer) acknowledge(_ context.Context, _ topoapi.ID, msg *e2.CtrlMsg) {
+	if r.outcome.Error != "" {
+		msg.AckCh <- e2.NewAck(nil, goerrors.New(r.outcome.Error))
+		return
+	}
+	msg.AckCh <- e2.NewAck(&e2api.ControlOutcome{Payload: r.outcome.OutcomePayload}, nil)
+}
+
+// nbiRequest returns the NBI request the slicing manager sends the control message for
+func nbiRequest(nodeID string, header *e2sm_rsm.E2SmRsmControlHeader, message *e2sm_rsm.E2SmRsmControlMessage) (interface{}, error) {
+	switch header.GetRsmCommand() {
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE:
+		config := message.GetSliceCreate()
+		return &rsmapi.CreateSliceRequest{
+			E2NodeId:      nodeID,
+			SliceId:       strconv.FormatInt(config.GetSliceId().GetValue(), 10),
+			SchedulerType: rsmapi.SchedulerType(config.GetSliceConfigParameters().GetSchedulerType()),
+			Weight:        strconv.Itoa(int(config.GetSliceConfigParameters().GetWeight())),
+			SliceType:     rsmapi.SliceType(config.GetSliceType()),
+		}, nil
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE:
+		config := message.GetSliceUpdate()
+		return &rsmapi.UpdateSliceRequest{
+			E2NodeId:      nodeID,
+			SliceId:       strconv.FormatInt(config.GetSliceId().GetValue(), 10),
+			SchedulerType: rsmapi.SchedulerType(config.GetSliceConfigParameters().GetSchedulerType()),
+			Weight:        strconv.Itoa(int(config.GetSliceConfigParameters().GetWeight())),
+			SliceType:     rsmapi.SliceType(config.GetSliceType()),
+		}, nil
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE:
+		sliceDelete := message.GetSliceDelete()
+		return &rsmapi.DeleteSliceRequest{
+			E2NodeId:  nodeID,
+			SliceId:   strconv.FormatInt(sliceDelete.GetSliceId().GetValue(), 10),
+			SliceType: rsmapi.SliceType(sliceDelete.GetSliceType()),
+		}, nil
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE:
+		return associationRequest(nodeID, message.GetSliceAssociate())
+	}
+	return nil, fmt.Errorf("command %v is not replayed", header.GetRsmCommand())
+}
+
+func associationRequest(nodeID string, assoc *e2sm_rsm.SliceAssociate) (*rsmapi.SetUeSliceAssociationRequest, error) {
+	ueID := &rsmapi.UeId{}
+	switch {
+	case assoc.GetUeId().GetDuUeF1ApId() != nil:
+		ueID.Type = rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID
+		ueID.UeId = strconv.FormatInt(assoc.GetUeId().GetDuUeF1ApId().GetValue(), 10)
+	case assoc.GetUeId().GetCuUeF1ApId() != nil:
+		ueID.Type = rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID
+		ueID.UeId = strconv.FormatInt(assoc.GetUeId().GetCuUeF1ApId().GetValue(), 10)
+	case assoc.GetUeId().GetRanUeNgapId() != nil:
+		ueID.Type = rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID
+		ueID.UeId = strconv.FormatInt(assoc.GetUeId().GetRanUeNgapId().GetValue(), 10)
+	case assoc.GetUeId().GetAmfUeNgapId() != nil:
+		ueID.Type = rsmapi.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID
+		ueID.UeId = strconv.FormatInt(assoc.GetUeId().GetAmfUeNgapId().GetValue(), 10)
+	case assoc.GetUeId().GetEnbUeS1ApId() != nil:
+		ueID.Type = rsmapi.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID
+		ueID.UeId = strconv.FormatInt(int64(assoc.GetUeId().GetEnbUeS1ApId().GetValue()), 10)
+	default:
+		return nil, fmt.Errorf("UE association without UE ID")
+	}
+
+	// several bearers are associated with a DRB ID list
+	drbIDs := make([]string, 0, len(assoc.GetBearerId()))
+	for _, bearerID := range assoc.GetBearerId() {
+		drbID := bearerID.GetDrbId()
+		if drbID.GetFourGdrbId() != nil {
+			drbIDs = append(drbIDs, strconv.Itoa(int(drbID.GetFourGdrbId().GetValue())))
+		} else {
+			drbIDs = append(drbIDs, strconv.Itoa(int(drbID.GetFiveGdrbId().GetValue())))
+		}
+	}
+
+	req := &rsmapi.SetUeSliceAssociationRequest{
+		E2NodeId:  nodeID,
+		UeId:      []*rsmapi.UeId{ueID},
+		DlSliceId: strconv.FormatInt(assoc.GetDownLinkSliceId().GetValue(), 10),
+		DrbId:     strings.Join(drbIDs, ","),
+	}
+	if assoc.GetUplinkSliceId() != nil {
+		req.UlSliceId = strconv.FormatInt(assoc.GetUplinkSliceId().GetValue(), 10)
+	}
+	return req, nil
+}
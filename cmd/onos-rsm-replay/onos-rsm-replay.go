@@ -0,0 +1,189 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// onos-rsm-replay decodes a recording of the messages onos-rsm exchanged with the E2 nodes and prints them as JSON lines.
+// With -apply, the indications are processed again by the monitor and the control messages are sent again as
+// NBI requests to the slicing manager, whose E2 control messages are acknowledged with the recorded outcomes.
+// By default, -apply runs against the in-memory R-NIB and UE-NIB; -nib onos runs it against onos-topo and onos-uenib.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"time"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/onosproject/onos-rsm/pkg/northbound"
+	"github.com/onosproject/onos-rsm/pkg/recorder"
+	"github.com/onosproject/onos-rsm/pkg/slicing"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+var log = logging.GetLogger()
+
+// decodedRecord is a record with its header and message decoded
+type decodedRecord struct {
+	Kind                recorder.Kind   `json:"kind"`
+	Timestamp           time.Time       `json:"timestamp"`
+	NodeID              string          `json:"nodeId"`
+	ServiceModelVersion string          `json:"serviceModelVersion"`
+	Subscription        string          `json:"subscription,omitempty"`
+	Header              json.RawMessage `json:"header,omitempty"`
+	Message             json.RawMessage `json:"message,omitempty"`
+	OutcomePayload      []byte          `json:"outcomePayload,omitempty"`
+	Error               string          `json:"error,omitempty"`
+	DecodeError         string          `json:"decodeError,omitempty"`
+	ReplayError         string          `json:"replayError,omitempty"`
+}
+
+func main() {
+	recordingPath := flag.String("recording", "", "path to the recording file")
+	smVersion := flag.String("smVersion", "v1", "Service model version of the records which do not have one")
+	apply := flag.Bool("apply", false, "process the indications and the control messages again against the R-NIB and UE-NIB")
+	nibBackend := flag.String("nib", "memory", "R-NIB and UE-NIB of -apply: memory, or onos for onos-topo and onos-uenib")
+	nibSnapshotDir := flag.String("nibSnapshotDir", "", "directory the in-memory R-NIB and UE-NIB are loaded from and saved to (empty to keep them in memory only)")
+	keyPath := flag.String("keyPath", "", "path to client private key")
+	certPath := flag.String("certPath", "", "path to client certificate")
+	uenibHost := flag.String("uenibHost", "onos-uenib:5150", "UENIB Host address")
+	ackTimer := flag.Int("ackTimer", 5, "ACK timer of the replayed control messages (seconds)")
+	flag.Parse()
+
+	if *recordingPath == "" {
+		log.Fatal("-recording is required")
+	}
+
+	ctx := context.Background()
+	monitors := make(map[string]*monitoring.Monitor)
+	var rnibClient rnib.TopoClient
+	var uenibClient uenib.Client
+	var identityResolver *monitoring.UEIdentityResolver
+	var controls *controlReplayer
+	if *apply {
+		var rnibStore *rnib.MemoryStore
+		if *nibBackend == "onos" {
+			var err error
+			rnibClient, err = rnib.NewClient(ctx)
+			if err != nil {
+				log.Fatal(err)
+			}
+			uenibClient, err = uenib.NewClient(ctx, *certPath, *keyPath, *uenibHost)
+			if err != nil {
+				log.Fatal(err)
+			}
+		} else {
+			var rnibSnapshot, uenibSnapshot string
+			if *nibSnapshotDir != "" {
+				rnibSnapshot = filepath.Join(*nibSnapshotDir, "rnib.json")
+				uenibSnapshot = filepath.Join(*nibSnapshotDir, "uenib.json")
+			}
+			var err error
+			rnibStore, err = rnib.NewMemoryStore(rnibSnapshot)
+			if err != nil {
+				log.Fatal(err)
+			}
+			uenibStore, err := uenib.NewMemoryStore(uenibSnapshot)
+			if err != nil {
+				log.Fatal(err)
+			}
+			rnibClient = rnib.NewMemoryClient(ctx, rnibStore)
+			uenibClient = uenib.NewMemoryClient(uenibStore)
+		}
+		identityResolver = monitoring.NewUEIdentityResolver(uenibClient)
+
+		ctrlDispatcher := e2.NewControlDispatcher()
+		rsmMsgCh := make(chan *northbound.RsmMsg)
+		slicingManager := slicing.NewManager(
+			slicing.WithRnibClient(rnibClient),
+			slicing.WithUenibClient(uenibClient),
+			slicing.WithControlDispatcher(ctrlDispatcher),
+			slicing.WithNbiReqChs(rsmMsgCh),
+			slicing.WithAckTimer(*ackTimer),
+			slicing.WithUEIdentityResolver(identityResolver),
+		)
+		go slicingManager.Run(ctx)
+		controls = newControlReplayer(ctrlDispatcher, rsmMsgCh, rnibStore)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	err := recorder.Read(*recordingPath, func(record recorder.Record) error {
+		if record.ServiceModelVersion == "" {
+			record.ServiceModelVersion = *smVersion
+		}
+		decoded := decodedRecord{
+			Kind:                record.Kind,
+			Timestamp:           record.Timestamp,
+			NodeID:              record.NodeID,
+			ServiceModelVersion: record.ServiceModelVersion,
+			Subscription:        record.Subscription,
+			OutcomePayload:      record.OutcomePayload,
+			Error:               record.Error,
+		}
+
+		codec, err := e2.GetCodec(e2.ServiceModelVersion(record.ServiceModelVersion))
+		if err != nil {
+			decoded.DecodeError = err.Error()
+			return encoder.Encode(decoded)
+		}
+
+		var header, message proto.Message
+		switch record.Kind {
+		case recorder.KindControl:
+			var ctrlHeader *e2sm_rsm.E2SmRsmControlHeader
+			var ctrlMessage *e2sm_rsm.E2SmRsmControlMessage
+			ctrlHeader, ctrlMessage, err = codec.DecodeControl(record.Header, record.Payload)
+			header, message = ctrlHeader, ctrlMessage
+			if err == nil && *apply {
+				if replayErr := controls.replay(ctx, codec, record, ctrlHeader, ctrlMessage); replayErr != nil {
+					decoded.ReplayError = replayErr.Error()
+				}
+			}
+		case recorder.KindIndication:
+			header, message, err = decodeIndication(codec, record)
+			if err == nil && *apply {
+				monitor, ok := monitors[record.NodeID]
+				if !ok {
+					monitor = monitoring.NewMonitor(
+						monitoring.WithNodeID(topoapi.ID(record.NodeID)),
+						monitoring.WithRNIBClient(rnibClient),
+						monitoring.WithUENIBClient(uenibClient),
+						monitoring.WithUEIdentityResolver(identityResolver),
+						monitoring.WithIndicationDecoder(codec))
+					monitors[record.NodeID] = monitor
+				}
+				if replayErr := monitor.ProcessIndication(ctx, e2api.Indication{Header: record.Header, Payload: record.Payload}); replayErr != nil {
+					decoded.ReplayError = replayErr.Error()
+				}
+			}
+		}
+		if err != nil {
+			decoded.DecodeError = err.Error()
+			return encoder.Encode(decoded)
+		}
+		if decoded.Header, err = protojson.Marshal(header); err != nil {
+			return err
+		}
+		if decoded.Message, err = protojson.Marshal(message); err != nil {
+			return err
+		}
+		return encoder.Encode(decoded)
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func decodeIndication(codec e2.Codec, record recorder.Record) (proto.Message, proto.Message, error) {
+	return codec.DecodeIndication(e2api.Indication{Header: record.Header, Payload: record.Payload})
+}
@@ -6,6 +6,9 @@ package main
 
 import (
 	"flag"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/onosproject/onos-lib-go/pkg/certs"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
@@ -26,8 +29,24 @@ func main() {
 	uenibHost := flag.String("uenibHost", "onos-uenib:5150", "UENIB Host address")
 	appID := flag.String("appID", "onos-rsm", "ONOS-RSM xAPP ID")
 	ackTimer := flag.Int("ackTimer", 5, "ACK timer (seconds)")
-
-	ready := make(chan bool)
+	ctrlRetryDeadline := flag.Int("ctrlRetryDeadline", 10000, "time after which a failed control message is not sent again (milliseconds); 0 disables the retries")
+	ctrlRetryInitialBackoff := flag.Int("ctrlRetryInitialBackoff", 200, "backoff before the first retry of a control message (milliseconds)")
+	ctrlRetryMaxBackoff := flag.Int("ctrlRetryMaxBackoff", 2000, "maximum backoff between the retries of a control message (milliseconds)")
+	compensationPolicy := flag.String("compensationPolicy", "retry", "action when a NIB write fails after a successful E2 control: retry or rollback")
+	journalPath := flag.String("journalPath", "/tmp/onos-rsm/journal.json", "path to the journal of NIB writes to be retried (empty to keep it in memory)")
+	reconnectPolicy := flag.String("reconnectPolicy", "restore", "action on the last-known slices when a DU reconnects: restore or drop")
+	driftInterval := flag.Int("driftInterval", 60, "drift detection period (seconds); 0 disables the periodic detection")
+	driftAutoRepair := flag.Bool("driftAutoRepair", false, "repair the drift found in two consecutive detections")
+	recordPath := flag.String("recordPath", "", "path to the recording of the E2 control messages and indications (empty to disable)")
+	recordMaxSize := flag.Int64("recordMaxSize", 100, "size of the recording file at which it is rotated (MB)")
+	recordMaxFiles := flag.Int("recordMaxFiles", 5, "number of rotated recording files to keep")
+	nibBackend := flag.String("nib", "onos", "R-NIB and UE-NIB implementation: onos, or memory to run standalone without onos-topo and onos-uenib")
+	nibSnapshotDir := flag.String("nibSnapshotDir", "", "directory the in-memory R-NIB and UE-NIB are saved to and loaded from (empty to keep them in memory only)")
+	kpiRetention := flag.Int("kpiRetention", 3600, "time the slice metrics are kept for the KPI queries (seconds)")
+	kpiMaxSamples := flag.Int("kpiMaxSamples", 10000, "number of slice metric samples kept per DU, slice type and UE")
+	handoverGracePeriod := flag.Int("handoverGracePeriod", 30, "time a UE handed out is kept in UE-NIB for its hand-in (seconds)")
+	metricsPort := flag.Int("metricsPort", 7070, "port of the Prometheus /metrics endpoint; 0 disables it")
+	shutdownGracePeriod := flag.Int("shutdownGracePeriod", 30, "time to stop gracefully on SIGTERM or SIGINT (seconds)")
 
 	flag.Parse()
 
@@ -50,9 +69,41 @@ func main() {
 		UenibHost:   *uenibHost,
 		AppID:       *appID,
 		AckTimer:    *ackTimer,
+
+		CtrlRetryDeadline:       *ctrlRetryDeadline,
+		CtrlRetryInitialBackoff: *ctrlRetryInitialBackoff,
+		CtrlRetryMaxBackoff:     *ctrlRetryMaxBackoff,
+
+		CompensationPolicy: *compensationPolicy,
+		JournalPath:        *journalPath,
+		ReconnectPolicy:    *reconnectPolicy,
+		DriftInterval:      *driftInterval,
+		DriftAutoRepair:    *driftAutoRepair,
+
+		ShutdownGracePeriod: *shutdownGracePeriod,
+
+		RecordPath:     *recordPath,
+		RecordMaxSize:  *recordMaxSize * 1024 * 1024,
+		RecordMaxFiles: *recordMaxFiles,
+
+		NibBackend:     *nibBackend,
+		NibSnapshotDir: *nibSnapshotDir,
+
+		KpiRetention:  *kpiRetention,
+		KpiMaxSamples: *kpiMaxSamples,
+
+		HandoverGracePeriod: *handoverGracePeriod,
+
+		MetricsPort: *metricsPort,
 	}
 
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
 	mgr := manager.NewManager(cfg)
 	mgr.Run()
-	<-ready
+
+	sig := <-sigCh
+	log.Infof("Received %v, stopping onos-rsm", sig)
+	mgr.Close()
 }
@@ -13,15 +13,21 @@ import (
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
+	"github.com/onosproject/onos-rsm/pkg/recorder"
 )
 
 var log = logging.GetLogger()
 
 // NewBroker creates a new subscription stream broker
-func NewBroker() Broker {
+func NewBroker(opts ...Option) Broker {
+	options := Options{}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
 	return &streamBroker{
-		subs:    make(map[e2api.ChannelID]Stream),
-		streams: make(map[StreamID]Stream),
+		subs:     make(map[e2api.ChannelID]Stream),
+		streams:  make(map[StreamID]Stream),
+		recorder: options.Recorder,
 	}
 }
 
@@ -48,6 +54,21 @@ type Broker interface {
 
 	// ChannelIDs get all of subscription channel IDs
 	ChannelIDs() []e2api.ChannelID
+
+	// CloseNodeStreams closes the subscription Streams of a node
+	// The subscriptions are deleted if possible, but the Streams are closed even if the node cannot be reached.
+	CloseNodeStreams(ctx context.Context, nodeID e2client.NodeID) error
+
+	// StreamDepths returns the number of buffered indications of each Stream
+	StreamDepths() []StreamDepth
+}
+
+// StreamDepth is the number of indications buffered in a Stream
+type StreamDepth struct {
+	StreamID         StreamID
+	NodeID           e2client.NodeID
+	SubscriptionName string
+	Depth            int
 }
 
 type streamBroker struct {
@@ -55,12 +76,13 @@ type streamBroker struct {
 	streams  map[StreamID]Stream
 	streamID StreamID
 	mu       sync.RWMutex
+	recorder *recorder.Recorder
 }
 
 func (b *streamBroker) ChannelIDs() []e2api.ChannelID {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	channelIDs := make([]e2api.ChannelID, len(b.subs))
+	channelIDs := make([]e2api.ChannelID, 0, len(b.subs))
 	for channelID := range b.subs {
 		channelIDs = append(channelIDs, channelID)
 	}
@@ -108,6 +130,42 @@ func (b *streamBroker) CloseStream(ctx context.Context, id e2api.ChannelID) (Str
 	return stream, stream.Close()
 }
 
+func (b *streamBroker) CloseNodeStreams(ctx context.Context, nodeID e2client.NodeID) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var err error
+	for channelID, stream := range b.subs {
+		if stream.Node().ID() != nodeID {
+			continue
+		}
+		if e := stream.Node().Unsubscribe(ctx, stream.SubscriptionName()); e != nil {
+			log.Warnf("Failed to delete subscription '%s' of disconnected node %s: %v", stream.SubscriptionName(), nodeID, e)
+		}
+		delete(b.subs, channelID)
+		delete(b.streams, stream.StreamID())
+		if e := stream.Close(); e != nil {
+			err = e
+		}
+		log.Infof("Closed stream %d for subscription '%s' of node %s", stream.StreamID(), channelID, nodeID)
+	}
+	return err
+}
+
+func (b *streamBroker) StreamDepths() []StreamDepth {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	depths := make([]StreamDepth, 0, len(b.streams))
+	for _, stream := range b.streams {
+		depths = append(depths, StreamDepth{
+			StreamID:         stream.StreamID(),
+			NodeID:           stream.Node().ID(),
+			SubscriptionName: stream.SubscriptionName(),
+			Depth:            stream.Len(),
+		})
+	}
+	return depths
+}
+
 func (b *streamBroker) GetWriter(id StreamID) (StreamWriter, error) {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
@@ -115,9 +173,32 @@ func (b *streamBroker) GetWriter(id StreamID) (StreamWriter, error) {
 	if !ok {
 		return nil, errors.NewNotFound("stream %d not found", id)
 	}
+	if b.recorder != nil {
+		return &recordingWriter{
+			StreamWriter: stream,
+			recorder:     b.recorder,
+		}, nil
+	}
 	return stream, nil
 }
 
+// recordingWriter records the indications written to the stream
+type recordingWriter struct {
+	StreamWriter
+	recorder *recorder.Recorder
+}
+
+func (w *recordingWriter) Send(indication e2api.Indication) error {
+	w.recorder.Record(recorder.Record{
+		Kind:         recorder.KindIndication,
+		NodeID:       string(w.Node().ID()),
+		Subscription: w.SubscriptionName(),
+		Header:       indication.Header,
+		Payload:      indication.Payload,
+	})
+	return w.StreamWriter.Send(indication)
+}
+
 func (b *streamBroker) Close() error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package broker
+
+import "github.com/onosproject/onos-rsm/pkg/recorder"
+
+type Options struct {
+	Recorder *recorder.Recorder
+}
+
+type Option interface {
+	apply(*Options)
+}
+
+type funcOption struct {
+	f func(*Options)
+}
+
+func (f funcOption) apply(options *Options) {
+	f.f(options)
+}
+
+func newOption(f func(*Options)) Option {
+	return funcOption{
+		f: f,
+	}
+}
+
+// WithRecorder sets the recorder of the indications written to the streams
+func WithRecorder(r *recorder.Recorder) Option {
+	return newOption(func(options *Options) {
+		options.Recorder = r
+	})
+}
@@ -38,6 +38,9 @@ type StreamWriter interface {
 	// it will be placed in a bounded memory buffer. If the buffer is full, an Unavailable error will be returned.
 	// This method is thread-safe.
 	Send(indication e2api.Indication) error
+
+	// Len returns the number of indications in the buffer
+	Len() int
 }
 
 // StreamID is a stream identifier
@@ -197,6 +200,12 @@ func (s *bufferedWriter) Send(ind e2api.Indication) error {
 	return nil
 }
 
+func (s *bufferedWriter) Len() int {
+	s.cond.L.Lock()
+	defer s.cond.L.Unlock()
+	return s.buffer.Len()
+}
+
 func (s *bufferedWriter) Close() error {
 	s.cond.L.Lock()
 	defer s.cond.L.Unlock()
@@ -0,0 +1,434 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+var log = logging.GetLogger()
+
+func NewDetector(opts ...Option) *Detector {
+	options := Options{}
+	for _, opt := range opts {
+		opt.apply(&options)
+	}
+
+	return &Detector{
+		rnibClient:  options.App.RnibClient,
+		uenibClient: options.App.UenibClient,
+		interval:    options.App.Interval,
+		autoRepair:  options.App.AutoRepair,
+		repairCh:    options.App.RepairCh,
+		attached:    make(map[attachedKey]*attachedUE),
+		detached:    make([]*detachedUE, 0),
+		previous:    make(map[string]bool),
+	}
+}
+
+// Detector compares the slices in onos-topo, the slice lists in UE-NIB and the UEs reported in RSM indications
+type Detector struct {
+	rnibClient  rnib.TopoClient
+	uenibClient uenib.Client
+	interval    time.Duration
+	autoRepair  bool
+	repairCh    chan *Repair
+	mu          sync.Mutex
+	attached    map[attachedKey]*attachedUE
+	detached    []*detachedUE
+	detectMu    sync.Mutex
+	latest      *api.DriftReport
+	previous    map[string]bool
+}
+
+func (d *Detector) Run(ctx context.Context) {
+	if d.interval <= 0 {
+		log.Info("Periodic drift detection is disabled")
+		return
+	}
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report := d.Detect(ctx)
+			if len(report.Findings) > 0 {
+				log.Warnf("Found %d inconsistencies between onos-topo, UE-NIB and RSM indications", len(report.Findings))
+			}
+		}
+	}
+}
+
+// LatestReport returns the report of the latest detection; it runs the detection if there is none yet
+func (d *Detector) LatestReport(ctx context.Context) *api.DriftReport {
+	d.detectMu.Lock()
+	latest := d.latest
+	d.detectMu.Unlock()
+	if latest == nil {
+		return d.Detect(ctx)
+	}
+	return latest
+}
+
+// Detect runs the drift detection; if auto-repair is enabled,
+// findings which were also found in the previous detection are repaired
+func (d *Detector) Detect(ctx context.Context) *api.DriftReport {
+	d.detectMu.Lock()
+	defer d.detectMu.Unlock()
+
+	s, ok := d.detect(ctx, nil)
+	if !ok {
+		d.latest = s.report
+		return s.report
+	}
+	current := make(map[string]bool)
+	confirmed := make(map[string]bool)
+	for _, f := range s.report.Findings {
+		key := findingKey(&f)
+		current[key] = true
+		if d.autoRepair && d.previous[key] && s.repairable[key] {
+			confirmed[key] = true
+		}
+	}
+	if len(confirmed) > 0 {
+		repaired, errs := d.repair(ctx, confirmed)
+		for i := range s.report.Findings {
+			s.report.Findings[i].Repaired = repaired[findingKey(&s.report.Findings[i])]
+		}
+		s.report.Errors = append(s.report.Errors, errs...)
+	}
+
+	d.previous = current
+	d.latest = s.report
+	return s.report
+}
+
+// repair repairs the findings with the given keys. The repair runs on the repair channel's consumer, if any,
+// so that its NIB writes are serialized with the NIB writes of the slicing manager.
+func (d *Detector) repair(ctx context.Context, keys map[string]bool) (map[string]bool, []string) {
+	r := &Repair{
+		run: func(ctx context.Context) *snapshot {
+			s, _ := d.detect(ctx, keys)
+			return s
+		},
+		done: make(chan *snapshot, 1),
+	}
+	if d.repairCh == nil {
+		r.Run(ctx)
+	} else {
+		select {
+		case d.repairCh <- r:
+		case <-ctx.Done():
+			return nil, []string{fmt.Sprintf("failed to repair: %v", ctx.Err())}
+		}
+	}
+
+	var s *snapshot
+	select {
+	case s = <-r.done:
+	case <-ctx.Done():
+		return nil, []string{fmt.Sprintf("failed to repair: %v", ctx.Err())}
+	}
+	repaired := make(map[string]bool)
+	for _, f := range s.report.Findings {
+		if f.Repaired {
+			repaired[findingKey(&f)] = true
+		}
+	}
+	return repaired, s.report.Errors
+}
+
+// Repair is a drift repair handed over the repair channel
+type Repair struct {
+	run  func(ctx context.Context) *snapshot
+	done chan *snapshot
+}
+
+// Run detects the drift again in the current NIB state and repairs the findings confirmed by the detector
+func (r *Repair) Run(ctx context.Context) {
+	r.done <- r.run(ctx)
+}
+
+// detect compares the NIBs and repairs the findings with the given keys; it returns false if the NIBs could not be read
+func (d *Detector) detect(ctx context.Context, keys map[string]bool) (*snapshot, bool) {
+	s := &snapshot{
+		report: &api.DriftReport{
+			Time:     time.Now(),
+			Findings: make([]api.DriftFinding, 0),
+			Errors:   make([]string, 0),
+		},
+		repairable: make(map[string]bool),
+		dirtyItems: make(map[topoapi.ID]map[*topoapi.RSMSlicingItem]bool),
+		dirtyUEs:   make(map[*uenib_api.RsmUeInfo]bool),
+		deleteUEs:  make(map[string]bool),
+	}
+
+	items, err := d.rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
+	if err != nil {
+		s.report.Errors = append(s.report.Errors, fmt.Sprintf("failed to get slices from onos-topo: %v", err))
+		return s, false
+	}
+	ues, err := d.uenibClient.GetUEs(ctx)
+	if err != nil {
+		s.report.Errors = append(s.report.Errors, fmt.Sprintf("failed to get UEs from UE-NIB: %v", err))
+		return s, false
+	}
+	s.items = items
+	s.ues = ues
+
+	repair := func(f *api.DriftFinding, action func() bool) {
+		key := findingKey(f)
+		s.repairable[key] = action != nil
+		if keys[key] && action != nil {
+			f.Repaired = action()
+		}
+		s.report.Findings = append(s.report.Findings, *f)
+	}
+
+	d.checkTopoAssociations(s, repair)
+	d.checkUenibAssociations(s, repair)
+	d.checkIndications(s, repair)
+
+	for nodeID, dirty := range s.dirtyItems {
+		for item := range dirty {
+			if err := d.rnibClient.UpdateRsmSliceItemAspect(ctx, nodeID, item); err != nil {
+				s.report.Errors = append(s.report.Errors, fmt.Sprintf("failed to repair slice %v on node %v: %v", item.GetID(), nodeID, err))
+			}
+		}
+	}
+	for ue := range s.dirtyUEs {
+		if s.deleteUEs[ue.GetGlobalUeID()] {
+			continue
+		}
+		if err := d.uenibClient.UpdateUE(ctx, ue); err != nil {
+			s.report.Errors = append(s.report.Errors, fmt.Sprintf("failed to repair UE %v: %v", ue.GetGlobalUeID(), err))
+		}
+	}
+	for globalUeID := range s.deleteUEs {
+		if err := d.uenibClient.DeleteUE(ctx, globalUeID); err != nil {
+			s.report.Errors = append(s.report.Errors, fmt.Sprintf("failed to delete stale UE %v: %v", globalUeID, err))
+		}
+	}
+	return s, true
+}
+
+// snapshot is the NIB state of a single detection and the NIB objects changed by the repairs
+type snapshot struct {
+	report     *api.DriftReport
+	repairable map[string]bool
+	items      map[string][]*topoapi.RSMSlicingItem
+	ues        []*uenib_api.RsmUeInfo
+	dirtyItems map[topoapi.ID]map[*topoapi.RSMSlicingItem]bool
+	dirtyUEs   map[*uenib_api.RsmUeInfo]bool
+	deleteUEs  map[string]bool
+}
+
+func (s *snapshot) markItem(nodeID topoapi.ID, item *topoapi.RSMSlicingItem) {
+	if _, ok := s.dirtyItems[nodeID]; !ok {
+		s.dirtyItems[nodeID] = make(map[*topoapi.RSMSlicingItem]bool)
+	}
+	s.dirtyItems[nodeID][item] = true
+}
+
+func (s *snapshot) findUE(duNodeID string, duUeF1apID int64) *uenib_api.RsmUeInfo {
+	for _, ue := range s.ues {
+		if ue.GetDuE2NodeId() == duNodeID && ue.GetUeIdList().GetDuUeF1apID().GetValue() == duUeF1apID {
+			return ue
+		}
+	}
+	return nil
+}
+
+func (s *snapshot) findItem(duNodeID string, sliceID string, sliceType topoapi.RSMSliceType) *topoapi.RSMSlicingItem {
+	for _, item := range s.items[duNodeID] {
+		if item.GetID() == sliceID && item.GetSliceType() == sliceType {
+			return item
+		}
+	}
+	return nil
+}
+
+// checkTopoAssociations checks that every UE listed in a slice in onos-topo exists in UE-NIB with the slice in its slice list
+func (d *Detector) checkTopoAssociations(s *snapshot, repair func(*api.DriftFinding, func() bool)) {
+	for duNodeID, items := range s.items {
+		for _, item := range items {
+			// the repairs remove entries from the list, so iterate over a copy
+			for _, ueID := range append([]*topoapi.UeIdentity(nil), item.GetUeIdList()...) {
+				duUeF1apID := ueID.GetDuUeF1apID().GetValue()
+				drbID := rnib.DrbValue(ueID.GetDrbId())
+				ue := s.findUE(duNodeID, duUeF1apID)
+				if ue == nil {
+					nodeID, item, ueID := topoapi.ID(duNodeID), item, ueID
+					repair(&api.DriftFinding{
+						Kind:        api.DriftOrphanedSliceAssociation,
+						E2NodeID:    duNodeID,
+						SliceID:     item.GetID(),
+						SliceType:   item.GetSliceType().String(),
+						DuUeF1apID:  duUeF1apID,
+						DrbID:       drbID,
+						Description: fmt.Sprintf("UE with DU UE F1AP ID %v in slice %v no longer exists in UE-NIB", duUeF1apID, item.GetID()),
+					}, func() bool {
+						for i := 0; i < len(item.UeIdList); i++ {
+							if item.UeIdList[i] == ueID {
+								item.UeIdList = append(item.UeIdList[:i], item.UeIdList[i+1:]...)
+								break
+							}
+						}
+						s.markItem(nodeID, item)
+						return true
+					})
+					continue
+				}
+
+				if hasUenibSlice(ue, duNodeID, item.GetID(), uenib_api.RSMSliceType(item.GetSliceType()), drbID) {
+					continue
+				}
+				ue, item := ue, item
+				repair(&api.DriftFinding{
+					Kind:        api.DriftMissingUEAssociation,
+					E2NodeID:    duNodeID,
+					SliceID:     item.GetID(),
+					SliceType:   item.GetSliceType().String(),
+					GlobalUeID:  ue.GetGlobalUeID(),
+					DuUeF1apID:  duUeF1apID,
+					DrbID:       drbID,
+					Description: fmt.Sprintf("UE %v is in slice %v in onos-topo but not in its slice list in UE-NIB", ue.GetGlobalUeID(), item.GetID()),
+				}, func() bool {
+					drb := uenibBearerDrbID(ue, drbID)
+					if drb == nil {
+						return false
+					}
+					ue.SliceList = append(ue.SliceList, &uenib_api.SliceInfo{
+						DuE2NodeId: duNodeID,
+						CuE2NodeId: ue.GetCuE2NodeId(),
+						ID:         item.GetID(),
+						SliceParameters: &uenib_api.RSMSliceParameters{
+							SchedulerType: uenib_api.RSMSchedulerType(item.GetSliceParameters().GetSchedulerType()),
+							Weight:        item.GetSliceParameters().GetWeight(),
+							QosLevel:      item.GetSliceParameters().GetQosLevel(),
+						},
+						SliceType: uenib_api.RSMSliceType(item.GetSliceType()),
+						DrbId:     drb,
+					})
+					s.dirtyUEs[ue] = true
+					return true
+				})
+			}
+		}
+	}
+}
+
+// checkUenibAssociations checks that every slice in the slice list of a UE in UE-NIB exists in onos-topo and lists the UE
+func (d *Detector) checkUenibAssociations(s *snapshot, repair func(*api.DriftFinding, func() bool)) {
+	for _, ue := range s.ues {
+		duUeF1apID := ue.GetUeIdList().GetDuUeF1apID().GetValue()
+		for _, slice := range append([]*uenib_api.SliceInfo(nil), ue.GetSliceList()...) {
+			drbID := uenib.DrbValue(slice.GetDrbId())
+			item := s.findItem(slice.GetDuE2NodeId(), slice.GetID(), topoapi.RSMSliceType(slice.GetSliceType()))
+			if item == nil {
+				ue, slice := ue, slice
+				repair(&api.DriftFinding{
+					Kind:        api.DriftMissingSlice,
+					E2NodeID:    slice.GetDuE2NodeId(),
+					SliceID:     slice.GetID(),
+					SliceType:   slice.GetSliceType().String(),
+					GlobalUeID:  ue.GetGlobalUeID(),
+					DuUeF1apID:  duUeF1apID,
+					DrbID:       drbID,
+					Description: fmt.Sprintf("slice %v referenced by UE %v does not exist in onos-topo", slice.GetID(), ue.GetGlobalUeID()),
+				}, func() bool {
+					for i := 0; i < len(ue.SliceList); i++ {
+						if ue.SliceList[i] == slice {
+							ue.SliceList = append(ue.SliceList[:i], ue.SliceList[i+1:]...)
+							break
+						}
+					}
+					s.dirtyUEs[ue] = true
+					return true
+				})
+				continue
+			}
+
+			if hasTopoUE(item, duUeF1apID, drbID) {
+				continue
+			}
+			ue, slice, item := ue, slice, item
+			repair(&api.DriftFinding{
+				Kind:        api.DriftMissingTopoAssociation,
+				E2NodeID:    slice.GetDuE2NodeId(),
+				SliceID:     slice.GetID(),
+				SliceType:   slice.GetSliceType().String(),
+				GlobalUeID:  ue.GetGlobalUeID(),
+				DuUeF1apID:  duUeF1apID,
+				DrbID:       drbID,
+				Description: fmt.Sprintf("slice %v is in the slice list of UE %v in UE-NIB but does not list the UE in onos-topo", slice.GetID(), ue.GetGlobalUeID()),
+			}, func() bool {
+				item.UeIdList = append(item.UeIdList, topoUeIdentity(ue, slice.GetDrbId()))
+				s.markItem(topoapi.ID(slice.GetDuE2NodeId()), item)
+				return true
+			})
+		}
+	}
+}
+
+// checkIndications checks the UEs in UE-NIB against the UEs reported attached or detached in RSM indications
+func (d *Detector) checkIndications(s *snapshot, repair func(*api.DriftFinding, func() bool)) {
+	attached, detached := d.reported()
+	for _, reported := range attached {
+		duUeF1apID := reported.ueID.GetDuUeF1apID().GetValue()
+		found := false
+		for _, ue := range s.ues {
+			if ue.GetCuE2NodeId() == string(reported.cuNodeID) && ue.GetUeIdList().GetDuUeF1apID().GetValue() == duUeF1apID {
+				found = true
+				break
+			}
+		}
+		if found {
+			continue
+		}
+		// the global UE ID is not known here, so only the monitor can add the UE back
+		repair(&api.DriftFinding{
+			Kind:        api.DriftUnknownUE,
+			E2NodeID:    string(reported.duNodeID),
+			DuUeF1apID:  duUeF1apID,
+			Description: fmt.Sprintf("UE with DU UE F1AP ID %v was reported attached to node %v but does not exist in UE-NIB", duUeF1apID, reported.cuNodeID),
+		}, nil)
+	}
+
+	for _, reported := range detached {
+		for _, ue := range s.ues {
+			if ue.GetCuE2NodeId() != string(reported.cuNodeID) || uenib.UEIDValue(ue.GetUeIdList(), reported.preferredType) != reported.ueID {
+				continue
+			}
+			ue := ue
+			repair(&api.DriftFinding{
+				Kind:        api.DriftStaleUE,
+				E2NodeID:    ue.GetDuE2NodeId(),
+				GlobalUeID:  ue.GetGlobalUeID(),
+				DuUeF1apID:  ue.GetUeIdList().GetDuUeF1apID().GetValue(),
+				Description: fmt.Sprintf("UE %v was reported detached from node %v but still exists in UE-NIB", ue.GetGlobalUeID(), reported.cuNodeID),
+			}, func() bool {
+				s.deleteUEs[ue.GetGlobalUeID()] = true
+				return true
+			})
+		}
+	}
+}
+
+func findingKey(f *api.DriftFinding) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s/%d/%d", f.Kind, f.E2NodeID, f.SliceID, f.SliceType, f.GlobalUeID, f.DuUeF1apID, f.DrbID)
+}
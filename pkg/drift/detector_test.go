@@ -0,0 +1,288 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cuNodeID = topoapi.ID("e2:4/e00/2/64")
+	duNodeID = topoapi.ID("e2:4/e00/3/c8")
+)
+
+func topoUE(duUeF1apID int64) *topoapi.UeIdentity {
+	return &topoapi.UeIdentity{
+		DuUeF1apID: &topoapi.DuUeF1ApID{Value: duUeF1apID},
+		DrbId:      &topoapi.DrbId{DrbId: &topoapi.DrbId_FiveGdrbId{FiveGdrbId: &topoapi.FiveGDrbId{Value: 5}}},
+	}
+}
+
+func uenibDrbID() *uenib_api.DrbId {
+	return &uenib_api.DrbId{DrbId: &uenib_api.DrbId_FiveGdrbId{FiveGdrbId: &uenib_api.FiveGDrbId{Value: 5}}}
+}
+
+// testUE is a UE on the DU with DRB 5 in the DL slices of the slice IDs
+func testUE(globalUeID string, duUeF1apID int64, sliceIDs ...string) *uenib_api.RsmUeInfo {
+	ue := &uenib_api.RsmUeInfo{
+		GlobalUeID: globalUeID,
+		UeIdList: &uenib_api.UeIdentity{
+			DuUeF1apID:      &uenib_api.DuUeF1ApID{Value: duUeF1apID},
+			AMFUeNgapID:     &uenib_api.AmfUeNgapID{Value: duUeF1apID + 100},
+			PreferredIDType: uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID,
+		},
+		BearerIdList: []*uenib_api.BearerId{{BearerId: &uenib_api.BearerId_DrbId{DrbId: uenibDrbID()}}},
+		CuE2NodeId:   string(cuNodeID),
+		DuE2NodeId:   string(duNodeID),
+	}
+	for _, sliceID := range sliceIDs {
+		ue.SliceList = append(ue.SliceList, &uenib_api.SliceInfo{
+			DuE2NodeId: string(duNodeID),
+			CuE2NodeId: string(cuNodeID),
+			ID:         sliceID,
+			SliceType:  uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE,
+			DrbId:      uenibDrbID(),
+		})
+	}
+	return ue
+}
+
+type driftTest struct {
+	detector    *Detector
+	rnibClient  rnib.TopoClient
+	uenibClient uenib.Client
+}
+
+// newDriftTest creates a detector on NIBs in which slice 1 of the DU lists UE 1, which is consistent,
+// UE 2, which does not exist in UE-NIB, and UE 4, whose slice list does not have the slice;
+// UE 1 also references slice 9, which does not exist, and UE 3 references slice 1, which does not list it
+func newDriftTest(ctx context.Context, t *testing.T, opts ...Option) *driftTest {
+	rnibStore, err := rnib.NewMemoryStore("")
+	require.NoError(t, err)
+	for _, nodeID := range []topoapi.ID{cuNodeID, duNodeID} {
+		require.NoError(t, rnibStore.Create(ctx, &topoapi.Object{
+			ID:   nodeID,
+			Type: topoapi.Object_ENTITY,
+			Obj:  &topoapi.Object_Entity{Entity: &topoapi.Entity{KindID: topoapi.E2NODE}},
+		}))
+	}
+	rnibClient := rnib.NewMemoryClient(ctx, rnibStore)
+	require.NoError(t, rnibClient.AddRsmSliceItemAspect(ctx, duNodeID, &topoapi.RSMSlicingItem{
+		ID:              "1",
+		SliceType:       topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE,
+		SliceParameters: &topoapi.RSMSliceParameters{Weight: 30},
+		UeIdList:        []*topoapi.UeIdentity{topoUE(1), topoUE(2), topoUE(4)},
+	}))
+
+	uenibStore, err := uenib.NewMemoryStore("")
+	require.NoError(t, err)
+	uenibClient := uenib.NewMemoryClient(uenibStore)
+	require.NoError(t, uenibClient.AddUE(ctx, testUE("ue-1", 1, "1", "9")))
+	require.NoError(t, uenibClient.AddUE(ctx, testUE("ue-3", 3, "1")))
+	require.NoError(t, uenibClient.AddUE(ctx, testUE("ue-4", 4)))
+
+	detector := NewDetector(append([]Option{
+		WithRnibClient(rnibClient),
+		WithUenibClient(uenibClient),
+	}, opts...)...)
+	return &driftTest{
+		detector:    detector,
+		rnibClient:  rnibClient,
+		uenibClient: uenibClient,
+	}
+}
+
+// kinds returns the kinds of the findings ordered by kind, and the kinds of the repaired findings
+func kinds(report *api.DriftReport) ([]api.DriftKind, []api.DriftKind) {
+	found := make([]api.DriftKind, 0)
+	repaired := make([]api.DriftKind, 0)
+	for _, f := range report.Findings {
+		found = append(found, f.Kind)
+		if f.Repaired {
+			repaired = append(repaired, f.Kind)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool {
+		return found[i] < found[j]
+	})
+	sort.Slice(repaired, func(i, j int) bool {
+		return repaired[i] < repaired[j]
+	})
+	return found, repaired
+}
+
+// backdate makes the UEs reported in indications older than the grace period UE-NIB is given to reflect them
+func (d *Detector) backdate() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for _, ue := range d.attached {
+		ue.time = ue.time.Add(-reportedUEGracePeriod)
+	}
+	for _, ue := range d.detached {
+		ue.time = ue.time.Add(-reportedUEGracePeriod)
+	}
+}
+
+var nibDrift = []api.DriftKind{
+	api.DriftMissingSlice,
+	api.DriftMissingTopoAssociation,
+	api.DriftMissingUEAssociation,
+	api.DriftOrphanedSliceAssociation,
+}
+
+func TestDetect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := newDriftTest(ctx, t)
+
+	report := d.detector.Detect(ctx)
+	assert.Empty(t, report.Errors)
+	found, repaired := kinds(report)
+	assert.Equal(t, nibDrift, found)
+	assert.Empty(t, repaired)
+	for _, f := range report.Findings {
+		switch f.Kind {
+		case api.DriftOrphanedSliceAssociation:
+			assert.Equal(t, int64(2), f.DuUeF1apID)
+		case api.DriftMissingSlice:
+			assert.Equal(t, "ue-1", f.GlobalUeID)
+			assert.Equal(t, "9", f.SliceID)
+		case api.DriftMissingTopoAssociation:
+			assert.Equal(t, "ue-3", f.GlobalUeID)
+		case api.DriftMissingUEAssociation:
+			assert.Equal(t, "ue-4", f.GlobalUeID)
+		}
+	}
+	assert.Equal(t, report, d.detector.LatestReport(ctx))
+}
+
+func TestAutoRepairConfirmedFindings(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := newDriftTest(ctx, t, WithAutoRepair(true))
+
+	// a finding is repaired only if the next detection finds it again
+	_, repaired := kinds(d.detector.Detect(ctx))
+	assert.Empty(t, repaired)
+	report := d.detector.Detect(ctx)
+	assert.Empty(t, report.Errors)
+	_, repaired = kinds(report)
+	assert.Equal(t, nibDrift, repaired)
+
+	found, _ := kinds(d.detector.Detect(ctx))
+	assert.Empty(t, found)
+	item, err := d.rnibClient.GetRsmSliceItemAspect(ctx, duNodeID, "1", 0)
+	require.NoError(t, err)
+	ues := make([]int64, 0)
+	for _, ueID := range item.GetUeIdList() {
+		ues = append(ues, ueID.GetDuUeF1apID().GetValue())
+	}
+	assert.Equal(t, []int64{1, 4, 3}, ues)
+	ue, err := d.uenibClient.GetUEWithGlobalID(ctx, "ue-1")
+	require.NoError(t, err)
+	require.Len(t, ue.GetSliceList(), 1)
+	assert.Equal(t, "1", ue.GetSliceList()[0].GetID())
+	ue, err = d.uenibClient.GetUEWithGlobalID(ctx, "ue-4")
+	require.NoError(t, err)
+	require.Len(t, ue.GetSliceList(), 1)
+}
+
+func TestRepairOnRepairChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	repairCh := make(chan *Repair)
+	d := newDriftTest(ctx, t, WithAutoRepair(true), WithRepairCh(repairCh))
+	d.detector.Detect(ctx)
+
+	// the repair runs on the consumer of the channel, e.g. the dispatcher of the slicing manager
+	repairs := 0
+	go func() {
+		for r := range repairCh {
+			repairs++
+			r.Run(ctx)
+		}
+	}()
+	_, repaired := kinds(d.detector.Detect(ctx))
+	close(repairCh)
+	assert.Equal(t, nibDrift, repaired)
+	assert.Equal(t, 1, repairs)
+}
+
+func TestIndications(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := newDriftTest(ctx, t, WithAutoRepair(true))
+
+	// UE 5 was attached but is not in UE-NIB, and UE 3 was detached but is still in UE-NIB
+	d.detector.UEAttached(cuNodeID, duNodeID, &uenib_api.UeIdentity{DuUeF1apID: &uenib_api.DuUeF1ApID{Value: 5}})
+	d.detector.UEDetached(cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, 103)
+	report := d.detector.Detect(ctx)
+	for _, f := range report.Findings {
+		assert.NotEqual(t, api.DriftUnknownUE, f.Kind)
+		assert.NotEqual(t, api.DriftStaleUE, f.Kind)
+	}
+
+	d.detector.backdate()
+	d.detector.Detect(ctx)
+	report = d.detector.Detect(ctx)
+	found := make(map[api.DriftKind]api.DriftFinding)
+	for _, f := range report.Findings {
+		found[f.Kind] = f
+	}
+	require.Contains(t, found, api.DriftUnknownUE)
+	assert.Equal(t, int64(5), found[api.DriftUnknownUE].DuUeF1apID)
+	assert.False(t, found[api.DriftUnknownUE].Repaired)
+	require.Contains(t, found, api.DriftStaleUE)
+	assert.Equal(t, "ue-3", found[api.DriftStaleUE].GlobalUeID)
+	assert.True(t, found[api.DriftStaleUE].Repaired)
+	_, err := d.uenibClient.GetUEWithGlobalID(ctx, "ue-3")
+	assert.Error(t, err)
+}
+
+func TestHandedOutUEIsNotUnknown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d := newDriftTest(ctx, t)
+
+	// UE 5 was handed out of the CU before UE-NIB reflected its attach
+	ueID := &uenib_api.UeIdentity{DuUeF1apID: &uenib_api.DuUeF1ApID{Value: 5}}
+	d.detector.UEAttached(cuNodeID, duNodeID, ueID)
+	d.detector.UEHandedOut(cuNodeID, ueID)
+	d.detector.backdate()
+	for _, f := range d.detector.Detect(ctx).Findings {
+		assert.NotEqual(t, api.DriftUnknownUE, f.Kind)
+	}
+
+	// a UE attached again is not reported detached
+	d.detector.UEDetached(cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, 101)
+	d.detector.UEAttached(cuNodeID, duNodeID, &uenib_api.UeIdentity{
+		DuUeF1apID:  &uenib_api.DuUeF1ApID{Value: 1},
+		AMFUeNgapID: &uenib_api.AmfUeNgapID{Value: 101},
+	})
+	d.detector.backdate()
+	for _, f := range d.detector.Detect(ctx).Findings {
+		assert.NotEqual(t, api.DriftStaleUE, f.Kind)
+	}
+}
+
+func TestDetectionDisabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	d := newDriftTest(ctx, t)
+	// Run returns at once without an interval
+	d.detector.Run(ctx)
+	assert.NoError(t, ctx.Err())
+}
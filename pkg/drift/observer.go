@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+const (
+	// reportedUEGracePeriod is the time UE-NIB is given to reflect a UE reported in an indication
+	reportedUEGracePeriod = 30 * time.Second
+	// detachedUEExpiry is the time a detach reported in an indication is remembered
+	detachedUEExpiry = 10 * time.Minute
+)
+
+type attachedUE struct {
+	cuNodeID topoapi.ID
+	duNodeID topoapi.ID
+	ueID     *uenib_api.UeIdentity
+	time     time.Time
+}
+
+type detachedUE struct {
+	cuNodeID      topoapi.ID
+	preferredType uenib_api.UeIdType
+	ueID          int64
+	time          time.Time
+}
+
+type attachedKey struct {
+	cuNodeID   topoapi.ID
+	duUeF1apID int64
+}
+
+// UEAttached records a UE reported attached in an RSM indication
+func (d *Detector) UEAttached(cuNodeID topoapi.ID, duNodeID topoapi.ID, ueID *uenib_api.UeIdentity) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.attached[attachedKey{cuNodeID: cuNodeID, duUeF1apID: ueID.GetDuUeF1apID().GetValue()}] = &attachedUE{
+		cuNodeID: cuNodeID,
+		duNodeID: duNodeID,
+		ueID:     ueID,
+		time:     time.Now(),
+	}
+	for i := 0; i < len(d.detached); i++ {
+		if d.detached[i].cuNodeID == cuNodeID && d.detached[i].ueID == uenib.UEIDValue(ueID, d.detached[i].preferredType) {
+			d.detached = append(d.detached[:i], d.detached[i+1:]...)
+			i--
+		}
+	}
+}
+
+// UEDetached records a UE reported detached in an RSM indication
+func (d *Detector) UEDetached(cuNodeID topoapi.ID, preferredType uenib_api.UeIdType, ueID int64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, ue := range d.attached {
+		if key.cuNodeID == cuNodeID && uenib.UEIDValue(ue.ueID, preferredType) == ueID {
+			delete(d.attached, key)
+		}
+	}
+	d.detached = append(d.detached, &detachedUE{
+		cuNodeID:      cuNodeID,
+		preferredType: preferredType,
+		ueID:          ueID,
+		time:          time.Now(),
+	})
+}
+
+// UEHandedOut forgets a UE reported attached which left its DU in a handover; the hand-in reports it attached again
+func (d *Detector) UEHandedOut(cuNodeID topoapi.ID, ueID *uenib_api.UeIdentity) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.attached, attachedKey{cuNodeID: cuNodeID, duUeF1apID: ueID.GetDuUeF1apID().GetValue()})
+}
+
+// reported returns the UEs reported attached before the grace period and the detaches which have not expired
+func (d *Detector) reported() ([]*attachedUE, []*detachedUE) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	attached := make([]*attachedUE, 0, len(d.attached))
+	for _, ue := range d.attached {
+		if now.Sub(ue.time) >= reportedUEGracePeriod {
+			attached = append(attached, ue)
+		}
+	}
+	detached := make([]*detachedUE, 0, len(d.detached))
+	remaining := make([]*detachedUE, 0, len(d.detached))
+	for _, ue := range d.detached {
+		if now.Sub(ue.time) >= detachedUEExpiry {
+			continue
+		}
+		remaining = append(remaining, ue)
+		if now.Sub(ue.time) >= reportedUEGracePeriod {
+			detached = append(detached, ue)
+		}
+	}
+	d.detached = remaining
+	return attached, detached
+}
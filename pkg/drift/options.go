@@ -0,0 +1,79 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	"time"
+
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+type Options struct {
+	App AppOptions
+}
+
+type AppOptions struct {
+	RnibClient rnib.TopoClient
+
+	UenibClient uenib.Client
+
+	Interval time.Duration
+
+	AutoRepair bool
+
+	RepairCh chan *Repair
+}
+
+type Option interface {
+	apply(*Options)
+}
+
+type funcOption struct {
+	f func(*Options)
+}
+
+func (f funcOption) apply(options *Options) {
+	f.f(options)
+}
+
+func newOption(f func(*Options)) Option {
+	return funcOption{
+		f: f,
+	}
+}
+
+func WithRnibClient(rnibClient rnib.TopoClient) Option {
+	return newOption(func(options *Options) {
+		options.App.RnibClient = rnibClient
+	})
+}
+
+func WithUenibClient(uenibClient uenib.Client) Option {
+	return newOption(func(options *Options) {
+		options.App.UenibClient = uenibClient
+	})
+}
+
+// WithInterval sets the period of the drift detection; zero disables the periodic detection
+func WithInterval(interval time.Duration) Option {
+	return newOption(func(options *Options) {
+		options.App.Interval = interval
+	})
+}
+
+// WithAutoRepair enables repairing the drift found in two consecutive detections
+func WithAutoRepair(autoRepair bool) Option {
+	return newOption(func(options *Options) {
+		options.App.AutoRepair = autoRepair
+	})
+}
+
+// WithRepairCh sets the channel the repairs are handed over to; its consumer runs them with Repair.Run
+func WithRepairCh(repairCh chan *Repair) Option {
+	return newOption(func(options *Options) {
+		options.App.RepairCh = repairCh
+	})
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package drift
+
+import (
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+func hasUenibSlice(ue *uenib_api.RsmUeInfo, duNodeID string, sliceID string, sliceType uenib_api.RSMSliceType, drbID int32) bool {
+	for _, slice := range ue.GetSliceList() {
+		if slice.GetDuE2NodeId() == duNodeID && slice.GetID() == sliceID && slice.GetSliceType() == sliceType && uenib.DrbValue(slice.GetDrbId()) == drbID {
+			return true
+		}
+	}
+	return false
+}
+
+func hasTopoUE(item *topoapi.RSMSlicingItem, duUeF1apID int64, drbID int32) bool {
+	for _, ueID := range item.GetUeIdList() {
+		if ueID.GetDuUeF1apID().GetValue() == duUeF1apID && rnib.DrbValue(ueID.GetDrbId()) == drbID {
+			return true
+		}
+	}
+	return false
+}
+
+// uenibBearerDrbID returns the DRB of the UE's bearer list with the given DRB ID
+func uenibBearerDrbID(ue *uenib_api.RsmUeInfo, drbID int32) *uenib_api.DrbId {
+	for _, bID := range ue.GetBearerIdList() {
+		if bID.GetDrbId() != nil && uenib.DrbValue(bID.GetDrbId()) == drbID {
+			return bID.GetDrbId()
+		}
+	}
+	return nil
+}
+
+// topoUeIdentity builds the onos-topo UE identity of the UE's DRB
+func topoUeIdentity(ue *uenib_api.RsmUeInfo, drbID *uenib_api.DrbId) *topoapi.UeIdentity {
+	ueID := &topoapi.UeIdentity{
+		DuUeF1apID: &topoapi.DuUeF1ApID{
+			Value: ue.GetUeIdList().GetDuUeF1apID().GetValue(),
+		},
+		CuUeF1apID: &topoapi.CuUeF1ApID{
+			Value: ue.GetUeIdList().GetCuUeF1apID().GetValue(),
+		},
+		RANUeNgapID: &topoapi.RanUeNgapID{
+			Value: ue.GetUeIdList().GetRANUeNgapID().GetValue(),
+		},
+		AMFUeNgapID: &topoapi.AmfUeNgapID{
+			Value: ue.GetUeIdList().GetAMFUeNgapID().GetValue(),
+		},
+		EnbUeS1apID: &topoapi.EnbUeS1ApID{
+			Value: ue.GetUeIdList().GetEnbUeS1apID().GetValue(),
+		},
+	}
+
+	if drbID.GetFourGdrbId() != nil {
+		ueID.DrbId = &topoapi.DrbId{
+			DrbId: &topoapi.DrbId_FourGdrbId{
+				FourGdrbId: &topoapi.FourGDrbId{
+					Value: drbID.GetFourGdrbId().GetValue(),
+				},
+			},
+		}
+		if drbID.GetFourGdrbId().GetQci() != nil {
+			ueID.GetDrbId().GetFourGdrbId().Qci = &topoapi.Qci{
+				Value: drbID.GetFourGdrbId().GetQci().GetValue(),
+			}
+		}
+		return ueID
+	}
+
+	ueID.DrbId = &topoapi.DrbId{
+		DrbId: &topoapi.DrbId_FiveGdrbId{
+			FiveGdrbId: &topoapi.FiveGDrbId{
+				Value: drbID.GetFiveGdrbId().GetValue(),
+			},
+		},
+	}
+	if drbID.GetFiveGdrbId().GetQfi() != nil {
+		ueID.GetDrbId().GetFiveGdrbId().Qfi = &topoapi.Qfi{
+			Value: drbID.GetFiveGdrbId().GetQfi().GetValue(),
+		}
+	}
+	for _, flow := range drbID.GetFiveGdrbId().GetFlowsMapToDrb() {
+		var param *topoapi.QoSflowLevelParameters
+		if flow.GetNonDynamicFiveQi() != nil {
+			param = &topoapi.QoSflowLevelParameters{
+				QosFlowLevelParameters: &topoapi.QoSflowLevelParameters_NonDynamicFiveQi{
+					NonDynamicFiveQi: &topoapi.NonDynamicFiveQi{
+						FiveQi: &topoapi.FiveQi{
+							Value: flow.GetNonDynamicFiveQi().GetFiveQi().GetValue(),
+						},
+					},
+				},
+			}
+		}
+		if flow.GetDynamicFiveQi() != nil {
+			param = &topoapi.QoSflowLevelParameters{
+				QosFlowLevelParameters: &topoapi.QoSflowLevelParameters_DynamicFiveQi{
+					DynamicFiveQi: &topoapi.DynamicFiveQi{
+						PriorityLevel:    flow.GetDynamicFiveQi().GetPriorityLevel(),
+						PacketDelayBudge: flow.GetDynamicFiveQi().GetPacketDelayBudge(),
+						PacketErrorRate:  flow.GetDynamicFiveQi().GetPacketErrorRate(),
+					},
+				},
+			}
+		}
+		if param != nil {
+			ueID.GetDrbId().GetFiveGdrbId().FlowsMapToDrb = append(ueID.GetDrbId().GetFiveGdrbId().FlowsMapToDrb, param)
+		}
+	}
+	return ueID
+}
@@ -0,0 +1,209 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kpi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-rsm/api"
+)
+
+const initialRingSize = 16
+
+// NewStore creates a KPI store keeping the samples of the retention period, at most maxSamples per DU, slice type and UE
+func NewStore(retention time.Duration, maxSamples int) *Store {
+	if maxSamples <= 0 {
+		maxSamples = 1
+	}
+	return &Store{
+		retention:  retention,
+		maxSamples: maxSamples,
+		series:     make(map[seriesKey]*ring),
+	}
+}
+
+// Store keeps the KPI samples in memory in a ring buffer per DU, slice type and UE
+type Store struct {
+	retention  time.Duration
+	maxSamples int
+	mu         sync.RWMutex
+	series     map[seriesKey]*ring
+}
+
+type seriesKey struct {
+	e2NodeID   string
+	sliceType  string
+	duUeF1apID int64
+}
+
+// Add adds the sample; the oldest sample of the UE is overwritten if the buffer is full
+func (s *Store) Add(sample api.KpiSample) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := seriesKey{
+		e2NodeID:   sample.E2NodeID,
+		sliceType:  sample.SliceType,
+		duUeF1apID: sample.DuUeF1apID,
+	}
+	r, ok := s.series[key]
+	if !ok {
+		// the UEs come and go, so the series without a sample of the retention period are dropped
+		s.expire(sample.Time.Add(-s.retention))
+		r = newRing(s.maxSamples)
+		s.series[key] = r
+	}
+	r.expire(sample.Time.Add(-s.retention))
+	r.add(sample)
+}
+
+func (s *Store) expire(before time.Time) {
+	for key, r := range s.series {
+		r.expire(before)
+		if r.size == 0 {
+			delete(s.series, key)
+		}
+	}
+}
+
+// Query returns the samples of the DU within the retention period and the window in time order;
+// an empty slice type or global UE ID matches all
+func (s *Store) Query(request *api.GetSliceKpisRequest) []api.KpiSample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	from := time.Now().Add(-s.retention)
+	if request.From.After(from) {
+		from = request.From
+	}
+
+	result := make([]api.KpiSample, 0)
+	for key, r := range s.series {
+		if key.e2NodeID != request.E2NodeID ||
+			(request.SliceType != "" && key.sliceType != request.SliceType) {
+			continue
+		}
+		r.each(func(sample api.KpiSample) {
+			if sample.Time.Before(from) || (!request.To.IsZero() && sample.Time.After(request.To)) {
+				return
+			}
+			if request.GlobalUeID != "" && sample.GlobalUeID != request.GlobalUeID {
+				return
+			}
+			result = append(result, sample)
+		})
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Time.Before(result[j].Time)
+	})
+	return result
+}
+
+// Latest returns the latest sample of each DU, slice type and UE within the retention period
+func (s *Store) Latest() []api.KpiSample {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	from := time.Now().Add(-s.retention)
+	result := make([]api.KpiSample, 0, len(s.series))
+	for _, r := range s.series {
+		if r.size == 0 {
+			continue
+		}
+		latest := r.samples[(r.start+r.size-1)%len(r.samples)]
+		if !latest.Time.Before(from) {
+			result = append(result, latest)
+		}
+	}
+	return result
+}
+
+// Summarize aggregates the samples
+func Summarize(samples []api.KpiSample) api.KpiSummary {
+	summary := api.KpiSummary{
+		Samples: len(samples),
+	}
+	if len(samples) == 0 {
+		return summary
+	}
+	var prb, bler, cqi int64
+	for _, sample := range samples {
+		prb += int64(sample.PrbUtilization)
+		bler += int64(sample.Bler)
+		cqi += int64(sample.AvgCqi)
+		if sample.PrbUtilization > summary.MaxPrbUtilization {
+			summary.MaxPrbUtilization = sample.PrbUtilization
+		}
+		if sample.NumUes > summary.MaxNumUes {
+			summary.MaxNumUes = sample.NumUes
+		}
+	}
+	n := float64(len(samples))
+	summary.AvgPrbUtilization = float64(prb) / n
+	summary.AvgBler = float64(bler) / n
+	summary.AvgCqi = float64(cqi) / n
+	return summary
+}
+
+// ring is a buffer of at most capacity samples in the order they were added
+type ring struct {
+	capacity int
+	samples  []api.KpiSample
+	start    int
+	size     int
+}
+
+func newRing(capacity int) *ring {
+	return &ring{
+		capacity: capacity,
+	}
+}
+
+func (r *ring) add(sample api.KpiSample) {
+	if r.size == len(r.samples) && r.size < r.capacity {
+		r.grow()
+	}
+	if r.size < len(r.samples) {
+		r.samples[(r.start+r.size)%len(r.samples)] = sample
+		r.size++
+		return
+	}
+	r.samples[r.start] = sample
+	r.start = (r.start + 1) % len(r.samples)
+}
+
+// grow doubles the buffer up to its capacity, so that the buffers of short-lived UEs stay small
+func (r *ring) grow() {
+	n := 2 * len(r.samples)
+	if n == 0 {
+		n = initialRingSize
+	}
+	if n > r.capacity {
+		n = r.capacity
+	}
+	samples := make([]api.KpiSample, n)
+	for i := 0; i < r.size; i++ {
+		samples[i] = r.samples[(r.start+i)%len(r.samples)]
+	}
+	r.samples = samples
+	r.start = 0
+}
+
+// expire drops the samples older than the time from the start of the buffer
+func (r *ring) expire(before time.Time) {
+	for r.size > 0 && r.samples[r.start].Time.Before(before) {
+		r.samples[r.start] = api.KpiSample{}
+		r.start = (r.start + 1) % len(r.samples)
+		r.size--
+	}
+}
+
+func (r *ring) each(f func(sample api.KpiSample)) {
+	for i := 0; i < r.size; i++ {
+		f(r.samples[(r.start+i)%len(r.samples)])
+	}
+}
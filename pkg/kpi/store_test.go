@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package kpi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDU   = "e2:4/e00/3/c8"
+	dlSlice  = "SLICE_TYPE_DL_SLICE"
+	ulSlice  = "SLICE_TYPE_UL_SLICE"
+	testUEID = int64(1)
+)
+
+func sample(t time.Time, sliceType string, duUeF1apID int64, prb int32) api.KpiSample {
+	return api.KpiSample{
+		Time:           t,
+		E2NodeID:       testDU,
+		SliceType:      sliceType,
+		DuUeF1apID:     duUeF1apID,
+		PrbUtilization: prb,
+	}
+}
+
+func prbs(samples []api.KpiSample) []int32 {
+	result := make([]int32, 0, len(samples))
+	for _, s := range samples {
+		result = append(result, s.PrbUtilization)
+	}
+	return result
+}
+
+func TestRingWrap(t *testing.T) {
+	s := NewStore(time.Hour, 40)
+	start := time.Now().Add(-time.Minute)
+	for i := 0; i < 100; i++ {
+		s.Add(sample(start.Add(time.Duration(i)*time.Millisecond), dlSlice, testUEID, int32(i)))
+	}
+
+	// the buffer grew up to its capacity and kept the latest samples in order
+	samples := s.Query(&api.GetSliceKpisRequest{E2NodeID: testDU})
+	require.Len(t, samples, 40)
+	for i, prb := range prbs(samples) {
+		assert.Equal(t, int32(60+i), prb)
+	}
+	latest := s.Latest()
+	require.Len(t, latest, 1)
+	assert.Equal(t, int32(99), latest[0].PrbUtilization)
+}
+
+func TestExpiry(t *testing.T) {
+	s := NewStore(time.Minute, 10)
+	now := time.Now()
+	s.Add(sample(now.Add(-2*time.Minute), dlSlice, testUEID, 1))
+	s.Add(sample(now.Add(-30*time.Second), dlSlice, testUEID, 2))
+	s.Add(sample(now.Add(-2*time.Minute), dlSlice, 2, 3))
+
+	// the samples older than the retention period are not returned
+	assert.Equal(t, []int32{2}, prbs(s.Query(&api.GetSliceKpisRequest{E2NodeID: testDU})))
+	assert.Len(t, s.Latest(), 1)
+
+	// the series of a UE without samples in the retention period is dropped with the next new series
+	s.Add(sample(now, ulSlice, testUEID, 4))
+	assert.Len(t, s.series, 2)
+	assert.NotContains(t, s.series, seriesKey{e2NodeID: testDU, sliceType: dlSlice, duUeF1apID: 2})
+}
+
+func TestWindowQuery(t *testing.T) {
+	s := NewStore(time.Hour, 10)
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		at := now.Add(time.Duration(i-5) * time.Minute)
+		s.Add(sample(at, dlSlice, testUEID, int32(i)))
+		ul := sample(at.Add(time.Second), ulSlice, 2, int32(10+i))
+		ul.GlobalUeID = "ue-2"
+		s.Add(ul)
+	}
+
+	// the window is closed, and the samples of the UEs and slice types are merged in time order
+	samples := s.Query(&api.GetSliceKpisRequest{
+		E2NodeID: testDU,
+		From:     now.Add(-4 * time.Minute),
+		To:       now.Add(-2*time.Minute + time.Second),
+	})
+	assert.Equal(t, []int32{1, 11, 2, 12, 3, 13}, prbs(samples))
+
+	assert.Equal(t, []int32{10, 11, 12, 13, 14}, prbs(s.Query(&api.GetSliceKpisRequest{E2NodeID: testDU, SliceType: ulSlice})))
+	assert.Equal(t, []int32{10, 11, 12, 13, 14}, prbs(s.Query(&api.GetSliceKpisRequest{E2NodeID: testDU, GlobalUeID: "ue-2"})))
+	assert.Empty(t, s.Query(&api.GetSliceKpisRequest{E2NodeID: "e2:4/e00/3/c9"}))
+
+	summary := Summarize(s.Query(&api.GetSliceKpisRequest{E2NodeID: testDU, SliceType: dlSlice}))
+	assert.Equal(t, 5, summary.Samples)
+	assert.Equal(t, 2.0, summary.AvgPrbUtilization)
+	assert.Equal(t, int32(4), summary.MaxPrbUtilization)
+}
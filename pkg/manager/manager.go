@@ -6,19 +6,29 @@ package manager
 
 import (
 	"context"
+	"net"
+	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
 	app "github.com/onosproject/onos-ric-sdk-go/pkg/config/app/default"
 	"github.com/onosproject/onos-rsm/pkg/broker"
 	appConfig "github.com/onosproject/onos-rsm/pkg/config"
+	"github.com/onosproject/onos-rsm/pkg/drift"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
+	"github.com/onosproject/onos-rsm/pkg/metrics"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
 	nbi "github.com/onosproject/onos-rsm/pkg/northbound"
+	"github.com/onosproject/onos-rsm/pkg/recorder"
 	"github.com/onosproject/onos-rsm/pkg/slicing"
 	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"google.golang.org/grpc"
 )
 
 var log = logging.GetLogger()
@@ -37,35 +47,99 @@ type Config struct {
 	UenibHost   string
 	AppID       string
 	AckTimer    int
+	// CtrlRetryDeadline, CtrlRetryInitialBackoff and CtrlRetryMaxBackoff are the retry policy of the control messages in milliseconds
+	CtrlRetryDeadline       int
+	CtrlRetryInitialBackoff int
+	CtrlRetryMaxBackoff     int
+
+	CompensationPolicy string
+	JournalPath        string
+	ReconnectPolicy    string
+	DriftInterval      int
+	DriftAutoRepair    bool
+	// ShutdownGracePeriod is the time in seconds given to Close to stop the app gracefully
+	ShutdownGracePeriod int
+	// RecordPath is the file the E2 messages are recorded to; recording is disabled if it is empty
+	RecordPath     string
+	RecordMaxSize  int64
+	RecordMaxFiles int
+	// NibBackend is the implementation of the R-NIB and UE-NIB: onos, or memory to run without onos-topo and onos-uenib
+	NibBackend string
+	// NibSnapshotDir is the directory the in-memory NIBs are saved to; they are not saved if it is empty
+	NibSnapshotDir string
+	// KpiRetention is the time in seconds the slice metrics are kept; KpiMaxSamples is the most kept per DU, slice type and UE
+	KpiRetention  int
+	KpiMaxSamples int
+	// HandoverGracePeriod is the time in seconds a UE handed out is kept for its hand-in
+	HandoverGracePeriod int
+	// MetricsPort is the port of the Prometheus /metrics endpoint; 0 disables it
+	MetricsPort int
 }
 
+const (
+	// NibBackendONOS is the R-NIB of onos-topo and the UE-NIB of onos-uenib
+	NibBackendONOS = "onos"
+	// NibBackendMemory is the in-memory R-NIB and UE-NIB
+	NibBackendMemory = "memory"
+)
+
 func NewManager(config Config) *Manager {
 	appCfg, err := appConfig.NewConfig(config.ConfigPath)
 	if err != nil {
 		log.Warn(err)
 	}
-	subscriptionBroker := broker.NewBroker()
-	rnibClient, err := rnib.NewClient()
-	if err != nil {
-		log.Warn(err)
+	var e2Recorder *recorder.Recorder
+	if config.RecordPath != "" {
+		e2Recorder, err = recorder.NewRecorder(config.RecordPath, config.RecordMaxSize, config.RecordMaxFiles)
+		if err != nil {
+			log.Warn(err)
+		}
 	}
-	uenibClient, err := uenib.NewClient(context.Background(), config.CertPath, config.KeyPath, config.UenibHost)
-	if err != nil {
-		log.Warn(err)
+	subscriptionBroker := broker.NewBroker(broker.WithRecorder(e2Recorder))
+	// the NIB clients watch their stores until the manager is closed
+	ctx, cancel := context.WithCancel(context.Background())
+	rnibClient, uenibClient := newNibClients(ctx, config)
+	ctrlDispatcher := e2.NewControlDispatcher()
+	kpiStore := kpi.NewStore(time.Duration(config.KpiRetention)*time.Second, config.KpiMaxSamples)
+	capabilityCache := rnib.NewCapabilityCache(rnibClient)
+	ctrlRetryPolicy := e2.RetryPolicy{
+		Deadline:       time.Duration(config.CtrlRetryDeadline) * time.Millisecond,
+		InitialBackoff: time.Duration(config.CtrlRetryInitialBackoff) * time.Millisecond,
+		MaxBackoff:     time.Duration(config.CtrlRetryMaxBackoff) * time.Millisecond,
+	}
+	if config.AckTimer > 0 {
+		ctrlRetryPolicy.AttemptTimeout = time.Duration(config.AckTimer) * time.Second
 	}
-	ctrlReqChsSliceCreate := make(map[string]chan *e2.CtrlMsg)
-	ctrlReqChsSliceUpdate := make(map[string]chan *e2.CtrlMsg)
-	ctrlReqChsSliceDelete := make(map[string]chan *e2.CtrlMsg)
-	ctrlReqChsUeAssociate := make(map[string]chan *e2.CtrlMsg)
 
 	rsmReqCh := make(chan *nbi.RsmMsg)
+	nodeEventCh := make(chan e2.NodeEvent, 100)
+	handoverCh := make(chan monitoring.HandoverEvent, 100)
+	identityResolver := monitoring.NewUEIdentityResolver(uenibClient)
+
+	repairCh := make(chan *drift.Repair)
+	driftDetector := drift.NewDetector(
+		drift.WithRnibClient(rnibClient),
+		drift.WithUenibClient(uenibClient),
+		drift.WithInterval(time.Duration(config.DriftInterval)*time.Second),
+		drift.WithAutoRepair(config.DriftAutoRepair),
+		drift.WithRepairCh(repairCh),
+	)
+	handoverTracker := monitoring.NewHandoverTracker(uenibClient, identityResolver, driftDetector, handoverCh, time.Duration(config.HandoverGracePeriod)*time.Second)
 
 	slicingManager := slicing.NewManager(
 		slicing.WithRnibClient(rnibClient),
 		slicing.WithUenibClient(uenibClient),
-		slicing.WithCtrlReqChs(ctrlReqChsSliceCreate, ctrlReqChsSliceUpdate, ctrlReqChsSliceDelete, ctrlReqChsUeAssociate),
+		slicing.WithCapabilityCache(capabilityCache),
+		slicing.WithControlDispatcher(ctrlDispatcher),
 		slicing.WithNbiReqChs(rsmReqCh),
 		slicing.WithAckTimer(config.AckTimer),
+		slicing.WithControlRetryPolicy(ctrlRetryPolicy),
+		slicing.WithCompensation(slicing.CompensationPolicy(config.CompensationPolicy), config.JournalPath),
+		slicing.WithNodeEventCh(nodeEventCh),
+		slicing.WithHandoverCh(handoverCh),
+		slicing.WithRepairCh(repairCh),
+		slicing.WithReconnectPolicy(slicing.ReconnectPolicy(config.ReconnectPolicy)),
+		slicing.WithUEIdentityResolver(identityResolver),
 	)
 
 	e2tHostAddr := strings.Split(config.E2tEndpoint, ":")[0]
@@ -81,41 +155,94 @@ func NewManager(config Config) *Manager {
 		e2.WithAppID(config.AppID),
 		e2.WithBroker(subscriptionBroker),
 		e2.WithRnibClient(rnibClient),
+		e2.WithCapabilityCache(capabilityCache),
 		e2.WithUenibClient(uenibClient),
-		e2.WithCtrlReqChs(ctrlReqChsSliceCreate, ctrlReqChsSliceUpdate, ctrlReqChsSliceDelete, ctrlReqChsUeAssociate),
+		e2.WithControlDispatcher(ctrlDispatcher),
+		e2.WithControlRetryPolicy(ctrlRetryPolicy),
+		e2.WithRecorder(e2Recorder),
+		e2.WithNodeEventCh(nodeEventCh),
+		e2.WithIndicationObserver(driftDetector),
+		e2.WithHandoverTracker(handoverTracker),
+		e2.WithUEIdentityResolver(identityResolver),
+		e2.WithKpiStore(kpiStore),
 	)
 	if err != nil {
 		log.Warn(err)
 	}
 
 	return &Manager{
-		appConfig:             appCfg,
-		config:                config,
-		e2Manager:             e2Manager,
-		rnibClient:            rnibClient,
-		uenibClient:           uenibClient,
-		slicingManager:        slicingManager,
-		ctrlReqChsSliceCreate: ctrlReqChsSliceCreate,
-		ctrlReqChsSliceUpdate: ctrlReqChsSliceUpdate,
-		ctrlReqChsSliceDelete: ctrlReqChsSliceDelete,
-		ctrlReqChsUeAssociate: ctrlReqChsUeAssociate,
-		rsmReqCh:              rsmReqCh,
+		appConfig:      appCfg,
+		config:         config,
+		e2Manager:      e2Manager,
+		rnibClient:     rnibClient,
+		uenibClient:    uenibClient,
+		slicingManager: slicingManager,
+		ctrlDispatcher: ctrlDispatcher,
+		capabilities:   capabilityCache,
+		kpiStore:       kpiStore,
+		handovers:      handoverTracker,
+		broker:         subscriptionBroker,
+		rsmReqCh:       rsmReqCh,
+		driftDetector:  driftDetector,
+		recorder:       e2Recorder,
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 }
 
+func newNibClients(ctx context.Context, config Config) (rnib.TopoClient, uenib.Client) {
+	if config.NibBackend != NibBackendONOS && config.NibBackend != NibBackendMemory && config.NibBackend != "" {
+		log.Warnf("Unknown NIB backend %v - using %v", config.NibBackend, NibBackendONOS)
+	}
+	if config.NibBackend == NibBackendMemory {
+		var rnibSnapshot, uenibSnapshot string
+		if config.NibSnapshotDir != "" {
+			rnibSnapshot = filepath.Join(config.NibSnapshotDir, "rnib.json")
+			uenibSnapshot = filepath.Join(config.NibSnapshotDir, "uenib.json")
+		}
+		rnibStore, err := rnib.NewMemoryStore(rnibSnapshot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		uenibStore, err := uenib.NewMemoryStore(uenibSnapshot)
+		if err != nil {
+			log.Fatal(err)
+		}
+		log.Info("Using the in-memory R-NIB and UE-NIB")
+		return rnib.NewMemoryClient(ctx, rnibStore), uenib.NewMemoryClient(uenibStore)
+	}
+
+	rnibClient, err := rnib.NewClient(ctx)
+	if err != nil {
+		log.Warn(err)
+	}
+	uenibClient, err := uenib.NewClient(ctx, config.CertPath, config.KeyPath, config.UenibHost)
+	if err != nil {
+		log.Warn(err)
+	}
+	return rnibClient, uenibClient
+}
+
 // Manager is a manager for the RSM xAPP service
 type Manager struct {
-	appConfig             appConfig.Config
-	config                Config
-	e2Manager             e2.Manager
-	rnibClient            rnib.TopoClient
-	uenibClient           uenib.Client
-	slicingManager        slicing.Manager
-	ctrlReqChsSliceCreate map[string]chan *e2.CtrlMsg
-	ctrlReqChsSliceUpdate map[string]chan *e2.CtrlMsg
-	ctrlReqChsSliceDelete map[string]chan *e2.CtrlMsg
-	ctrlReqChsUeAssociate map[string]chan *e2.CtrlMsg
-	rsmReqCh              chan *nbi.RsmMsg
+	appConfig      appConfig.Config
+	config         Config
+	e2Manager      e2.Manager
+	rnibClient     rnib.TopoClient
+	uenibClient    uenib.Client
+	slicingManager slicing.Manager
+	ctrlDispatcher *e2.ControlDispatcher
+	capabilities   *rnib.CapabilityCache
+	kpiStore       *kpi.Store
+	handovers      *monitoring.HandoverTracker
+	broker         broker.Broker
+	rsmReqCh       chan *nbi.RsmMsg
+	driftDetector  *drift.Detector
+	nbServer       *northbound.Server
+	metricsServer  *http.Server
+	recorder       *recorder.Recorder
+	ctx            context.Context
+	cancel         context.CancelFunc
 }
 
 // Run starts the manager and the associated services
@@ -133,19 +260,74 @@ func (m *Manager) start() error {
 		return err
 	}
 
-	err = m.e2Manager.Start()
+	err = m.startMetricsServer()
 	if err != nil {
 		log.Warn(err)
 		return err
 	}
 
-	go m.slicingManager.Run(context.Background())
+	// the capability cache is started first; the nodes which are not in it yet are read from onos-topo
+	go m.capabilities.Run(m.ctx)
+
+	err = m.e2Manager.Start()
+	if err != nil {
+		log.Warn(err)
+		return err
+	}
+	m.slicingManager.Run(m.ctx)
+	go m.driftDetector.Run(m.ctx)
+	go m.handovers.Run(m.ctx)
 
 	return nil
 }
 
+// Close stops the app: the northbound server stops accepting requests and waits for the in-flight ones,
+// the slicing manager stops taking new work and finishes the work in flight, and then the E2 manager closes
+// its control channels and deletes its subscriptions. All the stages share the shutdown grace period;
+// whatever is not done by its end is abandoned.
 func (m *Manager) Close() {
 	log.Info("Closing Manager")
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(m.config.ShutdownGracePeriod)*time.Second)
+	defer cancel()
+
+	if m.nbServer != nil {
+		nbStopped := make(chan struct{})
+		go func() {
+			m.nbServer.GracefulStop()
+			close(nbStopped)
+		}()
+		select {
+		case <-nbStopped:
+			log.Info("Stopped NBI")
+		case <-ctx.Done():
+			log.Warn("NBI requests are still in flight at the end of the grace period - stopping NBI")
+			m.nbServer.Stop()
+		}
+	}
+
+	if err := m.slicingManager.Drain(ctx); err != nil {
+		log.Warn(err)
+	}
+	m.cancel()
+	select {
+	case <-m.slicingManager.Stopped():
+		log.Info("Stopped slicing manager")
+	case <-ctx.Done():
+		log.Warn("Slicing manager did not stop within the grace period")
+	}
+
+	if err := m.e2Manager.Shutdown(ctx); err != nil {
+		log.Warn(err)
+	}
+	if err := m.recorder.Close(); err != nil {
+		log.Warn(err)
+	}
+	if m.metricsServer != nil {
+		if err := m.metricsServer.Shutdown(ctx); err != nil {
+			log.Warn(err)
+		}
+	}
+	log.Info("Closed Manager")
 }
 
 func (m *Manager) startNorthboundServer() error {
@@ -158,16 +340,42 @@ func (m *Manager) startNorthboundServer() error {
 		northbound.SecurityConfig{}))
 
 	s.AddService(nbi.NewService(m.rnibClient, m.uenibClient, m.rsmReqCh))
+	s.AddService(nbi.NewDiagnosticsService(m.driftDetector, &m.e2Manager))
+	s.AddService(nbi.NewSlicePairService(m.rnibClient, m.rsmReqCh))
+	s.AddService(nbi.NewKpiService(m.kpiStore))
+	m.nbServer = s
 
 	doneCh := make(chan error)
 	go func() {
 		err := s.Serve(func(started string) {
 			log.Info("Started NBI on ", started)
 			close(doneCh)
-		})
+		}, grpc.ChainUnaryInterceptor(metrics.UnaryServerInterceptor()))
 		if err != nil {
 			doneCh <- err
 		}
 	}()
 	return <-doneCh
 }
+
+func (m *Manager) startMetricsServer() error {
+	if m.config.MetricsPort == 0 {
+		return nil
+	}
+	if err := metrics.NewCollector(m.broker, m.rnibClient, m.uenibClient, m.kpiStore).Register(); err != nil {
+		return err
+	}
+	m.metricsServer = metrics.NewServer(m.config.MetricsPort)
+
+	lis, err := net.Listen("tcp", m.metricsServer.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := m.metricsServer.Serve(lis); err != nil && err != http.ErrServerClosed {
+			log.Warn(err)
+		}
+	}()
+	log.Info("Started metrics endpoint on ", lis.Addr())
+	return nil
+}
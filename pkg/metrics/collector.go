@@ -0,0 +1,156 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/onosproject/onos-rsm/pkg/broker"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const collectTimeout = 5 * time.Second
+
+var (
+	streamBufferDepthDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "broker", "stream_buffer_depth"),
+		"Indications buffered in a subscription stream", []string{"stream_id", "e2_node_id", "subscription"}, nil)
+	uesDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "ues"),
+		"UEs in UE-NIB by E2 node and role of the node", []string{"e2_node_id", "role"}, nil)
+	slicesDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "slices"),
+		"Slices by DU and slice type", []string{"e2_node_id", "slice_type"}, nil)
+	sliceWeightSumDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "slice_weight_sum"),
+		"Sum of the slice weights by DU and slice type", []string{"e2_node_id", "slice_type"}, nil)
+	slicePrbUtilizationDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "slice", "prb_utilization_percent"),
+		"Latest PRB utilization reported for the slice of the UE", []string{"e2_node_id", "slice_type", "du_ue_f1ap_id"}, nil)
+	sliceUesDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "slice", "ues"),
+		"Latest number of UEs reported associated with the slice of the UE", []string{"e2_node_id", "slice_type", "du_ue_f1ap_id"}, nil)
+	sliceBlerDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "slice", "bler_percent"),
+		"Latest block error rate reported for the slice of the UE", []string{"e2_node_id", "slice_type", "du_ue_f1ap_id"}, nil)
+	sliceAvgCqiDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "slice", "avg_cqi"),
+		"Latest average CQI reported for the slice of the UE", []string{"e2_node_id", "slice_type", "du_ue_f1ap_id"}, nil)
+)
+
+// NewCollector creates the collector of the state of the broker, the NIBs and the KPI store
+func NewCollector(streams broker.Broker, rnibClient rnib.TopoClient, uenibClient uenib.Client, kpiStore *kpi.Store) *Collector {
+	return &Collector{
+		streams:     streams,
+		rnibClient:  rnibClient,
+		uenibClient: uenibClient,
+		kpiStore:    kpiStore,
+	}
+}
+
+// Collector reads the state metrics when they are scraped
+type Collector struct {
+	streams     broker.Broker
+	rnibClient  rnib.TopoClient
+	uenibClient uenib.Client
+	kpiStore    *kpi.Store
+}
+
+// Register registers the collector with the default registry
+func (c *Collector) Register() error {
+	return prometheus.Register(c)
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- streamBufferDepthDesc
+	ch <- uesDesc
+	ch <- slicesDesc
+	ch <- sliceWeightSumDesc
+	ch <- slicePrbUtilizationDesc
+	ch <- sliceUesDesc
+	ch <- sliceBlerDesc
+	ch <- sliceAvgCqiDesc
+}
+
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+	c.collectStreams(ch)
+	c.collectUEs(ctx, ch)
+	c.collectSlices(ctx, ch)
+	c.collectKpis(ch)
+}
+
+func (c *Collector) collectStreams(ch chan<- prometheus.Metric) {
+	if c.streams == nil {
+		return
+	}
+	for _, depth := range c.streams.StreamDepths() {
+		ch <- prometheus.MustNewConstMetric(streamBufferDepthDesc, prometheus.GaugeValue, float64(depth.Depth),
+			strconv.Itoa(int(depth.StreamID)), string(depth.NodeID), depth.SubscriptionName)
+	}
+}
+
+func (c *Collector) collectUEs(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.uenibClient == nil {
+		return
+	}
+	ues, err := c.uenibClient.GetUEs(ctx)
+	if err != nil {
+		log.Warnf("Failed to collect the UE metrics: %v", err)
+		return
+	}
+	cuUEs := make(map[string]int)
+	duUEs := make(map[string]int)
+	for _, ue := range ues {
+		if ue.GetCuE2NodeId() != "" {
+			cuUEs[ue.GetCuE2NodeId()]++
+		}
+		if ue.GetDuE2NodeId() != "" {
+			duUEs[ue.GetDuE2NodeId()]++
+		}
+	}
+	for nodeID, n := range cuUEs {
+		ch <- prometheus.MustNewConstMetric(uesDesc, prometheus.GaugeValue, float64(n), nodeID, "cu")
+	}
+	for nodeID, n := range duUEs {
+		ch <- prometheus.MustNewConstMetric(uesDesc, prometheus.GaugeValue, float64(n), nodeID, "du")
+	}
+}
+
+func (c *Collector) collectSlices(ctx context.Context, ch chan<- prometheus.Metric) {
+	if c.rnibClient == nil {
+		return
+	}
+	items, err := c.rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
+	if err != nil {
+		log.Warnf("Failed to collect the slice metrics: %v", err)
+		return
+	}
+	for nodeID, nodeItems := range items {
+		slices := make(map[string]int)
+		weights := make(map[string]int64)
+		for _, item := range nodeItems {
+			sliceType := item.GetSliceType().String()
+			slices[sliceType]++
+			weights[sliceType] += int64(item.GetSliceParameters().GetWeight())
+		}
+		for sliceType, n := range slices {
+			ch <- prometheus.MustNewConstMetric(slicesDesc, prometheus.GaugeValue, float64(n), nodeID, sliceType)
+			ch <- prometheus.MustNewConstMetric(sliceWeightSumDesc, prometheus.GaugeValue, float64(weights[sliceType]), nodeID, sliceType)
+		}
+	}
+}
+
+func (c *Collector) collectKpis(ch chan<- prometheus.Metric) {
+	if c.kpiStore == nil {
+		return
+	}
+	// E2SM-RSM does not identify the slice of the metrics, so they are labeled with the UE they were reported for
+	for _, sample := range c.kpiStore.Latest() {
+		labels := []string{sample.E2NodeID, sample.SliceType, strconv.FormatInt(sample.DuUeF1apID, 10)}
+		ch <- prometheus.MustNewConstMetric(slicePrbUtilizationDesc, prometheus.GaugeValue, float64(sample.PrbUtilization), labels...)
+		ch <- prometheus.MustNewConstMetric(sliceUesDesc, prometheus.GaugeValue, float64(sample.NumUes), labels...)
+		ch <- prometheus.MustNewConstMetric(sliceBlerDesc, prometheus.GaugeValue, float64(sample.Bler), labels...)
+		ch <- prometheus.MustNewConstMetric(sliceAvgCqiDesc, prometheus.GaugeValue, float64(sample.AvgCqi), labels...)
+	}
+}
@@ -0,0 +1,50 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package metrics
+
+import (
+	"context"
+	"time"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	"github.com/onosproject/onos-rsm/api"
+	"google.golang.org/grpc"
+)
+
+type rsmAckResponse interface {
+	GetAck() *rsmapi.Ack
+}
+
+type slicePairAckResponse interface {
+	GetAck() *api.Ack
+}
+
+// UnaryServerInterceptor records the latency and the outcome of the northbound requests;
+// a request which returns an ACK without success is a failure
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		ObserveNorthboundRequest(info.FullMethod, requestOutcome(resp, err), time.Since(start))
+		return resp, err
+	}
+}
+
+func requestOutcome(resp interface{}, err error) string {
+	if err != nil {
+		return OutcomeError
+	}
+	switch r := resp.(type) {
+	case rsmAckResponse:
+		if !r.GetAck().GetSuccess() {
+			return OutcomeFailure
+		}
+	case slicePairAckResponse:
+		if ack := r.GetAck(); ack == nil || !ack.Success {
+			return OutcomeFailure
+		}
+	}
+	return OutcomeSuccess
+}
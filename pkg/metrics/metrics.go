@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package metrics has the Prometheus metrics of onos-rsm and their HTTP endpoint
+package metrics
+
+import (
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var log = logging.GetLogger()
+
+const namespace = "onos_rsm"
+
+var (
+	northboundRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "northbound",
+		Name:      "requests_total",
+		Help:      "Northbound requests by RPC and outcome",
+	}, []string{"rpc", "outcome"})
+
+	northboundLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "northbound",
+		Name:      "request_duration_seconds",
+		Help:      "Northbound request latency by RPC and outcome",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"rpc", "outcome"})
+
+	controlLatency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "e2",
+		Name:      "control_duration_seconds",
+		Help:      "E2 control latency, retries included, by E2 node, command and outcome",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 14),
+	}, []string{"e2_node_id", "command", "outcome"})
+
+	controlFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "e2",
+		Name:      "control_failures_total",
+		Help:      "Failed E2 control messages by E2 node, command and cause",
+	}, []string{"e2_node_id", "command", "cause"})
+
+	ackTimeouts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "e2",
+		Name:      "control_ack_timeouts_total",
+		Help:      "E2 control messages not acknowledged before the ACK timer expired by E2 node and command",
+	}, []string{"e2_node_id", "command"})
+
+	indications = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "e2",
+		Name:      "indications_total",
+		Help:      "RSM indications received by E2 node and type",
+	}, []string{"e2_node_id", "type"})
+)
+
+func init() {
+	prometheus.MustRegister(northboundRequests, northboundLatency, controlLatency, controlFailures, ackTimeouts, indications)
+}
+
+// Outcomes of the northbound requests and the E2 control messages
+const (
+	OutcomeSuccess = "success"
+	OutcomeFailure = "failure"
+	OutcomeError   = "error"
+)
+
+// ObserveNorthboundRequest records a northbound request
+func ObserveNorthboundRequest(rpc string, outcome string, duration time.Duration) {
+	northboundRequests.WithLabelValues(rpc, outcome).Inc()
+	northboundLatency.WithLabelValues(rpc, outcome).Observe(duration.Seconds())
+}
+
+// ObserveControl records an acknowledged E2 control message; the cause of a failure is counted
+func ObserveControl(nodeID string, command string, success bool, cause string, duration time.Duration) {
+	outcome := OutcomeSuccess
+	if !success {
+		outcome = OutcomeFailure
+		controlFailures.WithLabelValues(nodeID, command, cause).Inc()
+	}
+	controlLatency.WithLabelValues(nodeID, command, outcome).Observe(duration.Seconds())
+}
+
+// ObserveAckTimeout records an E2 control message which was not acknowledged in time
+func ObserveAckTimeout(nodeID string, command string) {
+	ackTimeouts.WithLabelValues(nodeID, command).Inc()
+	controlFailures.WithLabelValues(nodeID, command, "timeout").Inc()
+}
+
+// ObserveIndication records a received RSM indication
+func ObserveIndication(nodeID string, indicationType string) {
+	indications.WithLabelValues(nodeID, indicationType).Inc()
+}
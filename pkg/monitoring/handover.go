@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+// DefaultHandoverGracePeriod is the time to wait for the other half of a handover;
+// a UE handed out which is not handed in in time is detached by the garbage collector
+const DefaultHandoverGracePeriod = 30 * time.Second
+
+// minGCInterval is the shortest period of the garbage collection of the UEs handed out
+const minGCInterval = time.Second
+
+// HandoverEvent is a completed handover of a UE from a source DU to a target DU
+type HandoverEvent struct {
+	// GlobalUeID is the UE ID kept across the handover
+	GlobalUeID string
+	// Source is the UE before the handover, including its slice list
+	Source *uenib_api.RsmUeInfo
+	// Target is the UE after the handover, as stored in UE-NIB
+	Target *uenib_api.RsmUeInfo
+}
+
+type handIn struct {
+	ue   *uenib_api.RsmUeInfo
+	time time.Time
+	// held is true if the target UE was not added to UE-NIB, because the source UE is still there with its global UE ID
+	held bool
+}
+
+func NewHandoverTracker(uenibClient uenib.Client, identityResolver *UEIdentityResolver, observer IndicationObserver, handoverCh chan HandoverEvent, gracePeriod time.Duration) *HandoverTracker {
+	if gracePeriod <= 0 {
+		gracePeriod = DefaultHandoverGracePeriod
+	}
+	return &HandoverTracker{
+		uenibClient:      uenibClient,
+		identityResolver: identityResolver,
+		observer:         observer,
+		handoverCh:       handoverCh,
+		gracePeriod:      gracePeriod,
+		handOuts:         make(map[int64]*uenib_api.RsmUeInfo),
+		handIns:          make(map[int64]*handIn),
+	}
+}
+
+// HandoverTracker correlates hand-outs and hand-ins reported by all E2 nodes by AMF UE NGAP ID.
+// A UE handed out is kept in UE-NIB in the HANDING_OVER state until the matching hand-in is reported
+// or the grace period is over; the state is in UE-NIB so that the hand-in is correlated after a restart too.
+type HandoverTracker struct {
+	uenibClient      uenib.Client
+	identityResolver *UEIdentityResolver
+	observer         IndicationObserver
+	handoverCh       chan HandoverEvent
+	gracePeriod      time.Duration
+	mu               sync.Mutex
+	handOuts         map[int64]*uenib_api.RsmUeInfo
+	handIns          map[int64]*handIn
+}
+
+// Run collects the UEs whose hand-in did not come within the grace period until the context is done
+func (t *HandoverTracker) Run(ctx context.Context) {
+	interval := t.gracePeriod / 2
+	if interval < minGCInterval {
+		interval = minGCInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.collect(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// collect detaches the UEs in the HANDING_OVER state for longer than the grace period
+// and moves the UEs whose hand-in was held to their target DU
+func (t *HandoverTracker) collect(ctx context.Context) {
+	held := make([]*uenib_api.RsmUeInfo, 0)
+	t.mu.Lock()
+	for id, in := range t.handIns {
+		if time.Since(in.time) >= t.gracePeriod {
+			delete(t.handIns, id)
+			if in.held {
+				held = append(held, in.ue)
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	// the hand-out of a held hand-in was not reported, so the source UE is updated in place
+	for _, target := range held {
+		source, err := t.uenibClient.GetUEWithGlobalID(ctx, target.GetGlobalUeID())
+		if err != nil {
+			log.Infof("No hand-out for UE %v handed in on DU %v - adding it", target.GetGlobalUeID(), target.GetDuE2NodeId())
+			err = t.uenibClient.AddUE(ctx, target)
+		} else {
+			log.Infof("No hand-out for UE %v handed in on DU %v - moving it from DU %v", target.GetGlobalUeID(), target.GetDuE2NodeId(), source.GetDuE2NodeId())
+			err = t.complete(ctx, source, target)
+		}
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+
+	lifecycles, err := t.uenibClient.GetUELifecycles(ctx)
+	if err != nil {
+		log.Warnf("Failed to collect the UEs handed out: %v", err)
+		return
+	}
+	for globalUeID, lifecycle := range lifecycles {
+		if lifecycle.State != uenib.UEStateHandingOver || time.Since(lifecycle.Since) < t.gracePeriod {
+			continue
+		}
+		t.mu.Lock()
+		for amfUeNgapID, out := range t.handOuts {
+			if out.GetGlobalUeID() == globalUeID {
+				delete(t.handOuts, amfUeNgapID)
+			}
+		}
+		t.mu.Unlock()
+		log.Infof("No hand-in for UE %v since %v - detaching it", globalUeID, lifecycle.Since)
+		if source, err := t.uenibClient.GetUEWithGlobalID(ctx, globalUeID); err == nil && t.observer != nil {
+			t.observer.UEDetached(topoapi.ID(source.GetCuE2NodeId()), uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, source.GetUeIdList().GetCuUeF1apID().GetValue())
+		}
+		// the UE-NIB watchers see why the UE is removed
+		if err := t.uenibClient.SetUEState(ctx, globalUeID, uenib.UEStateDetached, "", "no hand-in"); err != nil {
+			log.Warn(err)
+		}
+		if err := t.uenibClient.DeleteUE(ctx, globalUeID); err != nil {
+			log.Warn(err)
+			continue
+		}
+		if t.identityResolver != nil {
+			t.identityResolver.Release(globalUeID)
+		}
+	}
+}
+
+// HandOut is called when a UE is handed out; the source UE is kept in UE-NIB in the HANDING_OVER state until
+// the matching hand-in is reported or the grace period is over
+func (t *HandoverTracker) HandOut(ctx context.Context, source *uenib_api.RsmUeInfo) error {
+	amfUeNgapID := source.GetUeIdList().GetAMFUeNgapID().GetValue()
+	t.mu.Lock()
+	in, ok := t.handIns[amfUeNgapID]
+	if ok && time.Since(in.time) < t.gracePeriod {
+		delete(t.handIns, amfUeNgapID)
+		t.mu.Unlock()
+		// the hand-in was reported first and the target UE was added, with a new global UE ID if the
+		// resolver did not recognize it as the source UE
+		if in.ue.GetGlobalUeID() != source.GetGlobalUeID() {
+			err := t.uenibClient.DeleteUE(ctx, in.ue.GetGlobalUeID())
+			if err != nil {
+				return err
+			}
+		}
+		return t.complete(ctx, source, in.ue)
+	}
+	delete(t.handIns, amfUeNgapID)
+	t.handOuts[amfUeNgapID] = source
+	t.mu.Unlock()
+	return t.uenibClient.SetUEState(ctx, source.GetGlobalUeID(), uenib.UEStateHandingOver, source.GetDuE2NodeId(), "hand-out")
+}
+
+// HandIn is called when a UE is handed in; it returns true if the caller must not add the target UE to UE-NIB:
+// the handover was completed with a previous hand-out, or the source UE is still in UE-NIB with the global UE ID
+// of the target UE, and the hand-in is held until the hand-out is reported or the grace period is over.
+// Otherwise the caller adds the target UE and the hand-in is kept to be correlated with a later hand-out.
+func (t *HandoverTracker) HandIn(ctx context.Context, target *uenib_api.RsmUeInfo) (bool, error) {
+	amfUeNgapID := target.GetUeIdList().GetAMFUeNgapID().GetValue()
+	t.mu.Lock()
+	source, ok := t.handOuts[amfUeNgapID]
+	if ok {
+		delete(t.handOuts, amfUeNgapID)
+		t.mu.Unlock()
+		return true, t.complete(ctx, source, target)
+	}
+	t.mu.Unlock()
+
+	// the hand-out was reported before a restart: the UE handed out has the global UE ID the target UE was resolved to
+	if lifecycle, err := t.uenibClient.GetUELifecycle(ctx, target.GetGlobalUeID()); err == nil && lifecycle.State == uenib.UEStateHandingOver {
+		source, err := t.uenibClient.GetUEWithGlobalID(ctx, target.GetGlobalUeID())
+		if err != nil {
+			return false, err
+		}
+		return true, t.complete(ctx, source, target)
+	}
+
+	// the hand-in is reported before the hand-out and the resolver gave the target UE the ID of the source UE
+	_, err := t.uenibClient.GetUEWithGlobalID(ctx, target.GetGlobalUeID())
+	held := err == nil
+	t.mu.Lock()
+	t.handIns[amfUeNgapID] = &handIn{
+		ue:   target,
+		time: time.Now(),
+		held: held,
+	}
+	t.mu.Unlock()
+	if held {
+		log.Infof("UE %v handed in on DU %v before its hand-out - holding the hand-in", target.GetGlobalUeID(), target.GetDuE2NodeId())
+	}
+	return held, nil
+}
+
+// complete moves the source UE to the target in UE-NIB keeping the global UE ID
+// and reports the handover so that the slice associations are re-issued
+func (t *HandoverTracker) complete(ctx context.Context, source *uenib_api.RsmUeInfo, target *uenib_api.RsmUeInfo) error {
+	ue := proto.Clone(target).(*uenib_api.RsmUeInfo)
+	ue.GlobalUeID = source.GetGlobalUeID()
+	ue.SliceList = make([]*uenib_api.SliceInfo, 0)
+	err := t.uenibClient.UpdateUE(ctx, ue)
+	if err != nil {
+		return err
+	}
+	err = t.uenibClient.SetUEState(ctx, ue.GetGlobalUeID(), uenib.UEStateAttached, ue.GetDuE2NodeId(), "hand-in")
+	if err != nil {
+		return err
+	}
+	log.Infof("UE %v handed over from DU %v to DU %v", ue.GetGlobalUeID(), source.GetDuE2NodeId(), ue.GetDuE2NodeId())
+	if t.handoverCh == nil {
+		return nil
+	}
+	// the channel is buffered; if the slicing manager falls behind, wait for it unless the indication stream is closed
+	select {
+	case t.handoverCh <- HandoverEvent{
+		GlobalUeID: ue.GetGlobalUeID(),
+		Source:     source,
+		Target:     ue,
+	}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("slice associations of UE %v not re-issued after handover: %v", ue.GetGlobalUeID(), ctx.Err())
+	}
+}
@@ -0,0 +1,208 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package monitoring
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingObserver records the UE state reported to the observer
+type recordingObserver struct {
+	mu        sync.Mutex
+	attached  []int64
+	detached  []int64
+	handedOut []int64
+}
+
+func (o *recordingObserver) UEAttached(_ topoapi.ID, _ topoapi.ID, ueID *uenib_api.UeIdentity) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.attached = append(o.attached, ueID.GetCuUeF1apID().GetValue())
+}
+
+func (o *recordingObserver) UEDetached(_ topoapi.ID, _ uenib_api.UeIdType, ueID int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.detached = append(o.detached, ueID)
+}
+
+func (o *recordingObserver) UEHandedOut(_ topoapi.ID, ueID *uenib_api.UeIdentity) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.handedOut = append(o.handedOut, ueID.GetCuUeF1apID().GetValue())
+}
+
+type handoverTest struct {
+	monitor     *Monitor
+	tracker     *HandoverTracker
+	resolver    *UEIdentityResolver
+	observer    *recordingObserver
+	uenibClient uenib.Client
+	handoverCh  chan HandoverEvent
+}
+
+func newHandoverTest(ctx context.Context, t *testing.T, gracePeriod time.Duration) *handoverTest {
+	uenibClient := newTestUenib(t)
+	resolver := NewUEIdentityResolver(uenibClient)
+	observer := &recordingObserver{}
+	handoverCh := make(chan HandoverEvent, 10)
+	tracker := NewHandoverTracker(uenibClient, resolver, observer, handoverCh, gracePeriod)
+	return &handoverTest{
+		monitor: NewMonitor(
+			WithNodeID(testCUNodeID),
+			WithRNIBClient(newTestTopology(ctx, t)),
+			WithUENIBClient(uenibClient),
+			WithUEIdentityResolver(resolver),
+			WithIndicationObserver(observer),
+			WithHandoverTracker(tracker)),
+		tracker:     tracker,
+		resolver:    resolver,
+		observer:    observer,
+		uenibClient: uenibClient,
+		handoverCh:  handoverCh,
+	}
+}
+
+func (h *handoverTest) ues(ctx context.Context, t *testing.T) []*uenib_api.RsmUeInfo {
+	ues, err := h.uenibClient.GetUEs(ctx)
+	require.NoError(t, err)
+	return ues
+}
+
+func (h *handoverTest) state(ctx context.Context, t *testing.T, globalUeID string) uenib.UEState {
+	lifecycle, err := h.uenibClient.GetUELifecycle(ctx, globalUeID)
+	require.NoError(t, err)
+	return lifecycle.State
+}
+
+func TestHandOutIsNotDetach(t *testing.T) {
+	ctx := context.Background()
+	gracePeriod := 50 * time.Millisecond
+	h := newHandoverTest(ctx, t, gracePeriod)
+
+	ue := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue))
+	require.Len(t, h.ues(ctx, t), 1)
+	globalUeID := h.ues(ctx, t)[0].GetGlobalUeID()
+
+	// the UE handed out is kept handing over and is not reported detached
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_OUT_UE_ATTACH, 1, ue))
+	require.Len(t, h.ues(ctx, t), 1)
+	assert.Equal(t, uenib.UEStateHandingOver, h.state(ctx, t, globalUeID))
+	assert.Equal(t, []int64{1}, h.observer.handedOut)
+	assert.Empty(t, h.observer.detached)
+
+	// the garbage collection does not detach the UE within the grace period
+	h.tracker.collect(ctx)
+	require.Len(t, h.ues(ctx, t), 1)
+	assert.Empty(t, h.observer.detached)
+
+	// the UE whose hand-in is overdue is detached, reported detached and its keys are released
+	time.Sleep(gracePeriod)
+	h.tracker.collect(ctx)
+	assert.Empty(t, h.ues(ctx, t))
+	assert.Equal(t, []int64{1}, h.observer.detached)
+	_, ok := h.resolver.Lookup(amfKey(ue))
+	assert.False(t, ok)
+}
+
+func TestDetachWithHandoverTracker(t *testing.T) {
+	ctx := context.Background()
+	h := newHandoverTest(ctx, t, time.Minute)
+
+	ue := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue))
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_DETACH, 1, ue))
+	assert.Empty(t, h.ues(ctx, t))
+	assert.Equal(t, []int64{1}, h.observer.detached)
+	assert.Empty(t, h.observer.handedOut)
+}
+
+// attachWithSlice attaches the UE on the cell 1 of DU 1 and associates it with a slice
+func (h *handoverTest) attachWithSlice(ctx context.Context, t *testing.T, ue testUE) string {
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue))
+	ueInfo := getUE(ctx, t, h.uenibClient, ue)
+	ueInfo.SliceList = []*uenib_api.SliceInfo{{DuE2NodeId: string(testDU1NodeID), CuE2NodeId: string(testCUNodeID), ID: "1"}}
+	require.NoError(t, h.uenibClient.UpdateUE(ctx, ueInfo))
+	return ueInfo.GetGlobalUeID()
+}
+
+// assertHandedOver asserts the UE was moved to DU 2 with its global UE ID and its slices are re-issued
+func (h *handoverTest) assertHandedOver(ctx context.Context, t *testing.T, globalUeID string, target testUE) {
+	ues := h.ues(ctx, t)
+	require.Len(t, ues, 1)
+	assert.Equal(t, globalUeID, ues[0].GetGlobalUeID())
+	assert.Equal(t, string(testDU2NodeID), ues[0].GetDuE2NodeId())
+	assert.Equal(t, target.cuUeF1apID, ues[0].GetUeIdList().GetCuUeF1apID().GetValue())
+	assert.Equal(t, uenib.UEStateAttached, h.state(ctx, t, globalUeID))
+	select {
+	case event := <-h.handoverCh:
+		assert.Equal(t, globalUeID, event.GlobalUeID)
+		assert.Len(t, event.Source.GetSliceList(), 1)
+		assert.Equal(t, string(testDU2NodeID), event.Target.GetDuE2NodeId())
+	default:
+		assert.Fail(t, "no handover event")
+	}
+	assert.Empty(t, h.observer.detached)
+}
+
+func TestHandOutBeforeHandIn(t *testing.T) {
+	ctx := context.Background()
+	h := newHandoverTest(ctx, t, time.Minute)
+
+	source := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	target := testUE{cuUeF1apID: 2, duUeF1apID: 12, ranUeNgapID: 21, amfUeNgapID: 31}
+	globalUeID := h.attachWithSlice(ctx, t, source)
+
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_OUT_UE_ATTACH, 1, source))
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_IN_UE_ATTACH, 2, target))
+	h.assertHandedOver(ctx, t, globalUeID, target)
+}
+
+func TestHandInBeforeHandOut(t *testing.T) {
+	ctx := context.Background()
+	h := newHandoverTest(ctx, t, time.Minute)
+
+	source := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	target := testUE{cuUeF1apID: 2, duUeF1apID: 12, ranUeNgapID: 21, amfUeNgapID: 31}
+	globalUeID := h.attachWithSlice(ctx, t, source)
+
+	// the hand-in is held and the source UE is kept until its hand-out
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_IN_UE_ATTACH, 2, target))
+	ues := h.ues(ctx, t)
+	require.Len(t, ues, 1)
+	assert.Equal(t, globalUeID, ues[0].GetGlobalUeID())
+	assert.Equal(t, string(testDU1NodeID), ues[0].GetDuE2NodeId())
+	assert.Empty(t, h.handoverCh)
+
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_OUT_UE_ATTACH, 1, source))
+	h.assertHandedOver(ctx, t, globalUeID, target)
+}
+
+func TestHandInWithoutHandOut(t *testing.T) {
+	ctx := context.Background()
+	gracePeriod := 50 * time.Millisecond
+	h := newHandoverTest(ctx, t, gracePeriod)
+
+	source := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	target := testUE{cuUeF1apID: 2, duUeF1apID: 12, ranUeNgapID: 21, amfUeNgapID: 31}
+	globalUeID := h.attachWithSlice(ctx, t, source)
+	require.NoError(t, emmEvent(ctx, t, h.monitor, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_IN_UE_ATTACH, 2, target))
+
+	// the source UE is moved in place once the hand-out is overdue
+	time.Sleep(gracePeriod)
+	h.tracker.collect(ctx)
+	h.assertHandedOver(ctx, t, globalUeID, target)
+}
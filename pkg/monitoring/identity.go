@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_v2_ies "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-v2-ies"
+	"github.com/onosproject/onos-lib-go/api/asn1/v1/asn1"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"google.golang.org/protobuf/encoding/prototext"
+)
+
+// UEIdentityKey is a RAN UE ID in the scope in which it identifies a UE: an AMF UE NGAP ID in a PLMN,
+// or a RAN UE NGAP ID on a CU. E2SM-RSM does not report the AMF of a UE, so its PLMN is the scope of the AMF UE NGAP ID.
+type UEIdentityKey struct {
+	Type  uenib_api.UeIdType
+	Scope string
+	Value int64
+}
+
+func (k UEIdentityKey) String() string {
+	return fmt.Sprintf("%v/%v/%v", k.Type, k.Scope, k.Value)
+}
+
+// UEIdentityKeys returns the identity keys of the UE, the AMF UE NGAP ID key first
+func UEIdentityKeys(ue *uenib_api.RsmUeInfo, plmnID string) []UEIdentityKey {
+	keys := make([]UEIdentityKey, 0, 2)
+	if amfUeNgapID := ue.GetUeIdList().GetAMFUeNgapID().GetValue(); amfUeNgapID != 0 && plmnID != "" {
+		keys = append(keys, UEIdentityKey{
+			Type:  uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID,
+			Scope: plmnID,
+			Value: amfUeNgapID,
+		})
+	}
+	if ranUeNgapID := ue.GetUeIdList().GetRANUeNgapID().GetValue(); ranUeNgapID != 0 && ue.GetCuE2NodeId() != "" {
+		keys = append(keys, UEIdentityKey{
+			Type:  uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID,
+			Scope: ue.GetCuE2NodeId(),
+			Value: ranUeNgapID,
+		})
+	}
+	return keys
+}
+
+// PlmnID returns the PLMN ID of the cell global ID in hex, or an empty string if it has none
+func PlmnID(cgi *e2sm_v2_ies.Cgi) string {
+	var plmnID []byte
+	if cgi.GetNRCgi() != nil {
+		plmnID = cgi.GetNRCgi().GetPLmnidentity().GetValue()
+	} else {
+		plmnID = cgi.GetEUtraCgi().GetPLmnidentity().GetValue()
+	}
+	if len(plmnID) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%x", plmnID)
+}
+
+// CellGlobalID returns the cell global ID of the cell global ID reported in an indication; it returns an error
+// if the PLMN ID is not 3 bytes or the cell identity is not a bit string of the cell identity length
+func CellGlobalID(cgi *e2sm_v2_ies.Cgi) (rnib.CellGlobalID, error) {
+	var plmnID []byte
+	var cellID *asn1.BitString
+	cellIDBits := rnib.NRCellIDBits
+	switch {
+	case cgi.GetNRCgi() != nil:
+		plmnID = cgi.GetNRCgi().GetPLmnidentity().GetValue()
+		cellID = cgi.GetNRCgi().GetNRcellIdentity().GetValue()
+	case cgi.GetEUtraCgi() != nil:
+		plmnID = cgi.GetEUtraCgi().GetPLmnidentity().GetValue()
+		cellID = cgi.GetEUtraCgi().GetEUtracellIdentity().GetValue()
+		cellIDBits = rnib.EUTRACellIDBits
+	default:
+		return rnib.CellGlobalID{}, errors.NewInvalid("cell global ID %v is neither an NR nor an E-UTRA cell global ID", cgi)
+	}
+	if len(plmnID) != 3 {
+		return rnib.CellGlobalID{}, errors.NewInvalid("PLMN ID %x of cell global ID %v is not 3 bytes", plmnID, cgi)
+	}
+	// the bits of the cell identity are the first Len bits of the value
+	if int(cellID.GetLen()) != cellIDBits || len(cellID.GetValue()) != (cellIDBits+7)/8 {
+		return rnib.CellGlobalID{}, errors.NewInvalid("cell identity of cell global ID %v is not a bit string of %v bits", cgi, cellIDBits)
+	}
+
+	result := rnib.CellGlobalID{
+		CellIDBits: cellIDBits,
+	}
+	for _, b := range plmnID {
+		result.PlmnID = result.PlmnID<<8 | uint32(b)
+	}
+	for _, b := range cellID.GetValue() {
+		result.CellID = result.CellID<<8 | uint64(b)
+	}
+	result.CellID >>= uint(len(cellID.GetValue())*8 - cellIDBits)
+	return result, nil
+}
+
+// cellPlmnID returns the PLMN ID of the cell global ID a UE is stored with in UE-NIB
+func cellPlmnID(cellGlobalID string) string {
+	cgi := &e2sm_v2_ies.Cgi{}
+	if err := prototext.Unmarshal([]byte(cellGlobalID), cgi); err != nil {
+		return ""
+	}
+	return PlmnID(cgi)
+}
+
+func NewUEIdentityResolver(uenibClient uenib.Client) *UEIdentityResolver {
+	return &UEIdentityResolver{
+		uenibClient: uenibClient,
+		ids:         make(map[UEIdentityKey]string),
+	}
+}
+
+// UEIdentityResolver gives a UE the same global UE ID each time it attaches or is handed over,
+// and keeps the map of the identity keys of the UEs in UE-NIB to their global UE IDs
+type UEIdentityResolver struct {
+	uenibClient uenib.Client
+	mu          sync.RWMutex
+	ids         map[UEIdentityKey]string
+}
+
+// Resolve returns the global UE ID of an attaching UE: the ID of a UE in UE-NIB with one of its keys, or a new ID.
+// The other UEs in UE-NIB with one of its keys are duplicates of the UE and are deleted. The RAN and AMF UE NGAP IDs
+// are recycled, so a UE which is no longer in UE-NIB does not give its ID to a UE attaching with its keys.
+func (r *UEIdentityResolver) Resolve(ctx context.Context, ue *uenib_api.RsmUeInfo, plmnID string) (string, error) {
+	keys := UEIdentityKeys(ue, plmnID)
+	if len(keys) == 0 {
+		log.Warnf("UE %v has neither an AMF UE NGAP ID nor a RAN UE NGAP ID - it gets a random global UE ID", ue.GetUeIdList())
+		return uuid.New().String(), nil
+	}
+	matched := make([]string, 0)
+	for _, key := range keys {
+		ues, err := r.getUEs(ctx, key)
+		if err != nil {
+			return "", err
+		}
+		for _, other := range ues {
+			matched = append(matched, other.GetGlobalUeID())
+		}
+	}
+	globalUeID := uuid.New().String()
+	if len(matched) > 0 {
+		globalUeID = matched[0]
+	}
+
+	r.mu.Lock()
+	duplicates := make(map[string]bool)
+	for _, id := range matched {
+		if id != globalUeID {
+			duplicates[id] = true
+		}
+	}
+	for key, id := range r.ids {
+		if duplicates[id] {
+			delete(r.ids, key)
+		}
+	}
+	for _, key := range keys {
+		r.ids[key] = globalUeID
+	}
+	r.mu.Unlock()
+
+	for _, id := range matched {
+		if !duplicates[id] {
+			continue
+		}
+		log.Infof("UE %v is a duplicate of UE %v - deleting it", id, globalUeID)
+		if err := r.uenibClient.DeleteUE(ctx, id); err != nil {
+			log.Warn(err)
+		}
+		delete(duplicates, id)
+	}
+	return globalUeID, nil
+}
+
+// getUEs returns the UEs in UE-NIB with the identity key
+func (r *UEIdentityResolver) getUEs(ctx context.Context, key UEIdentityKey) ([]*uenib_api.RsmUeInfo, error) {
+	if key.Type == uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID {
+		return r.uenibClient.GetUEsWithPreferredID(ctx, key.Scope, key.Type, key.Value)
+	}
+	// the AMF UE NGAP ID is looked up in all CUs and the UEs of other PLMNs are left out
+	ues, err := r.uenibClient.GetUEsWithPreferredID(ctx, "", key.Type, key.Value)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*uenib_api.RsmUeInfo, 0, len(ues))
+	for _, ue := range ues {
+		if cellPlmnID(ue.GetCellGlobalId()) == key.Scope {
+			result = append(result, ue)
+		}
+	}
+	return result, nil
+}
+
+// Release removes the identity keys of the UE once it is removed from UE-NIB
+func (r *UEIdentityResolver) Release(globalUeID string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key, id := range r.ids {
+		if id == globalUeID {
+			delete(r.ids, key)
+		}
+	}
+}
+
+// Lookup returns the global UE ID mapped to the identity key
+func (r *UEIdentityResolver) Lookup(key UEIdentityKey) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	id, ok := r.ids[key]
+	return id, ok
+}
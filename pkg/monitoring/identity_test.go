@@ -0,0 +1,122 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdentityTestMonitor(ctx context.Context, t *testing.T) (*Monitor, *UEIdentityResolver, func() []*uenib_api.RsmUeInfo) {
+	uenibClient := newTestUenib(t)
+	resolver := NewUEIdentityResolver(uenibClient)
+	m := NewMonitor(
+		WithNodeID(testCUNodeID),
+		WithRNIBClient(newTestTopology(ctx, t)),
+		WithUENIBClient(uenibClient),
+		WithUEIdentityResolver(resolver))
+	return m, resolver, func() []*uenib_api.RsmUeInfo {
+		ues, err := uenibClient.GetUEs(ctx)
+		require.NoError(t, err)
+		return ues
+	}
+}
+
+func amfKey(ue testUE) UEIdentityKey {
+	return UEIdentityKey{
+		Type:  uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID,
+		Scope: fmt.Sprintf("%x", testPlmnID),
+		Value: ue.amfUeNgapID,
+	}
+}
+
+func ranKey(ue testUE) UEIdentityKey {
+	return UEIdentityKey{
+		Type:  uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID,
+		Scope: string(testCUNodeID),
+		Value: ue.ranUeNgapID,
+	}
+}
+
+func TestResolveReattach(t *testing.T) {
+	ctx := context.Background()
+	m, resolver, ues := newIdentityTestMonitor(ctx, t)
+
+	ue := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue))
+	require.Len(t, ues(), 1)
+	globalUeID := ues()[0].GetGlobalUeID()
+
+	// the UE attaches again with new F1AP IDs without a detach, e.g. because the detach was lost
+	reattached := testUE{cuUeF1apID: 2, duUeF1apID: 12, ranUeNgapID: 21, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, reattached))
+	require.Len(t, ues(), 1)
+	assert.Equal(t, globalUeID, ues()[0].GetGlobalUeID())
+	assert.Equal(t, int64(2), ues()[0].GetUeIdList().GetCuUeF1apID().GetValue())
+	id, ok := resolver.Lookup(ranKey(ue))
+	assert.True(t, ok)
+	assert.Equal(t, globalUeID, id)
+}
+
+func TestResolveRecycledIDs(t *testing.T) {
+	ctx := context.Background()
+	m, resolver, ues := newIdentityTestMonitor(ctx, t)
+
+	ue := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue))
+	require.Len(t, ues(), 1)
+	globalUeID := ues()[0].GetGlobalUeID()
+
+	// the keys of the UE are released with its detach
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_DETACH, 1, ue))
+	assert.Empty(t, ues())
+	_, ok := resolver.Lookup(amfKey(ue))
+	assert.False(t, ok)
+	_, ok = resolver.Lookup(ranKey(ue))
+	assert.False(t, ok)
+
+	// another subscriber gets the recycled NGAP IDs and a new global UE ID
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 2, ue))
+	require.Len(t, ues(), 1)
+	assert.NotEqual(t, globalUeID, ues()[0].GetGlobalUeID())
+	id, ok := resolver.Lookup(amfKey(ue))
+	assert.True(t, ok)
+	assert.Equal(t, ues()[0].GetGlobalUeID(), id)
+}
+
+func TestResolveMergesDuplicates(t *testing.T) {
+	ctx := context.Background()
+	m, resolver, ues := newIdentityTestMonitor(ctx, t)
+
+	ue1 := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	ue2 := testUE{cuUeF1apID: 2, duUeF1apID: 12, ranUeNgapID: 22, amfUeNgapID: 32}
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue1))
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue2))
+	require.Len(t, ues(), 2)
+	id1, ok := resolver.Lookup(amfKey(ue1))
+	require.True(t, ok)
+	id2, ok := resolver.Lookup(amfKey(ue2))
+	require.True(t, ok)
+	require.NotEqual(t, id1, id2)
+
+	// a UE with the AMF UE NGAP ID of the first UE and the RAN UE NGAP ID of the second UE is the first UE,
+	// and the second UE is a duplicate of it
+	merged := testUE{cuUeF1apID: 3, duUeF1apID: 13, ranUeNgapID: 22, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, merged))
+	require.Len(t, ues(), 1)
+	assert.Equal(t, id1, ues()[0].GetGlobalUeID())
+	assert.Equal(t, int64(3), ues()[0].GetUeIdList().GetCuUeF1apID().GetValue())
+	id, ok := resolver.Lookup(ranKey(merged))
+	assert.True(t, ok)
+	assert.Equal(t, id1, id)
+	_, ok = resolver.Lookup(amfKey(ue2))
+	assert.False(t, ok)
+}
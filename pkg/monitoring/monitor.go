@@ -7,6 +7,8 @@ package monitoring
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
@@ -15,11 +17,13 @@ import (
 	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/onos-rsm/api"
 	"github.com/onosproject/onos-rsm/pkg/broker"
 	appConfig "github.com/onosproject/onos-rsm/pkg/config"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
+	"github.com/onosproject/onos-rsm/pkg/metrics"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
-	"google.golang.org/protobuf/proto"
 )
 
 var log = logging.GetLogger()
@@ -32,34 +36,59 @@ func NewMonitor(opts ...Option) *Monitor {
 
 	return &Monitor{
 		streamReader:           options.Monitor.StreamReader,
+		decoder:                options.Monitor.Decoder,
 		appConfig:              options.App.AppConfig,
 		nodeID:                 options.Monitor.NodeID,
 		rnibClient:             options.App.RnibClient,
 		uenibClient:            options.App.UenibClient,
 		ricIndEventTriggerType: options.App.EventTriggerType,
+		observer:               options.App.IndicationObserver,
+		handoverTracker:        options.App.HandoverTracker,
+		kpiStore:               options.App.KpiStore,
+		identityResolver:       options.App.IdentityResolver,
 	}
 }
 
+// IndicationDecoder decodes the indications of the service model version of a node
+type IndicationDecoder interface {
+	DecodeIndication(indication e2api.Indication) (*e2sm_rsm.E2SmRsmIndicationHeader, *e2sm_rsm.E2SmRsmIndicationMessage, error)
+}
+
+// IndicationObserver is notified of the UE state reported in RSM indications
+type IndicationObserver interface {
+	UEAttached(cuNodeID topoapi.ID, duNodeID topoapi.ID, ueID *uenib_api.UeIdentity)
+	UEDetached(cuNodeID topoapi.ID, preferredType uenib_api.UeIdType, ueID int64)
+	// UEHandedOut is called when a UE leaves its DU in a handover; the UE is not detached until the hand-in is overdue
+	UEHandedOut(cuNodeID topoapi.ID, ueID *uenib_api.UeIdentity)
+}
+
 type Monitor struct {
 	streamReader           broker.StreamReader
+	decoder                IndicationDecoder
 	appConfig              *appConfig.AppConfig
 	nodeID                 topoapi.ID
 	rnibClient             rnib.TopoClient
 	uenibClient            uenib.Client
 	ricIndEventTriggerType e2sm_rsm.RsmRicindicationTriggerType
+	observer               IndicationObserver
+	handoverTracker        *HandoverTracker
+	kpiStore               *kpi.Store
+	identityResolver       *UEIdentityResolver
 }
 
 func (m *Monitor) Start(ctx context.Context) error {
-	errCh := make(chan error)
+	errCh := make(chan error, 1)
 	go func() {
 		for {
 			indMsg, err := m.streamReader.Recv(ctx)
 			if err != nil {
 				errCh <- err
+				return
 			}
 			err = m.processIndication(ctx, indMsg, m.nodeID)
 			if err != nil {
 				errCh <- err
+				return
 			}
 		}
 	}()
@@ -72,19 +101,18 @@ func (m *Monitor) Start(ctx context.Context) error {
 	}
 }
 
-func (m *Monitor) processIndication(ctx context.Context, indMsg e2api.Indication, nodeID topoapi.ID) error {
-	indHeader := e2sm_rsm.E2SmRsmIndicationHeader{}
-	indPayload := e2sm_rsm.E2SmRsmIndicationMessage{}
-
-	err := proto.Unmarshal(indMsg.Header, &indHeader)
-	if err != nil {
-		return err
-	}
+// ProcessIndication processes an indication as if it was received on the stream, e.g. to replay a recording
+func (m *Monitor) ProcessIndication(ctx context.Context, indMsg e2api.Indication) error {
+	return m.processIndication(ctx, indMsg, m.nodeID)
+}
 
-	err = proto.Unmarshal(indMsg.Payload, &indPayload)
+func (m *Monitor) processIndication(ctx context.Context, indMsg e2api.Indication, nodeID topoapi.ID) error {
+	indHeader, indPayload, err := m.decoder.DecodeIndication(indMsg)
 	if err != nil {
+		metrics.ObserveIndication(string(nodeID), "invalid")
 		return err
 	}
+	metrics.ObserveIndication(string(nodeID), indicationType(indPayload))
 
 	if indPayload.GetIndicationMessageFormat1() != nil {
 		err = m.processMetricTypeMessage(ctx, indHeader.GetIndicationHeaderFormat1(), indPayload.GetIndicationMessageFormat1())
@@ -103,13 +131,59 @@ func (m *Monitor) processIndication(ctx context.Context, indMsg e2api.Indication
 	return nil
 }
 
-func (m *Monitor) processMetricTypeMessage(_ context.Context, indHdr *e2sm_rsm.E2SmRsmIndicationHeaderFormat1, indMsg *e2sm_rsm.E2SmRsmIndicationMessageFormat1) error {
+// indicationType returns the metrics label of the indication: metrics, or the EMM trigger type
+func indicationType(indPayload *e2sm_rsm.E2SmRsmIndicationMessage) string {
+	if indPayload.GetIndicationMessageFormat2() != nil {
+		return strings.ToLower(strings.TrimPrefix(indPayload.GetIndicationMessageFormat2().GetTriggerType().String(), "RSM_EMM_TRIGGER_TYPE_"))
+	}
+	return "metrics"
+}
+
+// processMetricTypeMessage stores the slice metrics a DU reported for a UE
+func (m *Monitor) processMetricTypeMessage(ctx context.Context, indHdr *e2sm_rsm.E2SmRsmIndicationHeaderFormat1, indMsg *e2sm_rsm.E2SmRsmIndicationMessageFormat1) error {
 
 	log.Debugf("Received indication message (Metric) hdr: %v / msg: %v", indHdr, indMsg)
+	if m.kpiStore == nil {
+		return nil
+	}
 
+	// the UE is known by its CU UE F1AP ID in the UE-NIB; the metrics of a UE not found there are stored without its global UE ID
+	var ue *uenib_api.RsmUeInfo
+	cuNodeID, err := m.rnibClient.GetSourceCUE2NodeID(ctx, m.nodeID)
+	if err != nil {
+		log.Warn(err)
+	} else {
+		ue, err = m.uenibClient.GetUEWithPreferredID(ctx, string(cuNodeID), uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, indMsg.GetCuUeF1ApId().GetValue())
+		if err != nil {
+			log.Debugf("Received the metrics of UE %v which is not in UE-NIB: %v", indMsg.GetCuUeF1ApId().GetValue(), err)
+			ue = nil
+		}
+	}
+
+	now := time.Now()
+	m.addSliceMetrics(now, ue, indMsg.GetDuUeF1ApId().GetValue(), uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE, indMsg.GetDlSlicingMetrics())
+	m.addSliceMetrics(now, ue, indMsg.GetDuUeF1ApId().GetValue(), uenib_api.RSMSliceType_SLICE_TYPE_UL_SLICE, indMsg.GetUlSlicingMetrics())
 	return nil
 }
 
+// addSliceMetrics stores the metrics of a direction; E2SM-RSM does not identify the slice of the metrics,
+// so they are stored for the UE and the direction only
+func (m *Monitor) addSliceMetrics(now time.Time, ue *uenib_api.RsmUeInfo, duUeF1apID int64, sliceType uenib_api.RSMSliceType, metrics []*e2sm_rsm.SliceMetrics) {
+	for _, sliceMetrics := range metrics {
+		m.kpiStore.Add(api.KpiSample{
+			Time:           now,
+			E2NodeID:       string(m.nodeID),
+			SliceType:      topoapi.RSMSliceType(sliceType).String(),
+			GlobalUeID:     ue.GetGlobalUeID(),
+			DuUeF1apID:     duUeF1apID,
+			PrbUtilization: sliceMetrics.GetPrbUtilization(),
+			NumUes:         sliceMetrics.GetNumUeAssocToSlice(),
+			Bler:           sliceMetrics.GetSliceLevelBler(),
+			AvgCqi:         sliceMetrics.GetAvgCqi(),
+		})
+	}
+}
+
 func (m *Monitor) processEmmEventMessage(ctx context.Context, indHdr *e2sm_rsm.E2SmRsmIndicationHeaderFormat1, indMsg *e2sm_rsm.E2SmRsmIndicationMessageFormat2, cuNodeID string) error {
 	log.Debugf("Received indication message (EMM) hdr: %v / msg: %v", indHdr, indMsg)
 
@@ -117,7 +191,11 @@ func (m *Monitor) processEmmEventMessage(ctx context.Context, indHdr *e2sm_rsm.E
 	var EnbUeS1apID int32
 	bIDList := make([]*uenib_api.BearerId, 0)
 
-	duNodeID, err := m.rnibClient.GetTargetDUE2NodeID(ctx, topoapi.ID(cuNodeID))
+	var duNodeID topoapi.ID
+	cgi, err := CellGlobalID(indHdr.GetCgi())
+	if err == nil {
+		duNodeID, err = m.rnibClient.GetServingDUE2NodeID(ctx, topoapi.ID(cuNodeID), cgi)
+	}
 	log.Debugf("Cu ID %v - Du ID %v", cuNodeID, duNodeID)
 	if err != nil {
 		log.Warn(err)
@@ -203,9 +281,7 @@ func (m *Monitor) processEmmEventMessage(ctx context.Context, indHdr *e2sm_rsm.E
 
 	switch indMsg.GetTriggerType() {
 	case e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_IN_UE_ATTACH:
-		// ToDo: Add logic to get GlobalUEID here after SMO is integrated - future
 		rsmUE := &uenib_api.RsmUeInfo{
-			GlobalUeID: uuid.New().String(),
 			UeIdList: &uenib_api.UeIdentity{
 				CuUeF1apID: &uenib_api.CuUeF1ApID{
 					Value: CuUeF1apID,
@@ -229,43 +305,79 @@ func (m *Monitor) processEmmEventMessage(ctx context.Context, indHdr *e2sm_rsm.E
 			DuE2NodeId:   string(duNodeID),
 			SliceList:    make([]*uenib_api.SliceInfo, 0),
 		}
-		log.Debugf("pushed rsmUE: %v", rsmUE)
-		err := m.uenibClient.AddUE(ctx, rsmUE)
-		// ToDo: add ue on ue store
-
+		globalUeID, err := m.resolveGlobalUeID(ctx, rsmUE, PlmnID(indHdr.GetCgi()))
 		if err != nil {
 			return err
 		}
-	case e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_DETACH, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_OUT_UE_ATTACH:
-		// ToDo: delete ue from ue store
-		switch indMsg.GetPrefferedUeIdtype() {
-		case e2sm_rsm.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID:
-			err := m.uenibClient.DeleteUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, CuUeF1apID)
-			if err != nil {
-				return err
+		rsmUE.GlobalUeID = globalUeID
+		if indMsg.GetTriggerType() == e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH {
+			// the UE attaches again without having been detached, e.g. when the detach indication was lost
+			if stale, err := m.uenibClient.GetUEWithGlobalID(ctx, globalUeID); err == nil {
+				log.Infof("UE %v attached again on DU %v - replacing it", globalUeID, duNodeID)
+				if err := m.uenibClient.DeleteUE(ctx, stale.GetGlobalUeID()); err != nil {
+					return err
+				}
 			}
-		case e2sm_rsm.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID:
-			err := m.uenibClient.DeleteUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, DuUeF1apID)
+		}
+		log.Debugf("pushed rsmUE: %v", rsmUE)
+		if m.observer != nil {
+			m.observer.UEAttached(topoapi.ID(cuNodeID), duNodeID, rsmUE.GetUeIdList())
+		}
+		if indMsg.GetTriggerType() == e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_IN_UE_ATTACH && m.handoverTracker != nil && AmfUeNgapID != 0 {
+			// if the hand-out was already reported, the UE keeps its global UE ID and the source UE is updated instead;
+			// if the source UE is not handed out yet, the tracker holds the hand-in until it is
+			handled, err := m.handoverTracker.HandIn(ctx, rsmUE)
 			if err != nil {
 				return err
 			}
-		case e2sm_rsm.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID:
-			err := m.uenibClient.DeleteUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, RanUeNgapID)
-			if err != nil {
-				return err
+			if handled {
+				return nil
 			}
-		case e2sm_rsm.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:
-			err := m.uenibClient.DeleteUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, AmfUeNgapID)
-			if err != nil {
-				return err
+		}
+		err = m.uenibClient.AddUE(ctx, rsmUE)
+		// ToDo: add ue on ue store
+
+		if err != nil {
+			return err
+		}
+	case e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_DETACH, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_OUT_UE_ATTACH:
+		if indMsg.GetTriggerType() == e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_HAND_OUT_UE_ATTACH && m.handoverTracker != nil {
+			// the source UE is kept until the hand-in is reported so that its slices can be carried to the target DU;
+			// it is reported detached only if the hand-in is not reported in the grace period
+			source, err := m.getUEWithPreferredID(ctx, cuNodeID, indMsg.GetPrefferedUeIdtype(), CuUeF1apID, DuUeF1apID, RanUeNgapID, AmfUeNgapID, EnbUeS1apID)
+			if err == nil && source.GetUeIdList().GetAMFUeNgapID().GetValue() != 0 {
+				if m.observer != nil {
+					m.observer.UEHandedOut(topoapi.ID(cuNodeID), source.GetUeIdList())
+				}
+				return m.handoverTracker.HandOut(ctx, source)
 			}
-		case e2sm_rsm.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID:
-			err := m.uenibClient.DeleteUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID, int64(EnbUeS1apID))
-			if err != nil {
-				return err
+		}
+		if m.observer != nil {
+			var ueID int64
+			switch indMsg.GetPrefferedUeIdtype() {
+			case e2sm_rsm.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID:
+				ueID = CuUeF1apID
+			case e2sm_rsm.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID:
+				ueID = DuUeF1apID
+			case e2sm_rsm.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID:
+				ueID = RanUeNgapID
+			case e2sm_rsm.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:
+				ueID = AmfUeNgapID
+			case e2sm_rsm.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID:
+				ueID = int64(EnbUeS1apID)
 			}
-		default:
-			return errors.NewNotSupported(fmt.Sprintf("Unknown preferred ID type: %v", indMsg.GetPrefferedUeIdtype()))
+			m.observer.UEDetached(topoapi.ID(cuNodeID), uenib_api.UeIdType(indMsg.GetPrefferedUeIdtype()), ueID)
+		}
+		ue, err := m.getUEWithPreferredID(ctx, cuNodeID, indMsg.GetPrefferedUeIdtype(), CuUeF1apID, DuUeF1apID, RanUeNgapID, AmfUeNgapID, EnbUeS1apID)
+		if err != nil {
+			return err
+		}
+		if err := m.uenibClient.DeleteUE(ctx, ue.GetGlobalUeID()); err != nil {
+			return err
+		}
+		// the RAN and AMF UE NGAP IDs of the UE are given to other UEs from now on
+		if m.identityResolver != nil {
+			m.identityResolver.Release(ue.GetGlobalUeID())
 		}
 	default:
 		return errors.NewNotSupported(fmt.Sprintf("Unknown EMM trigger type: %v", indMsg.GetTriggerType()))
@@ -273,3 +385,28 @@ func (m *Monitor) processEmmEventMessage(ctx context.Context, indHdr *e2sm_rsm.E
 
 	return nil
 }
+
+// resolveGlobalUeID returns the stable global UE ID of the UE, or a random one if there is no resolver
+func (m *Monitor) resolveGlobalUeID(ctx context.Context, ue *uenib_api.RsmUeInfo, plmnID string) (string, error) {
+	if m.identityResolver == nil {
+		return uuid.New().String(), nil
+	}
+	return m.identityResolver.Resolve(ctx, ue, plmnID)
+}
+
+func (m *Monitor) getUEWithPreferredID(ctx context.Context, cuNodeID string, preferredType e2sm_rsm.UeIdType, cuUeF1apID, duUeF1apID, ranUeNgapID, amfUeNgapID int64, enbUeS1apID int32) (*uenib_api.RsmUeInfo, error) {
+	switch preferredType {
+	case e2sm_rsm.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID:
+		return m.uenibClient.GetUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, cuUeF1apID)
+	case e2sm_rsm.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID:
+		return m.uenibClient.GetUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, duUeF1apID)
+	case e2sm_rsm.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID:
+		return m.uenibClient.GetUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, ranUeNgapID)
+	case e2sm_rsm.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:
+		return m.uenibClient.GetUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, amfUeNgapID)
+	case e2sm_rsm.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID:
+		return m.uenibClient.GetUEWithPreferredID(ctx, cuNodeID, uenib_api.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID, int64(enbUeS1apID))
+	default:
+		return nil, errors.NewNotSupported(fmt.Sprintf("Unknown preferred ID type: %v", preferredType))
+	}
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/pdubuilder"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	e2sm_v2_ies "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-v2-ies"
+	"github.com/onosproject/onos-lib-go/api/asn1/v1/asn1"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCUNodeID  = topoapi.ID("e2:4/e00/2/c8")
+	testDU1NodeID = topoapi.ID("e2:4/e00/3/c8")
+	testDU2NodeID = topoapi.ID("e2:4/e00/3/c9")
+)
+
+// testPlmnID is the PLMN ID of the cells of the test topology
+var testPlmnID = []byte{0x00, 0xf1, 0x10}
+
+// testUE is the IDs of a UE reported in the test indications
+type testUE struct {
+	cuUeF1apID  int64
+	duUeF1apID  int64
+	ranUeNgapID int64
+	amfUeNgapID int64
+}
+
+// newTestTopology creates an R-NIB with a CU which contains two DUs serving the cells 1 and 2
+func newTestTopology(ctx context.Context, t *testing.T) rnib.TopoClient {
+	store, err := rnib.NewMemoryStore("")
+	require.NoError(t, err)
+	for _, nodeID := range []topoapi.ID{testCUNodeID, testDU1NodeID, testDU2NodeID} {
+		require.NoError(t, store.Create(ctx, &topoapi.Object{
+			ID:   nodeID,
+			Type: topoapi.Object_ENTITY,
+			Obj:  &topoapi.Object_Entity{Entity: &topoapi.Entity{KindID: topoapi.E2NODE}},
+		}))
+	}
+	for i, duNodeID := range []topoapi.ID{testDU1NodeID, testDU2NodeID} {
+		cellID := topoapi.ID(fmt.Sprintf("%v/cell-%v", duNodeID, i+1))
+		cell := &topoapi.Object{
+			ID:   cellID,
+			Type: topoapi.Object_ENTITY,
+			Obj:  &topoapi.Object_Entity{Entity: &topoapi.Entity{KindID: topoapi.E2CELL}},
+		}
+		require.NoError(t, cell.SetAspect(&topoapi.E2Cell{
+			CellGlobalID: &topoapi.CellGlobalID{Value: fmt.Sprintf("%x", i+1), Type: topoapi.CellGlobalIDType_NRCGI},
+		}))
+		require.NoError(t, store.Create(ctx, cell))
+		for _, relation := range [][2]topoapi.ID{{testCUNodeID, duNodeID}, {duNodeID, cellID}} {
+			require.NoError(t, store.Create(ctx, &topoapi.Object{
+				ID:   relation[0] + "-" + relation[1],
+				Type: topoapi.Object_RELATION,
+				Obj: &topoapi.Object_Relation{Relation: &topoapi.Relation{
+					KindID:      topoapi.CONTAINS,
+					SrcEntityID: relation[0],
+					TgtEntityID: relation[1],
+				}},
+			}))
+		}
+	}
+	return rnib.NewMemoryClient(context.Background(), store)
+}
+
+func newTestUenib(t *testing.T) uenib.Client {
+	store, err := uenib.NewMemoryStore("")
+	require.NoError(t, err)
+	return uenib.NewMemoryClient(store)
+}
+
+// emmEvent processes the EMM event of the UE reported by the CU in the NR cell
+func emmEvent(ctx context.Context, t *testing.T, m *Monitor, triggerType e2sm_rsm.RsmEmmTriggerType, cellID uint64, ue testUE) error {
+	cgi, err := pdubuilder.CreateNrCGI(testPlmnID, &asn1.BitString{
+		// the 36 bits of the cell identity are the first bits of the 5 bytes
+		Value: []byte{byte(cellID >> 28), byte(cellID >> 20), byte(cellID >> 12), byte(cellID >> 4), byte(cellID << 4)},
+		Len:   rnib.NRCellIDBits,
+	})
+	require.NoError(t, err)
+	header, err := pdubuilder.CreateE2SmRsmIndicationHeaderFormat1(cgi)
+	require.NoError(t, err)
+
+	ueIDs := make([]*e2sm_rsm.UeIdentity, 0)
+	for _, create := range []func() (*e2sm_rsm.UeIdentity, error){
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDCuUeF1ApID(ue.cuUeF1apID) },
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDDuUeF1ApID(ue.duUeF1apID) },
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDRanUeNgapID(ue.ranUeNgapID) },
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDAmfUeNgapID(ue.amfUeNgapID) },
+	} {
+		ueID, err := create()
+		require.NoError(t, err)
+		ueIDs = append(ueIDs, ueID)
+	}
+	bearer := &e2sm_rsm.BearerId{
+		BearerId: &e2sm_rsm.BearerId_DrbId{
+			DrbId: &e2sm_rsm.DrbId{
+				DrbId: &e2sm_rsm.DrbId_FiveGdrbId{
+					FiveGdrbId: &e2sm_rsm.FiveGDrbId{
+						Value: 5,
+						Qfi:   &e2sm_rsm.Qfi{Value: 1},
+						FlowsMapToDrb: []*e2sm_rsm.QoSflowLevelParameters{{
+							QoSflowLevelParameters: &e2sm_rsm.QoSflowLevelParameters_NonDynamicFiveQi{
+								NonDynamicFiveQi: &e2sm_rsm.NonDynamicFiveQi{
+									FiveQi: &e2sm_v2_ies.FiveQi{Value: 9},
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+	message, err := pdubuilder.CreateE2SmRsmIndicationMessageFormat2(triggerType, ueIDs, e2sm_rsm.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, []*e2sm_rsm.BearerId{bearer})
+	require.NoError(t, err)
+	return m.processEmmEventMessage(ctx, header.GetIndicationHeaderFormat1(), message.GetIndicationMessageFormat2(), string(testCUNodeID))
+}
+
+func getUE(ctx context.Context, t *testing.T, uenibClient uenib.Client, ue testUE) *uenib_api.RsmUeInfo {
+	ueInfo, err := uenibClient.GetUEWithPreferredID(ctx, string(testCUNodeID), uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, ue.cuUeF1apID)
+	require.NoError(t, err)
+	return ueInfo
+}
+
+func TestAttachResolvesServingDU(t *testing.T) {
+	ctx := context.Background()
+	uenibClient := newTestUenib(t)
+	m := NewMonitor(
+		WithNodeID(testCUNodeID),
+		WithRNIBClient(newTestTopology(ctx, t)),
+		WithUENIBClient(uenibClient),
+		WithUEIdentityResolver(NewUEIdentityResolver(uenibClient)))
+
+	ue1 := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	ue2 := testUE{cuUeF1apID: 2, duUeF1apID: 12, ranUeNgapID: 22, amfUeNgapID: 32}
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue1))
+	require.NoError(t, emmEvent(ctx, t, m, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 2, ue2))
+	assert.Equal(t, string(testDU1NodeID), getUE(ctx, t, uenibClient, ue1).GetDuE2NodeId())
+	assert.Equal(t, string(testDU2NodeID), getUE(ctx, t, uenibClient, ue2).GetDuE2NodeId())
+}
+
+func TestMetricsAreStoredForTheUE(t *testing.T) {
+	ctx := context.Background()
+	uenibClient := newTestUenib(t)
+	topo := newTestTopology(ctx, t)
+	cu := NewMonitor(
+		WithNodeID(testCUNodeID),
+		WithRNIBClient(topo),
+		WithUENIBClient(uenibClient),
+		WithUEIdentityResolver(NewUEIdentityResolver(uenibClient)))
+	ue := testUE{cuUeF1apID: 1, duUeF1apID: 11, ranUeNgapID: 21, amfUeNgapID: 31}
+	require.NoError(t, emmEvent(ctx, t, cu, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, 1, ue))
+	ueInfo := getUE(ctx, t, uenibClient, ue)
+	ueInfo.SliceList = []*uenib_api.SliceInfo{
+		{DuE2NodeId: string(testDU1NodeID), ID: "1", SliceType: uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE},
+		{DuE2NodeId: string(testDU1NodeID), ID: "2", SliceType: uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE},
+	}
+	require.NoError(t, uenibClient.UpdateUE(ctx, ueInfo))
+
+	store := kpi.NewStore(time.Hour, 10)
+	du := NewMonitor(
+		WithNodeID(testDU1NodeID),
+		WithRNIBClient(topo),
+		WithUENIBClient(uenibClient),
+		WithKpiStore(store))
+	metrics := func(prb int32) *e2sm_rsm.SliceMetrics {
+		return &e2sm_rsm.SliceMetrics{PrbUtilization: prb, NumUeAssocToSlice: 3, SliceLevelBler: 1, AvgCqi: 12}
+	}
+	require.NoError(t, du.processMetricTypeMessage(ctx, nil, &e2sm_rsm.E2SmRsmIndicationMessageFormat1{
+		CuUeF1ApId:       &e2sm_rsm.CuUeF1ApId{Value: ue.cuUeF1apID},
+		DuUeF1ApId:       &e2sm_rsm.DuUeF1ApId{Value: ue.duUeF1apID},
+		DlSlicingMetrics: []*e2sm_rsm.SliceMetrics{metrics(40), metrics(50)},
+		UlSlicingMetrics: []*e2sm_rsm.SliceMetrics{metrics(60)},
+	}))
+	// the metrics of a UE which is not in UE-NIB are stored without its global UE ID
+	require.NoError(t, du.processMetricTypeMessage(ctx, nil, &e2sm_rsm.E2SmRsmIndicationMessageFormat1{
+		CuUeF1ApId:       &e2sm_rsm.CuUeF1ApId{Value: 9},
+		DuUeF1ApId:       &e2sm_rsm.DuUeF1ApId{Value: 19},
+		DlSlicingMetrics: []*e2sm_rsm.SliceMetrics{metrics(70)},
+	}))
+
+	dl := store.Query(&api.GetSliceKpisRequest{E2NodeID: string(testDU1NodeID), SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE.String()})
+	require.Len(t, dl, 3)
+	byUE := make(map[int64][]int32)
+	for _, sample := range dl {
+		byUE[sample.DuUeF1apID] = append(byUE[sample.DuUeF1apID], sample.PrbUtilization)
+		if sample.DuUeF1apID == ue.duUeF1apID {
+			assert.Equal(t, ueInfo.GetGlobalUeID(), sample.GlobalUeID)
+			assert.Equal(t, int32(3), sample.NumUes)
+			assert.Equal(t, int32(12), sample.AvgCqi)
+		} else {
+			assert.Empty(t, sample.GlobalUeID)
+		}
+	}
+	assert.ElementsMatch(t, []int32{40, 50}, byUE[ue.duUeF1apID])
+	assert.Equal(t, []int32{70}, byUE[19])
+
+	ul := store.Query(&api.GetSliceKpisRequest{E2NodeID: string(testDU1NodeID), GlobalUeID: ueInfo.GetGlobalUeID(), SliceType: topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE.String()})
+	require.Len(t, ul, 1)
+	assert.Equal(t, int32(60), ul[0].PrbUtilization)
+}
@@ -10,6 +10,7 @@ import (
 	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
 	"github.com/onosproject/onos-rsm/pkg/broker"
 	appConfig "github.com/onosproject/onos-rsm/pkg/config"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
 )
@@ -28,12 +29,21 @@ type AppOptions struct {
 	UenibClient uenib.Client
 
 	EventTriggerType e2sm_rsm.RsmRicindicationTriggerType
+
+	IndicationObserver IndicationObserver
+
+	HandoverTracker *HandoverTracker
+
+	KpiStore *kpi.Store
+
+	IdentityResolver *UEIdentityResolver
 }
 
 type MonitorOptions struct {
 	Node         e2client.Node
 	NodeID       topoapi.ID
 	StreamReader broker.StreamReader
+	Decoder      IndicationDecoder
 }
 
 type Option interface {
@@ -68,6 +78,13 @@ func WithNodeID(nodeID topoapi.ID) Option {
 	})
 }
 
+// WithIndicationDecoder sets the decoder of the service model version of the node
+func WithIndicationDecoder(decoder IndicationDecoder) Option {
+	return newOption(func(options *Options) {
+		options.Monitor.Decoder = decoder
+	})
+}
+
 // WithStreamReader sets stream reader
 func WithStreamReader(streamReader broker.StreamReader) Option {
 	return newOption(func(options *Options) {
@@ -102,3 +119,31 @@ func WithRicIndicationTriggerType(triggerType e2sm_rsm.RsmRicindicationTriggerTy
 		options.App.EventTriggerType = triggerType
 	})
 }
+
+// WithIndicationObserver sets the observer of the UE state reported in indications
+func WithIndicationObserver(observer IndicationObserver) Option {
+	return newOption(func(options *Options) {
+		options.App.IndicationObserver = observer
+	})
+}
+
+// WithHandoverTracker sets the tracker correlating hand-outs and hand-ins
+func WithHandoverTracker(tracker *HandoverTracker) Option {
+	return newOption(func(options *Options) {
+		options.App.HandoverTracker = tracker
+	})
+}
+
+// WithUEIdentityResolver sets the resolver of the global UE IDs of attaching UEs
+func WithUEIdentityResolver(resolver *UEIdentityResolver) Option {
+	return newOption(func(options *Options) {
+		options.App.IdentityResolver = resolver
+	})
+}
+
+// WithKpiStore sets the store of the slice metrics
+func WithKpiStore(store *kpi.Store) Option {
+	return newOption(func(options *Options) {
+		options.App.KpiStore = store
+	})
+}
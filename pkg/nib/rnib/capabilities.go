@@ -0,0 +1,254 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+const (
+	rewatchInitialBackoff = time.Second
+	// rewatchMaxBackoff is also the time a watch has to stay open for the backoff to be reset
+	rewatchMaxBackoff = time.Minute
+)
+
+// Capabilities are the slicing capabilities an E2 node advertises in its RSM RAN function; a zero limit is no limit
+type Capabilities struct {
+	MaxDlSlices      int32
+	MaxUlSlices      int32
+	MaxUesPerSlice   int32
+	SupportedConfigs []*topoapi.RSMSupportedSlicingConfigItem
+}
+
+// MaxSlices returns the maximum number of slices of the type
+func (c Capabilities) MaxSlices(sliceType topoapi.RSMSliceType) int32 {
+	if sliceType == topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE {
+		return c.MaxUlSlices
+	}
+	return c.MaxDlSlices
+}
+
+// NewCapabilityCache creates a cache of the E2 node capabilities and slices; Run keeps it up to date
+func NewCapabilityCache(client TopoClient) *CapabilityCache {
+	c := &CapabilityCache{
+		client: client,
+		nodes:  make(map[topoapi.ID]*cachedNode),
+	}
+	// the slices written by this client are in the cache before their watch event arrives
+	if observed, ok := client.(interface {
+		observe(func(object *topoapi.Object))
+	}); ok {
+		observed.observe(c.update)
+	}
+	return c
+}
+
+// CapabilityCache has the capabilities and the slices of the E2 nodes in onos-topo; the nodes which are not
+// in the cache yet are read from onos-topo
+type CapabilityCache struct {
+	client TopoClient
+	mu     sync.RWMutex
+	nodes  map[topoapi.ID]*cachedNode
+}
+
+type cachedNode struct {
+	revision topoapi.Revision
+	caps     Capabilities
+	slices   []*topoapi.RSMSlicingItem
+}
+
+// Run updates the cache on the E2 node events of onos-topo until the context is canceled. Whenever the watch
+// fails or ends, the cache is cleared, so that the nodes are read from onos-topo, and onos-topo is watched again
+// with exponential backoff.
+func (c *CapabilityCache) Run(ctx context.Context) {
+	backoff := rewatchInitialBackoff
+	for {
+		var watchedSince time.Time
+		err := c.watch(ctx, func() {
+			watchedSince = time.Now()
+		})
+		c.clear()
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !watchedSince.IsZero() && time.Since(watchedSince) >= rewatchMaxBackoff {
+			backoff = rewatchInitialBackoff
+		}
+		log.Warnf("Watch of the E2 nodes failed, watching again in %v: %v", backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > rewatchMaxBackoff {
+			backoff = rewatchMaxBackoff
+		}
+	}
+}
+
+// watch updates the cache on the E2 node events until the watch ends; watching is called once the watch is open
+func (c *CapabilityCache) watch(ctx context.Context, watching func()) error {
+	ch := make(chan topoapi.Event)
+	if err := c.client.WatchE2Nodes(ctx, ch); err != nil {
+		return err
+	}
+	watching()
+
+	for event := range ch {
+		if event.Type == topoapi.EventType_REMOVED {
+			c.remove(event.Object.GetID())
+			continue
+		}
+		object := event.Object
+		c.update(&object)
+	}
+	return errors.NewUnavailable("E2 node watch ended")
+}
+
+// Get returns the capabilities of the node; the node is read from onos-topo if it is not in the cache yet
+func (c *CapabilityCache) Get(ctx context.Context, nodeID topoapi.ID) (Capabilities, error) {
+	c.mu.RLock()
+	node, ok := c.nodes[nodeID]
+	c.mu.RUnlock()
+	if ok {
+		return node.caps, nil
+	}
+
+	e2Node, err := c.client.GetE2NodeAspects(ctx, nodeID)
+	if err != nil {
+		return Capabilities{}, err
+	}
+	caps, _ := capabilities(e2Node)
+	return caps, nil
+}
+
+// GetSupportedSlicingConfigTypes returns the slicing configurations the node supports
+func (c *CapabilityCache) GetSupportedSlicingConfigTypes(ctx context.Context, nodeID topoapi.ID) ([]*topoapi.RSMSupportedSlicingConfigItem, error) {
+	c.mu.RLock()
+	node, ok := c.nodes[nodeID]
+	c.mu.RUnlock()
+	if ok {
+		return node.caps.SupportedConfigs, nil
+	}
+	return c.client.GetSupportedSlicingConfigTypes(ctx, nodeID)
+}
+
+// GetRsmSliceItemAspects returns copies of the slices of the node
+func (c *CapabilityCache) GetRsmSliceItemAspects(ctx context.Context, nodeID topoapi.ID) ([]*topoapi.RSMSlicingItem, error) {
+	c.mu.RLock()
+	node, ok := c.nodes[nodeID]
+	c.mu.RUnlock()
+	if !ok {
+		return c.client.GetRsmSliceItemAspects(ctx, nodeID)
+	}
+	if node.slices == nil {
+		return nil, errors.NewNotFound("node %v has no slices", nodeID)
+	}
+	items := make([]*topoapi.RSMSlicingItem, 0, len(node.slices))
+	for _, item := range node.slices {
+		items = append(items, proto.Clone(item).(*topoapi.RSMSlicingItem))
+	}
+	return items, nil
+}
+
+// HasRsmSliceItemAspect returns true if the node has the slice
+func (c *CapabilityCache) HasRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, sliceID string, sliceType rsm.SliceType) bool {
+	c.mu.RLock()
+	node, ok := c.nodes[nodeID]
+	c.mu.RUnlock()
+	if !ok {
+		return c.client.HasRsmSliceItemAspect(ctx, nodeID, sliceID, sliceType)
+	}
+	for _, item := range node.slices {
+		if item.GetID() == sliceID && item.GetSliceType() == topoapi.RSMSliceType(sliceType) {
+			return true
+		}
+	}
+	return false
+}
+
+// update caches the E2 node object unless a later revision of it is cached already
+func (c *CapabilityCache) update(object *topoapi.Object) {
+	if object.GetEntity() == nil || object.GetEntity().GetKindID() != topoapi.E2NODE {
+		return
+	}
+	node := &cachedNode{
+		revision: object.GetRevision(),
+	}
+	e2Node := &topoapi.E2Node{}
+	if err := object.GetAspect(e2Node); err != nil {
+		log.Debugf("E2 node %v does not have E2Node aspect: %v", object.GetID(), err)
+	} else {
+		node.caps, _ = capabilities(e2Node)
+	}
+	sliceList := &topoapi.RSMSliceItemList{}
+	if err := object.GetAspect(sliceList); err == nil {
+		node.slices = sliceList.GetRsmSliceList()
+		if node.slices == nil {
+			node.slices = make([]*topoapi.RSMSlicingItem, 0)
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if prev, ok := c.nodes[object.GetID()]; ok && prev.revision > node.revision {
+		return
+	}
+	c.nodes[object.GetID()] = node
+}
+
+func (c *CapabilityCache) remove(nodeID topoapi.ID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.nodes, nodeID)
+}
+
+// clear removes all the nodes, e.g. because their events are not watched anymore
+func (c *CapabilityCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.nodes = make(map[topoapi.ID]*cachedNode)
+}
+
+// capabilities returns the capabilities in the RSM RAN function of the node, and false if it has none
+func capabilities(e2Node *topoapi.E2Node) (Capabilities, bool) {
+	caps := Capabilities{
+		SupportedConfigs: make([]*topoapi.RSMSupportedSlicingConfigItem, 0),
+	}
+	found := false
+	for _, sm := range e2Node.GetServiceModels() {
+		for _, ranFunc := range sm.GetRanFunctions() {
+			rsmRanFunc := &topoapi.RSMRanFunction{}
+			if err := proto.Unmarshal(ranFunc.GetValue(), rsmRanFunc); err != nil {
+				continue
+			}
+			for _, item := range rsmRanFunc.GetRicSlicingNodeCapabilityList() {
+				found = true
+				caps.MaxDlSlices = maxLimit(caps.MaxDlSlices, item.GetMaxNumberOfSlicesDl())
+				caps.MaxUlSlices = maxLimit(caps.MaxUlSlices, item.GetMaxNumberOfSlicesUl())
+				caps.MaxUesPerSlice = maxLimit(caps.MaxUesPerSlice, item.GetMaxNumberOfUesPerSlice())
+				caps.SupportedConfigs = append(caps.SupportedConfigs, item.GetSupportedConfig()...)
+			}
+		}
+	}
+	return caps, found
+}
+
+func maxLimit(a, b int32) int32 {
+	if b > a {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,75 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lostWatchClient hands the first E2 node watch to the test, which loses it by closing the channel
+type lostWatchClient struct {
+	TopoClient
+	mu      sync.Mutex
+	watches int
+	firstCh chan chan topoapi.Event
+}
+
+func (c *lostWatchClient) WatchE2Nodes(ctx context.Context, ch chan topoapi.Event) error {
+	c.mu.Lock()
+	c.watches++
+	first := c.watches == 1
+	c.mu.Unlock()
+	if first {
+		c.firstCh <- ch
+		return nil
+	}
+	return c.TopoClient.WatchE2Nodes(ctx, ch)
+}
+
+func TestCapabilityCacheWatchesAgain(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, du1NodeID, &topoapi.RSMSlicingItem{
+		ID:        "1",
+		SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE,
+	})
+	client := &lostWatchClient{
+		TopoClient: NewMemoryClient(ctx, store),
+		firstCh:    make(chan chan topoapi.Event, 1),
+	}
+	cache := NewCapabilityCache(client)
+	cached := func() bool {
+		cache.mu.RLock()
+		defer cache.mu.RUnlock()
+		_, ok := cache.nodes[du1NodeID]
+		return ok
+	}
+	go cache.Run(ctx)
+
+	ch := <-client.firstCh
+	object, err := store.Get(ctx, du1NodeID)
+	require.NoError(t, err)
+	ch <- topoapi.Event{Type: topoapi.EventType_NONE, Object: *object}
+	assert.Eventually(t, cached, 5*time.Second, 10*time.Millisecond)
+
+	// the node is not cached while its events are not watched, and is cached again from the next watch
+	close(ch)
+	assert.Eventually(t, func() bool {
+		return !cached()
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, cached, 5*time.Second, 10*time.Millisecond)
+	items, err := cache.GetRsmSliceItemAspects(ctx, du1NodeID)
+	require.NoError(t, err)
+	assert.Len(t, items, 1)
+}
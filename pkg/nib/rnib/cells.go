@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// NRCellIDBits is the length of an NR cell identity
+	NRCellIDBits = 36
+	// EUTRACellIDBits is the length of an E-UTRA cell identity
+	EUTRACellIDBits = 28
+)
+
+// CellGlobalID is the cell global ID reported by an E2 node: the PLMN ID and the cell identity of CellIDBits bits
+type CellGlobalID struct {
+	PlmnID     uint32
+	CellID     uint64
+	CellIDBits int
+}
+
+func (c CellGlobalID) String() string {
+	return fmt.Sprintf("%06x/%x", c.PlmnID, c.CellID)
+}
+
+// matches returns true if the hex cell global ID stored in onos-topo is the cell global ID,
+// which is stored either with or without the PLMN ID
+func (c CellGlobalID) matches(value string) bool {
+	value = strings.TrimPrefix(strings.ToLower(value), "0x")
+	if value == "" {
+		return false
+	}
+	id, err := strconv.ParseUint(value, 16, 64)
+	if err != nil {
+		log.Debugf("Cell global ID %v in onos-topo is not in hex: %v", value, err)
+		return false
+	}
+	return id == uint64(c.PlmnID)<<uint(c.CellIDBits)|c.CellID || id == c.CellID
+}
@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+)
+
+// DrbValue returns the value of the 4G or 5G DRB ID
+func DrbValue(drbID *topoapi.DrbId) int32 {
+	if drbID.GetFourGdrbId() != nil {
+		return drbID.GetFourGdrbId().GetValue()
+	}
+	return drbID.GetFiveGdrbId().GetValue()
+}
@@ -0,0 +1,333 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/google/uuid"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// NewMemoryClient creates an R-NIB client on the in-memory store, e.g. to run without onos-topo;
+// its topology cache watches the store until ctx is done
+func NewMemoryClient(ctx context.Context, store *MemoryStore) TopoClient {
+	return newTopoClient(ctx, store)
+}
+
+// NewMemoryStore creates an in-memory store of topo objects; if snapshotPath is set,
+// the objects are loaded from the snapshot file and every change is written to it
+func NewMemoryStore(snapshotPath string) (*MemoryStore, error) {
+	s := &MemoryStore{
+		snapshotPath: snapshotPath,
+		objects:      make(map[topoapi.ID]*topoapi.Object),
+		watchers:     make(map[*objectWatcher]struct{}),
+	}
+	if snapshotPath == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(snapshotPath)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+
+	snapshot := &objectSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	for _, bytes := range snapshot.Objects {
+		object := &topoapi.Object{}
+		if err := proto.Unmarshal(bytes, object); err != nil {
+			return nil, err
+		}
+		s.objects[object.ID] = object
+	}
+	log.Infof("Loaded %d topo objects from %v", len(s.objects), snapshotPath)
+	return s, nil
+}
+
+// MemoryStore keeps the topo objects in memory with the semantics of onos-topo: objects have revisions,
+// an update of a stale revision fails, and watchers get the existing objects and then the changes.
+// The relation filters of the watches are not supported.
+type MemoryStore struct {
+	snapshotPath string
+	mu           sync.RWMutex
+	objects      map[topoapi.ID]*topoapi.Object
+	watchers     map[*objectWatcher]struct{}
+}
+
+// objectSnapshot is the snapshot file; the objects are protobuf encoded
+type objectSnapshot struct {
+	Objects [][]byte `json:"objects"`
+}
+
+// Create adds the object; the object is updated with its revision
+func (s *MemoryStore) Create(_ context.Context, object *topoapi.Object) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if object.ID == topoapi.NullID {
+		return errors.NewInvalid("object ID is empty")
+	}
+	if _, ok := s.objects[object.ID]; ok {
+		return errors.NewAlreadyExists("object %v already exists", object.ID)
+	}
+
+	stored := proto.Clone(object).(*topoapi.Object)
+	if stored.UUID == "" {
+		stored.UUID = topoapi.UUID(uuid.New().String())
+	}
+	stored.Revision = 1
+	s.objects[stored.ID] = stored
+	if err := s.persist(); err != nil {
+		delete(s.objects, stored.ID)
+		return err
+	}
+	*object = *proto.Clone(stored).(*topoapi.Object)
+	s.notify(topoapi.EventType_ADDED, stored)
+	return nil
+}
+
+// Update replaces the object if its revision is the stored one; the object is updated with its new revision
+func (s *MemoryStore) Update(_ context.Context, object *topoapi.Object) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.objects[object.ID]
+	if !ok {
+		return errors.NewNotFound("object %v not found", object.ID)
+	}
+	if object.Revision != prev.Revision {
+		return errors.NewConflict("object %v revision %v is not the current revision %v", object.ID, object.Revision, prev.Revision)
+	}
+
+	stored := proto.Clone(object).(*topoapi.Object)
+	stored.UUID = prev.UUID
+	stored.Revision = prev.Revision + 1
+	s.objects[stored.ID] = stored
+	if err := s.persist(); err != nil {
+		s.objects[stored.ID] = prev
+		return err
+	}
+	*object = *proto.Clone(stored).(*topoapi.Object)
+	s.notify(topoapi.EventType_UPDATED, stored)
+	return nil
+}
+
+// Delete removes the object
+func (s *MemoryStore) Delete(_ context.Context, id topoapi.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.objects[id]
+	if !ok {
+		return errors.NewNotFound("object %v not found", id)
+	}
+
+	delete(s.objects, id)
+	if err := s.persist(); err != nil {
+		s.objects[id] = prev
+		return err
+	}
+	s.notify(topoapi.EventType_REMOVED, prev)
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id topoapi.ID) (*topoapi.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	object, ok := s.objects[id]
+	if !ok {
+		return nil, errors.NewNotFound("object %v not found", id)
+	}
+	return proto.Clone(object).(*topoapi.Object), nil
+}
+
+// List returns the objects ordered by ID
+func (s *MemoryStore) List(_ context.Context) ([]topoapi.Object, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list(nil), nil
+}
+
+func (s *MemoryStore) list(filters *topoapi.Filters) []topoapi.Object {
+	objects := make([]topoapi.Object, 0, len(s.objects))
+	for _, object := range s.objects {
+		if matchFilters(object, filters) {
+			objects = append(objects, *proto.Clone(object).(*topoapi.Object))
+		}
+	}
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].ID < objects[j].ID
+	})
+	return objects
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, ch chan topoapi.Event, filters *topoapi.Filters) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := &objectWatcher{
+		filters: filters,
+		signal:  make(chan struct{}, 1),
+	}
+	for _, object := range s.list(filters) {
+		w.push(topoapi.Event{
+			Type:   topoapi.EventType_NONE,
+			Object: object,
+		})
+	}
+	s.watchers[w] = struct{}{}
+
+	go func() {
+		w.run(ctx, ch)
+		s.mu.Lock()
+		delete(s.watchers, w)
+		s.mu.Unlock()
+	}()
+	return nil
+}
+
+func (s *MemoryStore) notify(eventType topoapi.EventType, object *topoapi.Object) {
+	for w := range s.watchers {
+		if matchFilters(object, w.filters) {
+			w.push(topoapi.Event{
+				Type:   eventType,
+				Object: *proto.Clone(object).(*topoapi.Object),
+			})
+		}
+	}
+}
+
+// persist writes all objects to a temporary file and renames it over the snapshot file
+func (s *MemoryStore) persist() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	snapshot := &objectSnapshot{
+		Objects: make([][]byte, 0, len(s.objects)),
+	}
+	for _, object := range s.objects {
+		bytes, err := proto.Marshal(object)
+		if err != nil {
+			return err
+		}
+		snapshot.Objects = append(snapshot.Objects, bytes)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.snapshotPath), 0755); err != nil {
+		return err
+	}
+	tmp := s.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.snapshotPath)
+}
+
+// objectWatcher queues the events of a watch, so that a slow watcher does not block the store
+type objectWatcher struct {
+	filters *topoapi.Filters
+	mu      sync.Mutex
+	events  []topoapi.Event
+	signal  chan struct{}
+}
+
+func (w *objectWatcher) push(event topoapi.Event) {
+	w.mu.Lock()
+	w.events = append(w.events, event)
+	w.mu.Unlock()
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *objectWatcher) run(ctx context.Context, ch chan topoapi.Event) {
+	defer close(ch)
+	for {
+		w.mu.Lock()
+		events := w.events
+		w.events = nil
+		w.mu.Unlock()
+		for _, event := range events {
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-w.signal:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func matchFilters(object *topoapi.Object, filters *topoapi.Filters) bool {
+	if filters == nil {
+		return true
+	}
+	if len(filters.GetObjectTypes()) > 0 {
+		found := false
+		for _, objectType := range filters.GetObjectTypes() {
+			if object.GetType() == objectType {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if filters.GetKindFilter() != nil && !matchFilter(kindID(object), filters.GetKindFilter()) {
+		return false
+	}
+	for _, filter := range filters.GetLabelFilters() {
+		if !matchFilter(object.GetLabels()[filter.GetKey()], filter) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchFilter(value string, filter *topoapi.Filter) bool {
+	switch f := filter.GetFilter().(type) {
+	case *topoapi.Filter_Equal_:
+		return value == f.Equal_.GetValue()
+	case *topoapi.Filter_Not:
+		return !matchFilter(value, f.Not.GetInner())
+	case *topoapi.Filter_In:
+		for _, v := range f.In.GetValues() {
+			if value == v {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func kindID(object *topoapi.Object) string {
+	switch {
+	case object.GetEntity() != nil:
+		return string(object.GetEntity().GetKindID())
+	case object.GetRelation() != nil:
+		return string(object.GetRelation().GetKindID())
+	}
+	return string(object.GetID())
+}
@@ -0,0 +1,142 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, ch chan topoapi.Event) topoapi.Event {
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "watch closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no watch event")
+	}
+	return topoapi.Event{}
+}
+
+func TestMemoryStoreRevisions(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+
+	createE2Node(ctx, t, store, du1NodeID)
+	err = store.Create(ctx, &topoapi.Object{ID: du1NodeID})
+	assert.True(t, errors.IsAlreadyExists(err))
+	err = store.Create(ctx, &topoapi.Object{})
+	assert.True(t, errors.IsInvalid(err))
+
+	object, err := store.Get(ctx, du1NodeID)
+	require.NoError(t, err)
+	assert.Equal(t, topoapi.Revision(1), object.Revision)
+	assert.NotEmpty(t, object.UUID)
+
+	// an update is based on the stored revision, so that the update of a stale object fails
+	stale := *object
+	object.Labels = map[string]string{"updated": "1"}
+	require.NoError(t, store.Update(ctx, object))
+	assert.Equal(t, topoapi.Revision(2), object.Revision)
+	err = store.Update(ctx, &stale)
+	assert.True(t, errors.IsConflict(err))
+	stored, err := store.Get(ctx, du1NodeID)
+	require.NoError(t, err)
+	assert.Equal(t, "1", stored.GetLabels()["updated"])
+	assert.Equal(t, object.UUID, stored.UUID)
+
+	require.NoError(t, store.Delete(ctx, du1NodeID))
+	_, err = store.Get(ctx, du1NodeID)
+	assert.True(t, errors.IsNotFound(err))
+	err = store.Update(ctx, object)
+	assert.True(t, errors.IsNotFound(err))
+	err = store.Delete(ctx, du1NodeID)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestMemoryStoreWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, du1NodeID)
+	createCell(ctx, t, store, "cell-1", cell1)
+
+	// the existing E2 nodes are replayed, and then the changes of E2 nodes are sent
+	ch := make(chan topoapi.Event)
+	require.NoError(t, store.Watch(ctx, ch, &topoapi.Filters{
+		KindFilter: &topoapi.Filter{
+			Filter: &topoapi.Filter_Equal_{Equal_: &topoapi.EqualFilter{Value: topoapi.E2NODE}},
+		},
+	}))
+	event := nextEvent(t, ch)
+	assert.Equal(t, topoapi.EventType_NONE, event.Type)
+	assert.Equal(t, du1NodeID, event.Object.ID)
+
+	createCell(ctx, t, store, "cell-2", cell2)
+	createE2Node(ctx, t, store, du2NodeID)
+	event = nextEvent(t, ch)
+	assert.Equal(t, topoapi.EventType_ADDED, event.Type)
+	assert.Equal(t, du2NodeID, event.Object.ID)
+
+	object, err := store.Get(ctx, du2NodeID)
+	require.NoError(t, err)
+	require.NoError(t, store.Update(ctx, object))
+	event = nextEvent(t, ch)
+	assert.Equal(t, topoapi.EventType_UPDATED, event.Type)
+	assert.Equal(t, topoapi.Revision(2), event.Object.Revision)
+
+	require.NoError(t, store.Delete(ctx, "cell-1"))
+	require.NoError(t, store.Delete(ctx, du2NodeID))
+	event = nextEvent(t, ch)
+	assert.Equal(t, topoapi.EventType_REMOVED, event.Type)
+	assert.Equal(t, du2NodeID, event.Object.ID)
+
+	// the watch is closed once its context is done
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-ch
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "topo", "snapshot.json")
+	store, err := NewMemoryStore(path)
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, du1NodeID, &topoapi.RSMSlicingItem{
+		ID:        "1",
+		SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE,
+	})
+	createE2Node(ctx, t, store, du2NodeID)
+	require.NoError(t, store.Delete(ctx, du2NodeID))
+
+	// the objects are loaded with their aspects and revisions
+	restarted, err := NewMemoryStore(path)
+	require.NoError(t, err)
+	objects, err := restarted.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+	assert.Equal(t, du1NodeID, objects[0].ID)
+	assert.Equal(t, topoapi.Revision(1), objects[0].Revision)
+	slices := &topoapi.RSMSliceItemList{}
+	require.NoError(t, objects[0].GetAspect(slices))
+	require.Len(t, slices.GetRsmSliceList(), 1)
+	assert.Equal(t, "1", slices.GetRsmSliceList()[0].GetID())
+
+	// the R-NIB client on the loaded store has the slices of the restart
+	client := NewMemoryClient(ctx, restarted)
+	assert.True(t, client.HasRsmSliceItemAspect(ctx, du1NodeID, "1", rsmapi.SliceType_SLICE_TYPE_DL_SLICE))
+}
@@ -8,7 +8,7 @@ package rnib
 import (
 	"context"
 	"fmt"
-	"strings"
+	"sync"
 
 	"github.com/gogo/protobuf/proto"
 	"github.com/onosproject/onos-api/go/onos/rsm"
@@ -22,21 +22,34 @@ import (
 
 var log = logging.GetLogger()
 
-func NewClient() (TopoClient, error) {
+// NewClient creates an R-NIB client of onos-topo; its topology cache watches onos-topo until ctx is done
+func NewClient(ctx context.Context) (TopoClient, error) {
 	sdkClient, err := toposdk.NewClient()
 	if err != nil {
 		return nil, err
 	}
-	return &topoClient{
+	return newTopoClient(ctx, &sdkStore{
 		client: sdkClient,
-	}, nil
+	}), nil
+}
+
+// newTopoClient creates a client which looks up the topology of the E2 nodes in a cache of the store
+func newTopoClient(ctx context.Context, store objectStore) *topoClient {
+	topology := newTopologyCache(ctx, store)
+	return &topoClient{
+		client:    store,
+		topology:  topology,
+		observers: []func(object *topoapi.Object){topology.update},
+	}
 }
 
 type TopoClient interface {
 	WatchE2Connections(ctx context.Context, ch chan topoapi.Event) error
+	WatchE2Nodes(ctx context.Context, ch chan topoapi.Event) error
 	GetSupportedSlicingConfigTypes(ctx context.Context, nodeID topoapi.ID) ([]*topoapi.RSMSupportedSlicingConfigItem, error)
 	GetE2NodeAspects(ctx context.Context, nodeID topoapi.ID) (*topoapi.E2Node, error)
-	GetTargetDUE2NodeID(ctx context.Context, cuE2NodeID topoapi.ID) (topoapi.ID, error)
+	GetDUE2NodeIDs(ctx context.Context, cuE2NodeID topoapi.ID) ([]topoapi.ID, error)
+	GetServingDUE2NodeID(ctx context.Context, cuE2NodeID topoapi.ID, cgi CellGlobalID) (topoapi.ID, error)
 	GetSourceCUE2NodeID(ctx context.Context, duE2NodeID topoapi.ID) (topoapi.ID, error)
 	HasRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, sliceID string, sliceType rsm.SliceType) bool
 	AddRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, msg *topoapi.RSMSlicingItem) error
@@ -51,7 +64,26 @@ type TopoClient interface {
 }
 
 type topoClient struct {
-	client toposdk.Client
+	client   objectStore
+	topology *topologyCache
+	mu       sync.RWMutex
+	// observers are called with the E2 node objects updated by the client
+	observers []func(object *topoapi.Object)
+}
+
+// observe registers a function called with every E2 node object the client updates
+func (t *topoClient) observe(f func(object *topoapi.Object)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.observers = append(t.observers, f)
+}
+
+func (t *topoClient) updated(object *topoapi.Object) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, f := range t.observers {
+		f(object)
+	}
 }
 
 func (t *topoClient) DeleteRsmSliceList(ctx context.Context, nodeID topoapi.ID) error {
@@ -67,6 +99,7 @@ func (t *topoClient) DeleteRsmSliceList(ctx context.Context, nodeID topoapi.ID)
 	if err != nil {
 		return err
 	}
+	t.updated(object)
 
 	return nil
 }
@@ -166,6 +199,7 @@ func (t *topoClient) SetRsmSliceListAspect(ctx context.Context, nodeID topoapi.I
 	if err != nil {
 		return err
 	}
+	t.updated(object)
 
 	return nil
 }
@@ -267,13 +301,34 @@ func (t *topoClient) GetE2NodeAspects(ctx context.Context, nodeID topoapi.ID) (*
 }
 
 func (t *topoClient) WatchE2Connections(ctx context.Context, ch chan topoapi.Event) error {
-	err := t.client.Watch(ctx, ch, toposdk.WithWatchFilters(getControlRelationFilter()))
+	err := t.client.Watch(ctx, ch, getControlRelationFilter())
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *topoClient) WatchE2Nodes(ctx context.Context, ch chan topoapi.Event) error {
+	err := t.client.Watch(ctx, ch, getE2NodeFilter())
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+func getE2NodeFilter() *topoapi.Filters {
+	filter := &topoapi.Filters{
+		KindFilter: &topoapi.Filter{
+			Filter: &topoapi.Filter_Equal_{
+				Equal_: &topoapi.EqualFilter{
+					Value: topoapi.E2NODE,
+				},
+			},
+		},
+	}
+	return filter
+}
+
 func getControlRelationFilter() *topoapi.Filters {
 	filter := &topoapi.Filters{
 		KindFilter: &topoapi.Filter{
@@ -287,71 +342,90 @@ func getControlRelationFilter() *topoapi.Filters {
 	return filter
 }
 
-func (t *topoClient) GetTargetDUE2NodeID(ctx context.Context, cuE2NodeID topoapi.ID) (topoapi.ID, error) {
-	// ToDo: When auto-discovery comes in, it should be changed
-	objects, err := t.client.List(ctx)
+// GetDUE2NodeIDs returns the DUs of the CU ordered by ID: the E2 nodes the CU contains in onos-topo,
+// or the DUs of the gNB of the CU by their E2 node IDs if the CU contains no E2 nodes
+func (t *topoClient) GetDUE2NodeIDs(ctx context.Context, cuE2NodeID topoapi.ID) ([]topoapi.ID, error) {
+	var duNodeIDs []topoapi.ID
+	err := t.topology.read(ctx, func(index *topologyIndex) error {
+		if cuNodeIDs := index.cus(cuE2NodeID); len(cuNodeIDs) > 0 {
+			return errors.NewInvalid(fmt.Sprintf("E2 node %v is a DU of CU %v", cuE2NodeID, cuNodeIDs[0]))
+		}
+		duNodeIDs = index.dus(cuE2NodeID)
+		if len(duNodeIDs) == 0 && !isDUNodeID(cuE2NodeID) {
+			duNodeIDs = index.siblings(cuE2NodeID, true)
+		}
+		return nil
+	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-
-	for _, obj := range objects {
-		log.Debugf("Relation: %v", obj.GetEntity())
-		if obj.GetEntity() != nil && obj.GetEntity().GetKindID() == topoapi.E2NODE {
-			if cuE2NodeID != obj.GetID() {
-				nodeID := fmt.Sprintf("%s/%s", strings.Split(string(cuE2NodeID), "/")[0], strings.Split(string(cuE2NodeID), "/")[1])
-				tgtNodeID := fmt.Sprintf("%s/%s", strings.Split(string(obj.GetID()), "/")[0], strings.Split(string(obj.GetID()), "/")[1])
-				if nodeID == tgtNodeID {
-					return obj.GetID(), nil
-				}
-			}
-		}
+	if len(duNodeIDs) == 0 {
+		return nil, errors.NewNotFound(fmt.Sprintf("DU-ID not found (CU-ID: %v)", cuE2NodeID))
 	}
+	return duNodeIDs, nil
+}
 
-	return "", errors.NewNotFound(fmt.Sprintf("DU-ID not found (CU-ID: %v)", cuE2NodeID))
+// GetServingDUE2NodeID returns the DU of the CU which contains the cell in onos-topo;
+// the only DU of a CU is returned even if the cell is not in onos-topo
+func (t *topoClient) GetServingDUE2NodeID(ctx context.Context, cuE2NodeID topoapi.ID, cgi CellGlobalID) (topoapi.ID, error) {
+	var duNodeID topoapi.ID
+	err := t.topology.read(ctx, func(index *topologyIndex) error {
+		duNodeIDs := index.dus(cuE2NodeID)
+		if len(duNodeIDs) == 0 && len(index.cus(cuE2NodeID)) == 0 && !isDUNodeID(cuE2NodeID) {
+			duNodeIDs = index.siblings(cuE2NodeID, true)
+		}
+		if len(duNodeIDs) == 0 {
+			return errors.NewNotFound(fmt.Sprintf("DU-ID not found (CU-ID: %v)", cuE2NodeID))
+		}
+		if nodeID, ok := index.servingDU(duNodeIDs, cgi); ok {
+			duNodeID = nodeID
+			return nil
+		}
+		if len(duNodeIDs) == 1 {
+			log.Debugf("Cell %v is not in onos-topo - using the only DU %v of CU %v", cgi, duNodeIDs[0], cuE2NodeID)
+			duNodeID = duNodeIDs[0]
+			return nil
+		}
+		return errors.NewNotFound(fmt.Sprintf("cell %v is not contained by any of the DUs %v of CU %v", cgi, duNodeIDs, cuE2NodeID))
+	})
+	return duNodeID, err
 }
 
+// GetSourceCUE2NodeID returns the CU of the DU: the E2 node which contains the DU in onos-topo,
+// or the CU of the gNB of the DU by their E2 node IDs if no E2 node contains the DU
 func (t *topoClient) GetSourceCUE2NodeID(ctx context.Context, duE2NodeID topoapi.ID) (topoapi.ID, error) {
-	// ToDo: When auto-discovery comes in, it should be changed
-	objects, err := t.client.List(ctx)
-	if err != nil {
-		return "", err
-	}
-
-	for _, obj := range objects {
-		log.Debugf("Relation: %v", obj.GetEntity())
-		if obj.GetEntity() != nil && obj.GetEntity().GetKindID() == topoapi.E2NODE {
-			if duE2NodeID != obj.GetID() {
-				nodeID := fmt.Sprintf("%s/%s", strings.Split(string(duE2NodeID), "/")[0], strings.Split(string(duE2NodeID), "/")[1])
-				tgtNodeID := fmt.Sprintf("%s/%s", strings.Split(string(obj.GetID()), "/")[0], strings.Split(string(obj.GetID()), "/")[1])
-				if nodeID == tgtNodeID {
-					return obj.GetID(), nil
-				}
-			}
+	var cuNodeID topoapi.ID
+	err := t.topology.read(ctx, func(index *topologyIndex) error {
+		cuNodeIDs := index.cus(duE2NodeID)
+		if len(cuNodeIDs) == 0 && len(index.dus(duE2NodeID)) == 0 && isDUNodeID(duE2NodeID) {
+			cuNodeIDs = index.siblings(duE2NodeID, false)
 		}
-	}
-
-	return "", errors.NewNotFound(fmt.Sprintf("CU-ID not found (DU-ID: %v)", duE2NodeID))
+		if len(cuNodeIDs) == 0 {
+			return errors.NewNotFound(fmt.Sprintf("CU-ID not found (DU-ID: %v)", duE2NodeID))
+		}
+		cuNodeID = cuNodeIDs[0]
+		return nil
+	})
+	return cuNodeID, err
 }
 
+// GetRSMSliceItemAspectsForAllDUs returns the slices of the E2 nodes which have slices; a DU whose slices
+// were never set has no slice list and is skipped
 func (t *topoClient) GetRSMSliceItemAspectsForAllDUs(ctx context.Context) (map[string][]*topoapi.RSMSlicingItem, error) {
 	results := make(map[string][]*topoapi.RSMSlicingItem)
-	objects, err := t.client.List(ctx)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, obj := range objects {
-		if obj.GetEntity() != nil && obj.GetEntity().GetKindID() == topoapi.E2NODE {
-			if obj.GetEntity() != nil && obj.GetEntity().GetKindID() == topoapi.E2NODE && len(strings.Split(string(obj.GetID()), "/")) == 4 && strings.Split(string(obj.GetID()), "/")[2] == "3" {
-				value := &topoapi.RSMSliceItemList{}
-				err = obj.GetAspect(value)
-				if err != nil {
-					return nil, err
-				}
-				results[string(obj.GetID())] = value.GetRsmSliceList()
+	err := t.topology.read(ctx, func(index *topologyIndex) error {
+		for _, obj := range index.e2Nodes() {
+			value := &topoapi.RSMSliceItemList{}
+			if err := obj.GetAspect(value); err != nil {
+				log.Debugf("E2 node %v has no slices: %v", obj.GetID(), err)
+				continue
 			}
+			results[string(obj.GetID())] = value.GetRsmSliceList()
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
-
 	return results, nil
 }
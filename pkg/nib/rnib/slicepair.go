@@ -0,0 +1,18 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+)
+
+// SlicePairDesc is the description of the DL and UL slices created together as a slice pair;
+// a DL and a UL slice with the same ID which were created separately are not a slice pair
+const SlicePairDesc = "Slice pair created by onos-RSM xAPP"
+
+// IsSlicePairItem returns true if the slice was created as one direction of a slice pair
+func IsSlicePairItem(item *topoapi.RSMSlicingItem) bool {
+	return item.GetSliceDesc() == SlicePairDesc
+}
@@ -0,0 +1,42 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	toposdk "github.com/onosproject/onos-ric-sdk-go/pkg/topo"
+)
+
+// objectStore is the store of the topo objects the R-NIB client reads and writes
+type objectStore interface {
+	Get(ctx context.Context, id topoapi.ID) (*topoapi.Object, error)
+	Update(ctx context.Context, object *topoapi.Object) error
+	List(ctx context.Context) ([]topoapi.Object, error)
+	// Watch sends the existing objects matching the filters and then their changes to ch; ch is closed when ctx is done
+	Watch(ctx context.Context, ch chan topoapi.Event, filters *topoapi.Filters) error
+}
+
+// sdkStore is the store of onos-topo
+type sdkStore struct {
+	client toposdk.Client
+}
+
+func (s *sdkStore) Get(ctx context.Context, id topoapi.ID) (*topoapi.Object, error) {
+	return s.client.Get(ctx, id)
+}
+
+func (s *sdkStore) Update(ctx context.Context, object *topoapi.Object) error {
+	return s.client.Update(ctx, object)
+}
+
+func (s *sdkStore) List(ctx context.Context) ([]topoapi.Object, error) {
+	return s.client.List(ctx)
+}
+
+func (s *sdkStore) Watch(ctx context.Context, ch chan topoapi.Event, filters *topoapi.Filters) error {
+	return s.client.Watch(ctx, ch, toposdk.WithWatchFilters(filters))
+}
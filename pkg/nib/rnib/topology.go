@@ -0,0 +1,291 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// watchRetryInterval is the time before the topology cache lists and watches the store again after the watch ended
+const watchRetryInterval = time.Second
+
+// topologyIndex has the E2 nodes, the cells and the contains relations between them. A CU contains its DUs
+// and a DU contains its cells, so that the DUs of a CU and the DU serving a cell are found by the relations.
+type topologyIndex struct {
+	objects     map[topoapi.ID]*topoapi.Object
+	contains    map[topoapi.ID]map[topoapi.ID]bool
+	containedBy map[topoapi.ID]map[topoapi.ID]bool
+}
+
+func newTopologyIndex() *topologyIndex {
+	return &topologyIndex{
+		objects:     make(map[topoapi.ID]*topoapi.Object),
+		contains:    make(map[topoapi.ID]map[topoapi.ID]bool),
+		containedBy: make(map[topoapi.ID]map[topoapi.ID]bool),
+	}
+}
+
+// indexed returns true if the object is an E2 node, a cell or a contains relation
+func indexed(object *topoapi.Object) bool {
+	if entity := object.GetEntity(); entity != nil {
+		return entity.GetKindID() == topoapi.E2NODE || entity.GetKindID() == topoapi.E2CELL
+	}
+	return object.GetRelation().GetKindID() == topoapi.CONTAINS
+}
+
+// put indexes the object unless a later revision of it is indexed already
+func (i *topologyIndex) put(object *topoapi.Object) {
+	if !indexed(object) {
+		return
+	}
+	if prev, ok := i.objects[object.GetID()]; ok && prev.GetRevision() > object.GetRevision() {
+		return
+	}
+	i.remove(object.GetID())
+	i.objects[object.GetID()] = proto.Clone(object).(*topoapi.Object)
+	if relation := object.GetRelation(); relation != nil {
+		if i.contains[relation.GetSrcEntityID()] == nil {
+			i.contains[relation.GetSrcEntityID()] = make(map[topoapi.ID]bool)
+		}
+		i.contains[relation.GetSrcEntityID()][relation.GetTgtEntityID()] = true
+		if i.containedBy[relation.GetTgtEntityID()] == nil {
+			i.containedBy[relation.GetTgtEntityID()] = make(map[topoapi.ID]bool)
+		}
+		i.containedBy[relation.GetTgtEntityID()][relation.GetSrcEntityID()] = true
+	}
+}
+
+func (i *topologyIndex) remove(id topoapi.ID) {
+	object, ok := i.objects[id]
+	if !ok {
+		return
+	}
+	delete(i.objects, id)
+	if relation := object.GetRelation(); relation != nil {
+		delete(i.contains[relation.GetSrcEntityID()], relation.GetTgtEntityID())
+		if len(i.contains[relation.GetSrcEntityID()]) == 0 {
+			delete(i.contains, relation.GetSrcEntityID())
+		}
+		delete(i.containedBy[relation.GetTgtEntityID()], relation.GetSrcEntityID())
+		if len(i.containedBy[relation.GetTgtEntityID()]) == 0 {
+			delete(i.containedBy, relation.GetTgtEntityID())
+		}
+	}
+}
+
+// entities returns the entities of the kind among the IDs, ordered by ID
+func (i *topologyIndex) entities(ids map[topoapi.ID]bool, kindID topoapi.ID) []topoapi.ID {
+	result := make([]topoapi.ID, 0, len(ids))
+	for id := range ids {
+		if i.objects[id].GetEntity().GetKindID() == kindID {
+			result = append(result, id)
+		}
+	}
+	sort.Slice(result, func(a, b int) bool {
+		return result[a] < result[b]
+	})
+	return result
+}
+
+// dus returns the DUs the E2 node contains, ordered by ID
+func (i *topologyIndex) dus(cuNodeID topoapi.ID) []topoapi.ID {
+	return i.entities(i.contains[cuNodeID], topoapi.E2NODE)
+}
+
+// cus returns the CUs which contain the E2 node, ordered by ID
+func (i *topologyIndex) cus(duNodeID topoapi.ID) []topoapi.ID {
+	return i.entities(i.containedBy[duNodeID], topoapi.E2NODE)
+}
+
+// e2NodeIDPrefix returns the PLMN ID and the node ID of the E2 node ID, e.g. e2:4/e00 of e2:4/e00/3/c8
+func e2NodeIDPrefix(nodeID topoapi.ID) (string, bool) {
+	parts := strings.Split(string(nodeID), "/")
+	if len(parts) < 2 {
+		return "", false
+	}
+	return parts[0] + "/" + parts[1], true
+}
+
+// isDUNodeID returns true if the E2 node ID has the E2 node type of a DU, e.g. e2:4/e00/3/c8
+func isDUNodeID(nodeID topoapi.ID) bool {
+	parts := strings.Split(string(nodeID), "/")
+	return len(parts) == 4 && parts[2] == "3"
+}
+
+// siblings returns the other E2 nodes with the same PLMN ID and node ID which are DUs, or which are not, ordered by ID;
+// the CU and the DUs of a gNB are found by their E2 node IDs if onos-topo has no contains relations between them
+func (i *topologyIndex) siblings(nodeID topoapi.ID, dus bool) []topoapi.ID {
+	prefix, ok := e2NodeIDPrefix(nodeID)
+	if !ok {
+		return nil
+	}
+	ids := make(map[topoapi.ID]bool)
+	for id := range i.objects {
+		if id == nodeID || isDUNodeID(id) != dus {
+			continue
+		}
+		if p, ok := e2NodeIDPrefix(id); ok && p == prefix {
+			ids[id] = true
+		}
+	}
+	return i.entities(ids, topoapi.E2NODE)
+}
+
+// servingDU returns the E2 node among the E2 nodes which contains the cell
+func (i *topologyIndex) servingDU(nodeIDs []topoapi.ID, cgi CellGlobalID) (topoapi.ID, bool) {
+	for _, nodeID := range nodeIDs {
+		for _, cellID := range i.entities(i.contains[nodeID], topoapi.E2CELL) {
+			cell := &topoapi.E2Cell{}
+			if err := i.objects[cellID].GetAspect(cell); err != nil {
+				continue
+			}
+			if cgi.matches(cell.GetCellGlobalID().GetValue()) {
+				return nodeID, true
+			}
+		}
+	}
+	return "", false
+}
+
+// e2Nodes returns the E2 node objects ordered by ID
+func (i *topologyIndex) e2Nodes() []*topoapi.Object {
+	ids := make(map[topoapi.ID]bool, len(i.objects))
+	for id := range i.objects {
+		ids[id] = true
+	}
+	objects := make([]*topoapi.Object, 0)
+	for _, id := range i.entities(ids, topoapi.E2NODE) {
+		objects = append(objects, i.objects[id])
+	}
+	return objects
+}
+
+// newTopologyCache creates a cache of the E2 nodes, cells and contains relations of the store kept up to date by watching it
+func newTopologyCache(ctx context.Context, store objectStore) *topologyCache {
+	c := &topologyCache{
+		store: store,
+		index: newTopologyIndex(),
+	}
+	go c.run(ctx)
+	return c
+}
+
+// topologyCache keeps the topology of the E2 nodes in a local index, so that the DUs of the indications are
+// not looked up by listing the store; until the index is first synchronized, the reads are served by listing the store
+type topologyCache struct {
+	store  objectStore
+	mu     sync.RWMutex
+	synced bool
+	index  *topologyIndex
+}
+
+func (c *topologyCache) run(ctx context.Context) {
+	for {
+		err := c.sync(ctx)
+		if err != nil {
+			log.Warnf("Failed to synchronize the topology cache: %v", err)
+		}
+		c.mu.Lock()
+		c.synced = false
+		c.mu.Unlock()
+		select {
+		case <-time.After(watchRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync watches the store, lists it to rebuild the index, and then applies the watch events until the watch ends
+func (c *topologyCache) sync(ctx context.Context) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan topoapi.Event)
+	if err := c.store.Watch(watchCtx, ch, getTopologyFilter()); err != nil {
+		return err
+	}
+	objects, err := c.store.List(ctx)
+	if err != nil {
+		// the watch may be blocked sending an event until it sees the canceled context
+		go func() {
+			for range ch {
+			}
+		}()
+		return err
+	}
+	index := newTopologyIndex()
+	for i := range objects {
+		index.put(&objects[i])
+	}
+	// the index is updated by the client as soon as it is shared
+	log.Infof("Synchronized the topology cache with %v objects", len(index.objects))
+	c.mu.Lock()
+	c.index = index
+	c.synced = true
+	c.mu.Unlock()
+
+	for event := range ch {
+		object := event.Object
+		if event.Type == topoapi.EventType_REMOVED {
+			c.mu.Lock()
+			c.index.remove(object.GetID())
+			c.mu.Unlock()
+			continue
+		}
+		c.update(&object)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.NewUnavailable("topology watch ended")
+}
+
+// getTopologyFilter returns the filter of the E2 nodes, the cells and the contains relations
+func getTopologyFilter() *topoapi.Filters {
+	return &topoapi.Filters{
+		KindFilter: &topoapi.Filter{
+			Filter: &topoapi.Filter_In{
+				In: &topoapi.InFilter{
+					Values: []string{topoapi.E2NODE, topoapi.E2CELL, topoapi.CONTAINS},
+				},
+			},
+		},
+	}
+}
+
+// update indexes the object, e.g. an E2 node updated by the client before its watch event arrives
+func (c *topologyCache) update(object *topoapi.Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.index.put(object)
+}
+
+// read calls f with the index, or with an index of the listed store until the cache is synchronized
+func (c *topologyCache) read(ctx context.Context, f func(index *topologyIndex) error) error {
+	c.mu.RLock()
+	if c.synced {
+		defer c.mu.RUnlock()
+		return f(c.index)
+	}
+	c.mu.RUnlock()
+
+	objects, err := c.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	index := newTopologyIndex()
+	for i := range objects {
+		index.put(&objects[i])
+	}
+	return f(index)
+}
@@ -0,0 +1,276 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package rnib
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cuNodeID  = topoapi.ID("e2:4/e00/2/64")
+	du1NodeID = topoapi.ID("e2:4/e00/3/c8")
+	du2NodeID = topoapi.ID("e2:4/e00/3/c9")
+)
+
+var (
+	cell1 = CellGlobalID{PlmnID: 0x138426, CellID: 0x1, CellIDBits: NRCellIDBits}
+	cell2 = CellGlobalID{PlmnID: 0x138426, CellID: 0x2, CellIDBits: NRCellIDBits}
+)
+
+func createE2Node(ctx context.Context, t *testing.T, store *MemoryStore, nodeID topoapi.ID, slices ...*topoapi.RSMSlicingItem) {
+	node := &topoapi.Object{
+		ID:   nodeID,
+		Type: topoapi.Object_ENTITY,
+		Obj: &topoapi.Object_Entity{
+			Entity: &topoapi.Entity{
+				KindID: topoapi.E2NODE,
+			},
+		},
+	}
+	if len(slices) > 0 {
+		require.NoError(t, node.SetAspect(&topoapi.RSMSliceItemList{RsmSliceList: slices}))
+	}
+	require.NoError(t, store.Create(ctx, node))
+}
+
+func createCell(ctx context.Context, t *testing.T, store *MemoryStore, cellID topoapi.ID, cgi CellGlobalID) {
+	cell := &topoapi.Object{
+		ID:   cellID,
+		Type: topoapi.Object_ENTITY,
+		Obj: &topoapi.Object_Entity{
+			Entity: &topoapi.Entity{
+				KindID: topoapi.E2CELL,
+			},
+		},
+	}
+	require.NoError(t, cell.SetAspect(&topoapi.E2Cell{
+		CellGlobalID: &topoapi.CellGlobalID{
+			Value: fmt.Sprintf("%x", uint64(cgi.PlmnID)<<uint(cgi.CellIDBits)|cgi.CellID),
+			Type:  topoapi.CellGlobalIDType_NRCGI,
+		},
+	}))
+	require.NoError(t, store.Create(ctx, cell))
+}
+
+func createContains(ctx context.Context, t *testing.T, store *MemoryStore, src topoapi.ID, tgt topoapi.ID) topoapi.ID {
+	id := topoapi.ID(fmt.Sprintf("uuid:%v-%v", src, tgt))
+	require.NoError(t, store.Create(ctx, &topoapi.Object{
+		ID:   id,
+		Type: topoapi.Object_RELATION,
+		Obj: &topoapi.Object_Relation{
+			Relation: &topoapi.Relation{
+				KindID:      topoapi.CONTAINS,
+				SrcEntityID: src,
+				TgtEntityID: tgt,
+			},
+		},
+	}))
+	return id
+}
+
+// newSyncedClient creates a client on the store and waits until its topology cache is synchronized
+func newSyncedClient(ctx context.Context, t *testing.T, store *MemoryStore) *topoClient {
+	client := newTopoClient(ctx, store)
+	require.Eventually(t, func() bool {
+		client.topology.mu.RLock()
+		defer client.topology.mu.RUnlock()
+		return client.topology.synced
+	}, 5*time.Second, 10*time.Millisecond)
+	return client
+}
+
+func TestTopologyCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, cuNodeID)
+	createE2Node(ctx, t, store, du1NodeID)
+	createE2Node(ctx, t, store, du2NodeID)
+	createCell(ctx, t, store, "cell-1", cell1)
+	createCell(ctx, t, store, "cell-2", cell2)
+	createContains(ctx, t, store, cuNodeID, du1NodeID)
+	createContains(ctx, t, store, cuNodeID, du2NodeID)
+	createContains(ctx, t, store, du1NodeID, "cell-1")
+	createContains(ctx, t, store, du2NodeID, "cell-2")
+	client := newSyncedClient(ctx, t, store)
+
+	duNodeIDs, err := client.GetDUE2NodeIDs(ctx, cuNodeID)
+	require.NoError(t, err)
+	assert.Equal(t, []topoapi.ID{du1NodeID, du2NodeID}, duNodeIDs)
+	_, err = client.GetDUE2NodeIDs(ctx, du1NodeID)
+	assert.True(t, errors.IsInvalid(err))
+
+	cuNodeIDOfDU, err := client.GetSourceCUE2NodeID(ctx, du2NodeID)
+	require.NoError(t, err)
+	assert.Equal(t, cuNodeID, cuNodeIDOfDU)
+
+	// a DU added after the cache is synchronized is indexed from the watch
+	du3NodeID := topoapi.ID("e2:4/e00/3/ca")
+	createE2Node(ctx, t, store, du3NodeID)
+	relationID := createContains(ctx, t, store, cuNodeID, du3NodeID)
+	assert.Eventually(t, func() bool {
+		duNodeIDs, err := client.GetDUE2NodeIDs(ctx, cuNodeID)
+		return err == nil && len(duNodeIDs) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, store.Delete(ctx, relationID))
+	assert.Eventually(t, func() bool {
+		duNodeIDs, err := client.GetDUE2NodeIDs(ctx, cuNodeID)
+		return err == nil && len(duNodeIDs) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestServingDU(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, cuNodeID)
+	createE2Node(ctx, t, store, du1NodeID)
+	createE2Node(ctx, t, store, du2NodeID)
+	createCell(ctx, t, store, "cell-1", cell1)
+	createCell(ctx, t, store, "cell-2", cell2)
+	createContains(ctx, t, store, cuNodeID, du1NodeID)
+	createContains(ctx, t, store, cuNodeID, du2NodeID)
+	createContains(ctx, t, store, du1NodeID, "cell-1")
+	createContains(ctx, t, store, du2NodeID, "cell-2")
+	client := newSyncedClient(ctx, t, store)
+
+	duNodeID, err := client.GetServingDUE2NodeID(ctx, cuNodeID, cell1)
+	require.NoError(t, err)
+	assert.Equal(t, du1NodeID, duNodeID)
+	duNodeID, err = client.GetServingDUE2NodeID(ctx, cuNodeID, cell2)
+	require.NoError(t, err)
+	assert.Equal(t, du2NodeID, duNodeID)
+
+	// onos-topo may store the cell identity without the PLMN ID
+	cell := &topoapi.Object{
+		ID:   "cell-3",
+		Type: topoapi.Object_ENTITY,
+		Obj: &topoapi.Object_Entity{
+			Entity: &topoapi.Entity{
+				KindID: topoapi.E2CELL,
+			},
+		},
+	}
+	require.NoError(t, cell.SetAspect(&topoapi.E2Cell{CellGlobalID: &topoapi.CellGlobalID{Value: "3"}}))
+	require.NoError(t, store.Create(ctx, cell))
+	createContains(ctx, t, store, du2NodeID, "cell-3")
+	cell3 := CellGlobalID{PlmnID: 0x138426, CellID: 0x3, CellIDBits: NRCellIDBits}
+	assert.Eventually(t, func() bool {
+		duNodeID, err := client.GetServingDUE2NodeID(ctx, cuNodeID, cell3)
+		return err == nil && duNodeID == du2NodeID
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// a cell which is not in onos-topo is not served by any of the DUs
+	unknown := CellGlobalID{PlmnID: 0x138426, CellID: 0x4, CellIDBits: NRCellIDBits}
+	_, err = client.GetServingDUE2NodeID(ctx, cuNodeID, unknown)
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestServingDUOfCUWithOneDU(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, cuNodeID)
+	createE2Node(ctx, t, store, du1NodeID)
+	createContains(ctx, t, store, cuNodeID, du1NodeID)
+	client := newSyncedClient(ctx, t, store)
+
+	duNodeID, err := client.GetServingDUE2NodeID(ctx, cuNodeID, cell1)
+	require.NoError(t, err)
+	assert.Equal(t, du1NodeID, duNodeID)
+}
+
+func TestE2NodeIDFallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	// onos-topo has no contains relations between the CU and the DU
+	createE2Node(ctx, t, store, cuNodeID)
+	createE2Node(ctx, t, store, du1NodeID)
+	createE2Node(ctx, t, store, "e2:4/e01/2/64")
+	createE2Node(ctx, t, store, "e2:4/e01/3/c8")
+	client := newSyncedClient(ctx, t, store)
+
+	duNodeIDs, err := client.GetDUE2NodeIDs(ctx, cuNodeID)
+	require.NoError(t, err)
+	assert.Equal(t, []topoapi.ID{du1NodeID}, duNodeIDs)
+
+	duNodeID, err := client.GetServingDUE2NodeID(ctx, cuNodeID, cell1)
+	require.NoError(t, err)
+	assert.Equal(t, du1NodeID, duNodeID)
+
+	cuNodeIDOfDU, err := client.GetSourceCUE2NodeID(ctx, du1NodeID)
+	require.NoError(t, err)
+	assert.Equal(t, cuNodeID, cuNodeIDOfDU)
+
+	// the roles are not swapped
+	_, err = client.GetDUE2NodeIDs(ctx, du1NodeID)
+	assert.True(t, errors.IsNotFound(err))
+	_, err = client.GetSourceCUE2NodeID(ctx, cuNodeID)
+	assert.True(t, errors.IsNotFound(err))
+
+	// the contains relations take precedence over the E2 node IDs
+	du2NodeIDOfOtherGNB := topoapi.ID("e2:4/e01/3/c9")
+	createE2Node(ctx, t, store, du2NodeIDOfOtherGNB)
+	createContains(ctx, t, store, cuNodeID, du2NodeIDOfOtherGNB)
+	assert.Eventually(t, func() bool {
+		duNodeIDs, err := client.GetDUE2NodeIDs(ctx, cuNodeID)
+		return err == nil && len(duNodeIDs) == 1 && duNodeIDs[0] == du2NodeIDOfOtherGNB
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSliceItemsOfAllDUs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, cuNodeID)
+	createE2Node(ctx, t, store, du1NodeID, &topoapi.RSMSlicingItem{
+		ID:        "1",
+		SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE,
+	})
+	createE2Node(ctx, t, store, du2NodeID)
+	createContains(ctx, t, store, cuNodeID, du1NodeID)
+	createContains(ctx, t, store, cuNodeID, du2NodeID)
+	client := newSyncedClient(ctx, t, store)
+
+	slices, err := client.GetRSMSliceItemAspectsForAllDUs(ctx)
+	require.NoError(t, err)
+	require.Len(t, slices, 1)
+	require.Len(t, slices[string(du1NodeID)], 1)
+	assert.Equal(t, "1", slices[string(du1NodeID)][0].GetID())
+}
+
+func TestTopologyCacheStopsWatching(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	createE2Node(ctx, t, store, cuNodeID)
+	newSyncedClient(ctx, t, store)
+
+	watchers := func() int {
+		store.mu.RLock()
+		defer store.mu.RUnlock()
+		return len(store.watchers)
+	}
+	assert.Equal(t, 1, watchers())
+	cancel()
+	assert.Eventually(t, func() bool {
+		return watchers() == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
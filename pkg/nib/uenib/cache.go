@@ -0,0 +1,446 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+const (
+	// watchRetryInterval is the time before the cache lists and watches the store again after the watch ended
+	watchRetryInterval = time.Second
+	// pendingWriteTimeout is the time a write of the cache waits for its watch event;
+	// the other events of the UE are older than the write until then and are ignored
+	pendingWriteTimeout = 10 * time.Second
+)
+
+// preferredID is a UE ID in the CU the UE is attached to; the UEs are indexed with an empty CU ID too,
+// so that a UE ID can be looked up in all CUs. A zero UE ID is not set and is not indexed.
+type preferredID struct {
+	cuNodeID string
+	idType   uenib.UeIdType
+	value    int64
+}
+
+// ueIndex has the UEs indexed by global UE ID, by preferred ID in their CU and by E2 node
+type ueIndex struct {
+	ues       map[uenib.ID]*indexedUE
+	preferred map[preferredID]idSet
+	e2Nodes   map[string]idSet
+}
+
+type idSet map[uenib.ID]bool
+
+type indexedUE struct {
+	ue uenib.UE
+	// rsmUE is nil if err is set: the UE has no RSM aspect which could be read
+	rsmUE *uenib.RsmUeInfo
+	err   error
+}
+
+func newUEIndex() *ueIndex {
+	return &ueIndex{
+		ues:       make(map[uenib.ID]*indexedUE),
+		preferred: make(map[preferredID]idSet),
+		e2Nodes:   make(map[string]idSet),
+	}
+}
+
+func (i *ueIndex) put(ue uenib.UE) {
+	i.remove(ue.ID)
+	entry := &indexedUE{
+		ue:    *proto.Clone(&ue).(*uenib.UE),
+		rsmUE: &uenib.RsmUeInfo{},
+	}
+	if err := ue.GetAspect(entry.rsmUE); err != nil {
+		entry.rsmUE = nil
+		entry.err = err
+	}
+	i.ues[ue.ID] = entry
+	for _, key := range preferredIDs(entry.rsmUE) {
+		if i.preferred[key] == nil {
+			i.preferred[key] = make(idSet)
+		}
+		i.preferred[key][ue.ID] = true
+	}
+	for _, nodeID := range e2NodeIDs(entry.rsmUE) {
+		if i.e2Nodes[nodeID] == nil {
+			i.e2Nodes[nodeID] = make(idSet)
+		}
+		i.e2Nodes[nodeID][ue.ID] = true
+	}
+}
+
+// merge returns the indexed UE with the aspects of the UE, or the UE if it is not indexed
+func (i *ueIndex) merge(ue uenib.UE) uenib.UE {
+	entry, ok := i.ues[ue.ID]
+	if !ok {
+		return ue
+	}
+	merged := *proto.Clone(&entry.ue).(*uenib.UE)
+	if merged.Aspects == nil {
+		merged.Aspects = make(map[string]*types.Any)
+	}
+	for aspectType, aspect := range ue.Aspects {
+		merged.Aspects[aspectType] = aspect
+	}
+	return merged
+}
+
+func (i *ueIndex) remove(id uenib.ID) {
+	entry, ok := i.ues[id]
+	if !ok {
+		return
+	}
+	delete(i.ues, id)
+	for _, key := range preferredIDs(entry.rsmUE) {
+		delete(i.preferred[key], id)
+		if len(i.preferred[key]) == 0 {
+			delete(i.preferred, key)
+		}
+	}
+	for _, nodeID := range e2NodeIDs(entry.rsmUE) {
+		delete(i.e2Nodes[nodeID], id)
+		if len(i.e2Nodes[nodeID]) == 0 {
+			delete(i.e2Nodes, nodeID)
+		}
+	}
+}
+
+// invalid returns the error reading a UE without an RSM aspect, if there is one
+func (i *ueIndex) invalid() error {
+	for _, id := range i.ids() {
+		if err := i.ues[id].err; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *ueIndex) ids() []uenib.ID {
+	ids := make([]uenib.ID, 0, len(i.ues))
+	for id := range i.ues {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		return ids[a] < ids[b]
+	})
+	return ids
+}
+
+// withPreferredID returns the UEs with the preferred ID ordered by global UE ID
+func (i *ueIndex) withPreferredID(key preferredID) []*indexedUE {
+	return i.lookup(i.preferred[key])
+}
+
+// withE2Node returns the UEs whose CU or DU is the E2 node ordered by global UE ID
+func (i *ueIndex) withE2Node(nodeID string) []*indexedUE {
+	return i.lookup(i.e2Nodes[nodeID])
+}
+
+func (i *ueIndex) lookup(set idSet) []*indexedUE {
+	ids := make([]uenib.ID, 0, len(set))
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(a, b int) bool {
+		return ids[a] < ids[b]
+	})
+	entries := make([]*indexedUE, 0, len(ids))
+	for _, id := range ids {
+		entries = append(entries, i.ues[id])
+	}
+	return entries
+}
+
+func preferredIDs(rsmUE *uenib.RsmUeInfo) []preferredID {
+	if rsmUE == nil {
+		return nil
+	}
+	ueIDs := rsmUE.GetUeIdList()
+	keys := make([]preferredID, 0, 10)
+	for idType, value := range map[uenib.UeIdType]int64{
+		uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID:  ueIDs.GetCuUeF1apID().GetValue(),
+		uenib.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID:  ueIDs.GetDuUeF1apID().GetValue(),
+		uenib.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID:  ueIDs.GetRANUeNgapID().GetValue(),
+		uenib.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:  ueIDs.GetAMFUeNgapID().GetValue(),
+		uenib.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID: int64(ueIDs.GetEnbUeS1apID().GetValue()),
+	} {
+		if value == 0 {
+			continue
+		}
+		keys = append(keys, preferredID{
+			cuNodeID: rsmUE.GetCuE2NodeId(),
+			idType:   idType,
+			value:    value,
+		})
+		if rsmUE.GetCuE2NodeId() != "" {
+			keys = append(keys, preferredID{
+				idType: idType,
+				value:  value,
+			})
+		}
+	}
+	return keys
+}
+
+func e2NodeIDs(rsmUE *uenib.RsmUeInfo) []string {
+	nodeIDs := make([]string, 0, 2)
+	for _, nodeID := range []string{rsmUE.GetCuE2NodeId(), rsmUE.GetDuE2NodeId()} {
+		if nodeID != "" {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	return nodeIDs
+}
+
+// pendingWrite is a write of the cache whose watch event has not been received yet
+type pendingWrite struct {
+	eventType uenib.EventType
+	aspects   map[string]*types.Any
+	deadline  time.Time
+}
+
+// matches returns true if the event is the one of the write
+func (w *pendingWrite) matches(event uenib.Event) bool {
+	if event.Type == uenib.EventType_REMOVED || w.eventType == uenib.EventType_REMOVED {
+		return event.Type == w.eventType
+	}
+	for aspectType, aspect := range w.aspects {
+		eventAspect, ok := event.UE.Aspects[aspectType]
+		if !ok || !bytes.Equal(eventAspect.GetValue(), aspect.GetValue()) {
+			return false
+		}
+	}
+	return true
+}
+
+// newCache creates a cache of the store kept up to date by watching it
+func newCache(ctx context.Context, store ueStore) *ueCache {
+	c := &ueCache{
+		store:   store,
+		index:   newUEIndex(),
+		pending: make(map[uenib.ID][]*pendingWrite),
+	}
+	go c.run(ctx)
+	return c
+}
+
+// ueCache is a ueStore which keeps the UEs of the store it wraps in a local index. The writes of the cache are
+// applied to the index when the store accepts them, so that they can be read back before their watch events come.
+// Until the index is first synchronized with the store, the reads are served by listing the store.
+type ueCache struct {
+	store   ueStore
+	mu      sync.RWMutex
+	synced  bool
+	index   *ueIndex
+	pending map[uenib.ID][]*pendingWrite
+}
+
+func (c *ueCache) run(ctx context.Context) {
+	for {
+		err := c.sync(ctx)
+		if err != nil {
+			log.Warnf("Failed to synchronize the UE cache: %v", err)
+		}
+		c.mu.Lock()
+		c.synced = false
+		c.mu.Unlock()
+		select {
+		case <-time.After(watchRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sync watches the store, lists it to rebuild the index, and then applies the watch events until the watch ends
+func (c *ueCache) sync(ctx context.Context) error {
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	ch := make(chan uenib.Event)
+	if err := c.store.Watch(watchCtx, ch); err != nil {
+		return err
+	}
+	ues, err := c.store.List(ctx)
+	if err != nil {
+		// the watch may be blocked sending an event until it sees the canceled context
+		go func() {
+			for range ch {
+			}
+		}()
+		return err
+	}
+	index := newUEIndex()
+	for _, ue := range ues {
+		index.put(ue)
+	}
+	c.mu.Lock()
+	c.index = index
+	c.synced = true
+	c.mu.Unlock()
+	log.Infof("Synchronized the UE cache with %v UEs", len(ues))
+
+	for event := range ch {
+		c.apply(event)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return errors.NewUnavailable("UE watch ended")
+}
+
+func (c *ueCache) apply(event uenib.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	pending := c.pending[event.UE.ID]
+	for len(pending) > 0 && time.Now().After(pending[0].deadline) {
+		pending = pending[1:]
+	}
+	if len(pending) > 0 {
+		if !pending[0].matches(event) {
+			log.Debugf("Ignoring %v event of UE %v older than the last write", event.Type, event.UE.ID)
+			c.setPending(event.UE.ID, pending)
+			return
+		}
+		pending = pending[1:]
+	}
+	c.setPending(event.UE.ID, pending)
+
+	switch event.Type {
+	case uenib.EventType_REMOVED:
+		c.index.remove(event.UE.ID)
+	case uenib.EventType_UPDATED:
+		// an update event may only have the aspects which were updated, as the writes of the cache do
+		c.index.put(c.index.merge(event.UE))
+	default:
+		c.index.put(event.UE)
+	}
+}
+
+func (c *ueCache) setPending(id uenib.ID, pending []*pendingWrite) {
+	if len(pending) == 0 {
+		delete(c.pending, id)
+		return
+	}
+	c.pending[id] = pending
+}
+
+// write registers the write before it is sent to the store, so that its watch event is recognized even if it
+// comes before the store returns, and applies the write to the index once the store accepted it
+func (c *ueCache) write(eventType uenib.EventType, ue uenib.UE, f func() error) error {
+	c.mu.Lock()
+	w := &pendingWrite{
+		eventType: eventType,
+		aspects:   ue.Aspects,
+		deadline:  time.Now().Add(pendingWriteTimeout),
+	}
+	c.pending[ue.ID] = append(c.pending[ue.ID], w)
+	c.mu.Unlock()
+
+	err := f()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		pending := make([]*pendingWrite, 0, len(c.pending[ue.ID]))
+		for _, p := range c.pending[ue.ID] {
+			if p != w {
+				pending = append(pending, p)
+			}
+		}
+		c.setPending(ue.ID, pending)
+		return err
+	}
+
+	switch eventType {
+	case uenib.EventType_REMOVED:
+		c.index.remove(ue.ID)
+	case uenib.EventType_UPDATED:
+		c.index.put(c.index.merge(ue))
+	default:
+		c.index.put(ue)
+	}
+	return nil
+}
+
+// read calls f with the index, or with an index of the listed store until the cache is synchronized
+func (c *ueCache) read(ctx context.Context, f func(index *ueIndex) error) error {
+	c.mu.RLock()
+	if c.synced {
+		defer c.mu.RUnlock()
+		return f(c.index)
+	}
+	c.mu.RUnlock()
+
+	ues, err := c.store.List(ctx)
+	if err != nil {
+		return err
+	}
+	index := newUEIndex()
+	for _, ue := range ues {
+		index.put(ue)
+	}
+	return f(index)
+}
+
+func (c *ueCache) Create(ctx context.Context, ue uenib.UE) error {
+	return c.write(uenib.EventType_ADDED, ue, func() error {
+		return c.store.Create(ctx, ue)
+	})
+}
+
+func (c *ueCache) Update(ctx context.Context, ue uenib.UE) error {
+	return c.write(uenib.EventType_UPDATED, ue, func() error {
+		return c.store.Update(ctx, ue)
+	})
+}
+
+func (c *ueCache) Delete(ctx context.Context, id uenib.ID) error {
+	return c.write(uenib.EventType_REMOVED, uenib.UE{ID: id}, func() error {
+		return c.store.Delete(ctx, id)
+	})
+}
+
+func (c *ueCache) Get(ctx context.Context, id uenib.ID) (uenib.UE, error) {
+	var ue uenib.UE
+	err := c.read(ctx, func(index *ueIndex) error {
+		entry, ok := index.ues[id]
+		if !ok {
+			return errors.NewNotFound("UE %v not found", id)
+		}
+		ue = *proto.Clone(&entry.ue).(*uenib.UE)
+		return nil
+	})
+	return ue, err
+}
+
+// List returns the UEs ordered by ID
+func (c *ueCache) List(ctx context.Context) ([]uenib.UE, error) {
+	var ues []uenib.UE
+	err := c.read(ctx, func(index *ueIndex) error {
+		ues = make([]uenib.UE, 0, len(index.ues))
+		for _, id := range index.ids() {
+			ues = append(ues, *proto.Clone(&index.ues[id].ue).(*uenib.UE))
+		}
+		return nil
+	})
+	return ues, err
+}
+
+func (c *ueCache) Watch(ctx context.Context, ch chan<- uenib.Event) error {
+	return c.store.Watch(ctx, ch)
+}
+
+var _ ueStore = &ueCache{}
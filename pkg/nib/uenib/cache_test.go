@@ -0,0 +1,163 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func rsmUE(id string, cuNodeID string, cuUeF1apID int64, ranUeNgapID int64) *uenib.RsmUeInfo {
+	return &uenib.RsmUeInfo{
+		GlobalUeID: id,
+		UeIdList: &uenib.UeIdentity{
+			CuUeF1apID:  &uenib.CuUeF1ApID{Value: cuUeF1apID},
+			RANUeNgapID: &uenib.RanUeNgapID{Value: ranUeNgapID},
+		},
+		CuE2NodeId: cuNodeID,
+	}
+}
+
+func ueEntity(t *testing.T, ue *uenib.RsmUeInfo) uenib.UE {
+	writer := bytes.Buffer{}
+	require.NoError(t, (&jsonpb.Marshaler{}).Marshal(&writer, ue))
+	return uenib.UE{
+		ID: uenib.ID(ue.GetGlobalUeID()),
+		Aspects: map[string]*types.Any{
+			proto.MessageName(ue): {
+				TypeUrl: proto.MessageName(ue),
+				Value:   writer.Bytes(),
+			},
+		},
+	}
+}
+
+func lifecycleAspect(t *testing.T, state UEState) *types.Any {
+	lifecycle := &UELifecycle{}
+	lifecycle.transition(state, "", "test")
+	aspect, err := lifecycle.aspect()
+	require.NoError(t, err)
+	return aspect
+}
+
+// newTestCache creates a cache which is not synchronized with a store, so that the events are applied by the test
+func newTestCache() *ueCache {
+	return &ueCache{
+		synced:  true,
+		index:   newUEIndex(),
+		pending: make(map[uenib.ID][]*pendingWrite),
+	}
+}
+
+func TestCacheMergesUpdatedEvents(t *testing.T) {
+	c := newTestCache()
+	c.apply(uenib.Event{Type: uenib.EventType_ADDED, UE: ueEntity(t, rsmUE("ue-1", "cu-1", 10, 30))})
+
+	// the event of an update has only the lifecycle aspect
+	c.apply(uenib.Event{
+		Type: uenib.EventType_UPDATED,
+		UE: uenib.UE{
+			ID:      "ue-1",
+			Aspects: map[string]*types.Any{UELifecycleAspect: lifecycleAspect(t, UEStateHandingOver)},
+		},
+	})
+
+	entry, ok := c.index.ues["ue-1"]
+	require.True(t, ok)
+	require.NoError(t, entry.err)
+	assert.Equal(t, int64(10), entry.rsmUE.GetUeIdList().GetCuUeF1apID().GetValue())
+	lifecycle, err := ueLifecycle(entry.ue)
+	require.NoError(t, err)
+	assert.Equal(t, UEStateHandingOver, lifecycle.State)
+	assert.Len(t, c.index.withPreferredID(preferredID{cuNodeID: "cu-1", idType: uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, value: 10}), 1)
+
+	// the RSM aspect of a later update replaces the IDs in the index and keeps the lifecycle
+	c.apply(uenib.Event{Type: uenib.EventType_UPDATED, UE: ueEntity(t, rsmUE("ue-1", "cu-1", 11, 30))})
+	assert.Empty(t, c.index.withPreferredID(preferredID{cuNodeID: "cu-1", idType: uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, value: 10}))
+	assert.Len(t, c.index.withPreferredID(preferredID{cuNodeID: "cu-1", idType: uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, value: 11}), 1)
+	lifecycle, err = ueLifecycle(c.index.ues["ue-1"].ue)
+	require.NoError(t, err)
+	assert.Equal(t, UEStateHandingOver, lifecycle.State)
+}
+
+func TestCachePendingWriteTimeout(t *testing.T) {
+	c := newTestCache()
+	written := ueEntity(t, rsmUE("ue-1", "cu-1", 10, 30))
+	c.index.put(written)
+	c.pending["ue-1"] = []*pendingWrite{{
+		eventType: uenib.EventType_ADDED,
+		aspects:   written.Aspects,
+		deadline:  time.Now().Add(time.Hour),
+	}}
+
+	// an event older than the pending write is ignored
+	c.apply(uenib.Event{Type: uenib.EventType_REMOVED, UE: uenib.UE{ID: "ue-1"}})
+	assert.Contains(t, c.index.ues, uenib.ID("ue-1"))
+	assert.Len(t, c.pending["ue-1"], 1)
+
+	// once the write timed out without its event, the events are applied again
+	c.pending["ue-1"][0].deadline = time.Now().Add(-time.Second)
+	c.apply(uenib.Event{Type: uenib.EventType_REMOVED, UE: uenib.UE{ID: "ue-1"}})
+	assert.NotContains(t, c.index.ues, uenib.ID("ue-1"))
+	assert.NotContains(t, c.pending, uenib.ID("ue-1"))
+}
+
+func TestCachePendingWriteMatched(t *testing.T) {
+	c := newTestCache()
+	written := ueEntity(t, rsmUE("ue-1", "cu-1", 10, 30))
+	c.index.put(written)
+	c.pending["ue-1"] = []*pendingWrite{{
+		eventType: uenib.EventType_ADDED,
+		aspects:   written.Aspects,
+		deadline:  time.Now().Add(time.Hour),
+	}}
+
+	// the event of the write clears it, and the next event is applied
+	c.apply(uenib.Event{Type: uenib.EventType_ADDED, UE: written})
+	assert.NotContains(t, c.pending, uenib.ID("ue-1"))
+	c.apply(uenib.Event{Type: uenib.EventType_REMOVED, UE: uenib.UE{ID: "ue-1"}})
+	assert.NotContains(t, c.index.ues, uenib.ID("ue-1"))
+}
+
+func TestPreferredIDLookup(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	client := NewMemoryClient(store)
+
+	// ue-1 has no RAN UE NGAP ID; ue-2 and ue-3 have the same CU UE F1AP ID on different CUs
+	require.NoError(t, client.AddUE(ctx, rsmUE("ue-1", "cu-1", 10, 0)))
+	require.NoError(t, client.AddUE(ctx, rsmUE("ue-3", "cu-2", 20, 31)))
+	require.NoError(t, client.AddUE(ctx, rsmUE("ue-2", "cu-1", 20, 30)))
+
+	_, err = client.GetUEWithPreferredID(ctx, "cu-1", uenib.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, 0)
+	assert.Error(t, err)
+	ues, err := client.GetUEsWithPreferredID(ctx, "", uenib.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, 0)
+	require.NoError(t, err)
+	assert.Empty(t, ues)
+
+	ue, err := client.GetUEWithPreferredID(ctx, "cu-2", uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, 20)
+	require.NoError(t, err)
+	assert.Equal(t, "ue-3", ue.GetGlobalUeID())
+
+	// in all CUs, the first UE ordered by global UE ID is returned
+	ue, err = client.GetUEWithPreferredID(ctx, "", uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, 20)
+	require.NoError(t, err)
+	assert.Equal(t, "ue-2", ue.GetGlobalUeID())
+	ues, err = client.GetUEsWithPreferredID(ctx, "", uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, 20)
+	require.NoError(t, err)
+	require.Len(t, ues, 2)
+	assert.Equal(t, "ue-2", ues[0].GetGlobalUeID())
+	assert.Equal(t, "ue-3", ues[1].GetGlobalUeID())
+}
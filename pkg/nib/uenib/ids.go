@@ -0,0 +1,34 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"github.com/onosproject/onos-api/go/onos/uenib"
+)
+
+// UEIDValue returns the UE ID of the given type; -1 if the type is not known
+func UEIDValue(ueID *uenib.UeIdentity, idType uenib.UeIdType) int64 {
+	switch idType {
+	case uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID:
+		return ueID.GetCuUeF1apID().GetValue()
+	case uenib.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID:
+		return ueID.GetDuUeF1apID().GetValue()
+	case uenib.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID:
+		return ueID.GetRANUeNgapID().GetValue()
+	case uenib.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:
+		return ueID.GetAMFUeNgapID().GetValue()
+	case uenib.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID:
+		return int64(ueID.GetEnbUeS1apID().GetValue())
+	}
+	return -1
+}
+
+// DrbValue returns the value of the 4G or 5G DRB ID
+func DrbValue(drbID *uenib.DrbId) int32 {
+	if drbID.GetFourGdrbId() != nil {
+		return drbID.GetFourGdrbId().GetValue()
+	}
+	return drbID.GetFiveGdrbId().GetValue()
+}
@@ -0,0 +1,143 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// UELifecycleAspect is the type of the UE-NIB aspect with the lifecycle of a UE, which is stored on the UE entity
+// with its RsmUeInfo aspect; it is added with the RsmUeInfo aspect and removed with the UE
+const UELifecycleAspect = "onos.rsm.UeLifecycle"
+
+// maxUETransitions is the number of the last transitions kept in the lifecycle of a UE
+const maxUETransitions = 10
+
+// UEState is the state of a UE in its lifecycle
+type UEState string
+
+const (
+	// UEStateAttached is the state of a UE served by a DU
+	UEStateAttached UEState = "ATTACHED"
+	// UEStateHandingOver is the state of a UE handed out by its DU whose hand-in was not reported yet
+	UEStateHandingOver UEState = "HANDING_OVER"
+	// UEStateDetached is the state in which a UE is removed from UE-NIB
+	UEStateDetached UEState = "DETACHED"
+)
+
+// UETransition is a change of the state of a UE
+type UETransition struct {
+	From     UEState   `json:"from,omitempty"`
+	To       UEState   `json:"to"`
+	Time     time.Time `json:"time"`
+	E2NodeID string    `json:"e2NodeId,omitempty"`
+	Cause    string    `json:"cause,omitempty"`
+}
+
+// UELifecycle is the state of a UE and its last transitions
+type UELifecycle struct {
+	State       UEState        `json:"state"`
+	Since       time.Time      `json:"since"`
+	Transitions []UETransition `json:"transitions,omitempty"`
+}
+
+// transition moves the lifecycle to the state
+func (l *UELifecycle) transition(to UEState, e2NodeID string, cause string) {
+	now := time.Now()
+	l.Transitions = append(l.Transitions, UETransition{
+		From:     l.State,
+		To:       to,
+		Time:     now,
+		E2NodeID: e2NodeID,
+		Cause:    cause,
+	})
+	if len(l.Transitions) > maxUETransitions {
+		l.Transitions = l.Transitions[len(l.Transitions)-maxUETransitions:]
+	}
+	l.State = to
+	l.Since = now
+}
+
+func (l *UELifecycle) aspect() (*types.Any, error) {
+	value, err := json.Marshal(l)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Any{
+		TypeUrl: UELifecycleAspect,
+		Value:   value,
+	}, nil
+}
+
+// ueLifecycle returns the lifecycle of the UE; a UE stored without one is attached
+func ueLifecycle(ue uenib.UE) (*UELifecycle, error) {
+	aspect, ok := ue.Aspects[UELifecycleAspect]
+	if !ok {
+		return &UELifecycle{
+			State: UEStateAttached,
+		}, nil
+	}
+	lifecycle := &UELifecycle{}
+	if err := json.Unmarshal(aspect.GetValue(), lifecycle); err != nil {
+		return nil, fmt.Errorf("failed to read the lifecycle of UE %v: %v", ue.ID, err)
+	}
+	return lifecycle, nil
+}
+
+func (c *client) GetUELifecycle(ctx context.Context, id string) (*UELifecycle, error) {
+	var lifecycle *UELifecycle
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		entry, ok := index.ues[uenib.ID(id)]
+		if !ok {
+			return errors.NewNotFound(fmt.Sprintf("Global UE ID %v does not exist", id))
+		}
+		var err error
+		lifecycle, err = ueLifecycle(entry.ue)
+		return err
+	})
+	return lifecycle, err
+}
+
+func (c *client) GetUELifecycles(ctx context.Context) (map[string]*UELifecycle, error) {
+	lifecycles := make(map[string]*UELifecycle)
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		for id, entry := range index.ues {
+			lifecycle, err := ueLifecycle(entry.ue)
+			if err != nil {
+				log.Warn(err)
+				continue
+			}
+			lifecycles[string(id)] = lifecycle
+		}
+		return nil
+	})
+	return lifecycles, err
+}
+
+func (c *client) SetUEState(ctx context.Context, id string, state UEState, e2NodeID string, cause string) error {
+	lifecycle, err := c.GetUELifecycle(ctx, id)
+	if err != nil {
+		return err
+	}
+	lifecycle.transition(state, e2NodeID, cause)
+	aspect, err := lifecycle.aspect()
+	if err != nil {
+		return err
+	}
+	log.Infof("UE %v is %v (%v on E2 node %v)", id, state, cause, e2NodeID)
+	return c.store.Update(ctx, uenib.UE{
+		ID: uenib.ID(id),
+		Aspects: map[string]*types.Any{
+			UELifecycleAspect: aspect,
+		},
+	})
+}
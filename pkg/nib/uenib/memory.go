@@ -0,0 +1,264 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// NewMemoryClient creates a UE-NIB client on the in-memory store, e.g. to run without onos-uenib
+func NewMemoryClient(store *MemoryStore) Client {
+	return newClient(context.Background(), store)
+}
+
+// NewMemoryStore creates an in-memory store of UE entities; if snapshotPath is set,
+// the UEs are loaded from the snapshot file and every change is written to it
+func NewMemoryStore(snapshotPath string) (*MemoryStore, error) {
+	s := &MemoryStore{
+		snapshotPath: snapshotPath,
+		ues:          make(map[uenib.ID]*uenib.UE),
+		watchers:     make(map[*ueWatcher]struct{}),
+	}
+	if snapshotPath == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(snapshotPath)
+	if os.IsNotExist(err) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+
+	snapshot := &ueSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	for _, bytes := range snapshot.UEs {
+		ue := &uenib.UE{}
+		if err := proto.Unmarshal(bytes, ue); err != nil {
+			return nil, err
+		}
+		s.ues[ue.ID] = ue
+	}
+	log.Infof("Loaded %d UEs from %v", len(s.ues), snapshotPath)
+	return s, nil
+}
+
+// MemoryStore keeps the UE entities in memory with the semantics of onos-uenib: an update replaces only
+// the aspects it has, and watchers get the existing UEs and then the changes
+type MemoryStore struct {
+	snapshotPath string
+	mu           sync.RWMutex
+	ues          map[uenib.ID]*uenib.UE
+	watchers     map[*ueWatcher]struct{}
+}
+
+// ueSnapshot is the snapshot file; the UEs are protobuf encoded
+type ueSnapshot struct {
+	UEs [][]byte `json:"ues"`
+}
+
+func (s *MemoryStore) Create(_ context.Context, ue uenib.UE) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ue.ID == "" {
+		return errors.NewInvalid("UE ID is empty")
+	}
+	if _, ok := s.ues[ue.ID]; ok {
+		return errors.NewAlreadyExists("UE %v already exists", ue.ID)
+	}
+
+	stored := proto.Clone(&ue).(*uenib.UE)
+	s.ues[ue.ID] = stored
+	if err := s.persist(); err != nil {
+		delete(s.ues, ue.ID)
+		return err
+	}
+	s.notify(uenib.EventType_ADDED, stored)
+	return nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, ue uenib.UE) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.ues[ue.ID]
+	if !ok {
+		return errors.NewNotFound("UE %v not found", ue.ID)
+	}
+
+	stored := proto.Clone(prev).(*uenib.UE)
+	if stored.Aspects == nil {
+		stored.Aspects = make(map[string]*types.Any)
+	}
+	for aspectType, aspect := range ue.Aspects {
+		stored.Aspects[aspectType] = proto.Clone(aspect).(*types.Any)
+	}
+	s.ues[ue.ID] = stored
+	if err := s.persist(); err != nil {
+		s.ues[ue.ID] = prev
+		return err
+	}
+	s.notify(uenib.EventType_UPDATED, stored)
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id uenib.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	prev, ok := s.ues[id]
+	if !ok {
+		return errors.NewNotFound("UE %v not found", id)
+	}
+
+	delete(s.ues, id)
+	if err := s.persist(); err != nil {
+		s.ues[id] = prev
+		return err
+	}
+	s.notify(uenib.EventType_REMOVED, prev)
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id uenib.ID) (uenib.UE, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ue, ok := s.ues[id]
+	if !ok {
+		return uenib.UE{}, errors.NewNotFound("UE %v not found", id)
+	}
+	return *proto.Clone(ue).(*uenib.UE), nil
+}
+
+// List returns the UEs ordered by ID
+func (s *MemoryStore) List(_ context.Context) ([]uenib.UE, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.list(), nil
+}
+
+func (s *MemoryStore) list() []uenib.UE {
+	ues := make([]uenib.UE, 0, len(s.ues))
+	for _, ue := range s.ues {
+		ues = append(ues, *proto.Clone(ue).(*uenib.UE))
+	}
+	sort.Slice(ues, func(i, j int) bool {
+		return ues[i].ID < ues[j].ID
+	})
+	return ues
+}
+
+func (s *MemoryStore) Watch(ctx context.Context, ch chan<- uenib.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	w := &ueWatcher{
+		signal: make(chan struct{}, 1),
+	}
+	for _, ue := range s.list() {
+		w.push(uenib.Event{
+			Type: uenib.EventType_NONE,
+			UE:   ue,
+		})
+	}
+	s.watchers[w] = struct{}{}
+
+	go func() {
+		w.run(ctx, ch)
+		s.mu.Lock()
+		delete(s.watchers, w)
+		s.mu.Unlock()
+	}()
+	return nil
+}
+
+func (s *MemoryStore) notify(eventType uenib.EventType, ue *uenib.UE) {
+	for w := range s.watchers {
+		w.push(uenib.Event{
+			Type: eventType,
+			UE:   *proto.Clone(ue).(*uenib.UE),
+		})
+	}
+}
+
+// persist writes all UEs to a temporary file and renames it over the snapshot file
+func (s *MemoryStore) persist() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	snapshot := &ueSnapshot{
+		UEs: make([][]byte, 0, len(s.ues)),
+	}
+	for _, ue := range s.ues {
+		bytes, err := proto.Marshal(ue)
+		if err != nil {
+			return err
+		}
+		snapshot.UEs = append(snapshot.UEs, bytes)
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.snapshotPath), 0755); err != nil {
+		return err
+	}
+	tmp := s.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.snapshotPath)
+}
+
+// ueWatcher queues the events of a watch, so that a slow watcher does not block the store
+type ueWatcher struct {
+	mu     sync.Mutex
+	events []uenib.Event
+	signal chan struct{}
+}
+
+func (w *ueWatcher) push(event uenib.Event) {
+	w.mu.Lock()
+	w.events = append(w.events, event)
+	w.mu.Unlock()
+	select {
+	case w.signal <- struct{}{}:
+	default:
+	}
+}
+
+func (w *ueWatcher) run(ctx context.Context, ch chan<- uenib.Event) {
+	defer close(ch)
+	for {
+		w.mu.Lock()
+		events := w.events
+		w.events = nil
+		w.mu.Unlock()
+		for _, event := range events {
+			select {
+			case ch <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case <-w.signal:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func nextEvent(t *testing.T, ch chan uenib.Event) uenib.Event {
+	select {
+	case event, ok := <-ch:
+		require.True(t, ok, "watch closed")
+		return event
+	case <-time.After(5 * time.Second):
+		require.FailNow(t, "no watch event")
+	}
+	return uenib.Event{}
+}
+
+func TestMemoryStoreMergesAspects(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+
+	ue := ueEntity(t, rsmUE("ue-1", "cu-1", 10, 30))
+	require.NoError(t, store.Create(ctx, ue))
+	assert.True(t, errors.IsAlreadyExists(store.Create(ctx, ue)))
+	assert.True(t, errors.IsInvalid(store.Create(ctx, uenib.UE{})))
+	assert.True(t, errors.IsNotFound(store.Update(ctx, uenib.UE{ID: "ue-2"})))
+
+	// an update replaces the aspects it has and keeps the others
+	require.NoError(t, store.Update(ctx, uenib.UE{
+		ID: "ue-1",
+		Aspects: map[string]*types.Any{
+			UELifecycleAspect: lifecycleAspect(t, UEStateHandingOver),
+		},
+	}))
+	stored, err := store.Get(ctx, "ue-1")
+	require.NoError(t, err)
+	assert.Len(t, stored.Aspects, 2)
+	info := &uenib.RsmUeInfo{}
+	require.NoError(t, stored.GetAspect(info))
+	assert.Equal(t, int64(10), info.GetUeIdList().GetCuUeF1apID().GetValue())
+	lifecycle, err := ueLifecycle(stored)
+	require.NoError(t, err)
+	assert.Equal(t, UEStateHandingOver, lifecycle.State)
+
+	require.NoError(t, store.Delete(ctx, "ue-1"))
+	_, err = store.Get(ctx, "ue-1")
+	assert.True(t, errors.IsNotFound(err))
+	assert.True(t, errors.IsNotFound(store.Delete(ctx, "ue-1")))
+}
+
+func TestMemoryStoreWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	store, err := NewMemoryStore("")
+	require.NoError(t, err)
+	require.NoError(t, store.Create(ctx, ueEntity(t, rsmUE("ue-2", "cu-1", 20, 30))))
+	require.NoError(t, store.Create(ctx, ueEntity(t, rsmUE("ue-1", "cu-1", 10, 30))))
+
+	// the existing UEs are replayed ordered by ID, and then the changes are sent
+	ch := make(chan uenib.Event)
+	require.NoError(t, store.Watch(ctx, ch))
+	event := nextEvent(t, ch)
+	assert.Equal(t, uenib.EventType_NONE, event.Type)
+	assert.Equal(t, uenib.ID("ue-1"), event.UE.ID)
+	event = nextEvent(t, ch)
+	assert.Equal(t, uenib.EventType_NONE, event.Type)
+	assert.Equal(t, uenib.ID("ue-2"), event.UE.ID)
+
+	require.NoError(t, store.Update(ctx, ueEntity(t, rsmUE("ue-1", "cu-1", 11, 30))))
+	event = nextEvent(t, ch)
+	assert.Equal(t, uenib.EventType_UPDATED, event.Type)
+	assert.Equal(t, uenib.ID("ue-1"), event.UE.ID)
+
+	require.NoError(t, store.Delete(ctx, "ue-2"))
+	event = nextEvent(t, ch)
+	assert.Equal(t, uenib.EventType_REMOVED, event.Type)
+	assert.Equal(t, uenib.ID("ue-2"), event.UE.ID)
+
+	// the watch is closed once its context is done
+	cancel()
+	assert.Eventually(t, func() bool {
+		_, ok := <-ch
+		return !ok
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestMemoryStoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "uenib", "snapshot.json")
+	store, err := NewMemoryStore(path)
+	require.NoError(t, err)
+	client := NewMemoryClient(store)
+	require.NoError(t, client.AddUE(ctx, rsmUE("ue-1", "cu-1", 10, 30)))
+	require.NoError(t, client.AddUE(ctx, rsmUE("ue-2", "cu-1", 20, 31)))
+	require.NoError(t, client.DeleteUE(ctx, "ue-2"))
+
+	// the UEs of the restart are found by their preferred IDs
+	restarted, err := NewMemoryStore(path)
+	require.NoError(t, err)
+	ues, err := restarted.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, ues, 1)
+	client = NewMemoryClient(restarted)
+	ue, err := client.GetUEWithPreferredID(ctx, "cu-1", uenib.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, 30)
+	require.NoError(t, err)
+	assert.Equal(t, "ue-1", ue.GetGlobalUeID())
+}
@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package uenib
+
+import (
+	"context"
+	"io"
+
+	"github.com/onosproject/onos-api/go/onos/uenib"
+)
+
+// ueStore is the store of the UE entities the UE-NIB client reads and writes
+type ueStore interface {
+	Create(ctx context.Context, ue uenib.UE) error
+	Update(ctx context.Context, ue uenib.UE) error
+	Delete(ctx context.Context, id uenib.ID) error
+	Get(ctx context.Context, id uenib.ID) (uenib.UE, error)
+	List(ctx context.Context) ([]uenib.UE, error)
+	// Watch sends the existing UEs and then their changes to ch; ch is closed when ctx is done
+	Watch(ctx context.Context, ch chan<- uenib.Event) error
+}
+
+// grpcStore is the store of onos-uenib
+type grpcStore struct {
+	client uenib.UEServiceClient
+}
+
+func (s *grpcStore) Create(ctx context.Context, ue uenib.UE) error {
+	resp, err := s.client.CreateUE(ctx, &uenib.CreateUERequest{
+		UE: ue,
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("CreateUE Resp: %v", resp)
+	return nil
+}
+
+func (s *grpcStore) Update(ctx context.Context, ue uenib.UE) error {
+	resp, err := s.client.UpdateUE(ctx, &uenib.UpdateUERequest{
+		UE: ue,
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("Update UE Resp: %v", resp)
+	return nil
+}
+
+func (s *grpcStore) Delete(ctx context.Context, id uenib.ID) error {
+	resp, err := s.client.DeleteUE(ctx, &uenib.DeleteUERequest{
+		ID: id,
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("DeleteUE Resp: %v", resp)
+	return nil
+}
+
+func (s *grpcStore) Get(ctx context.Context, id uenib.ID) (uenib.UE, error) {
+	resp, err := s.client.GetUE(ctx, &uenib.GetUERequest{
+		ID: id,
+	})
+	if err != nil {
+		return uenib.UE{}, err
+	}
+	return resp.GetUE(), nil
+}
+
+func (s *grpcStore) List(ctx context.Context) ([]uenib.UE, error) {
+	stream, err := s.client.ListUEs(ctx, &uenib.ListUERequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]uenib.UE, 0)
+	for {
+		object, err := stream.Recv()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return nil, err
+		}
+		result = append(result, object.GetUE())
+	}
+}
+
+func (s *grpcStore) Watch(ctx context.Context, ch chan<- uenib.Event) error {
+	stream, err := s.client.WatchUEs(ctx, &uenib.WatchUERequest{})
+	if err != nil {
+		close(ch)
+		return err
+	}
+
+	go func() {
+		defer close(ch)
+		for {
+			resp, err := stream.Recv()
+			if err != nil {
+				if err != io.EOF && ctx.Err() == nil {
+					log.Warn(err)
+				}
+				return
+			}
+			ch <- resp.Event
+		}
+	}()
+	return nil
+}
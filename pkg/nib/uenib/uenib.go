@@ -9,7 +9,6 @@ import (
 	"context"
 	"fmt"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
-	"io"
 
 	"github.com/gogo/protobuf/jsonpb"
 	"github.com/gogo/protobuf/proto"
@@ -27,9 +26,18 @@ func NewClient(ctx context.Context, certPath string, keyPath string, uenibAddr s
 		return nil, err
 	}
 
-	return &client{
+	return newClient(ctx, &grpcStore{
 		client: uenib.NewUEServiceClient(conn),
-	}, nil
+	}), nil
+}
+
+// newClient creates a client which reads the UEs from a cache of the store
+func newClient(ctx context.Context, store ueStore) *client {
+	cache := newCache(ctx, store)
+	return &client{
+		store: cache,
+		cache: cache,
+	}
 }
 
 type Client interface {
@@ -41,32 +49,42 @@ type Client interface {
 	GetUEWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) (*uenib.RsmUeInfo, error)
 	GetUEWithGlobalID(ctx context.Context, id string) (*uenib.RsmUeInfo, error)
 	GetUEs(ctx context.Context) ([]*uenib.RsmUeInfo, error)
+	GetUEsWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) ([]*uenib.RsmUeInfo, error)
 	GetUenibUEWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) (uenib.UE, error)
 	DeleteUEWithE2NodeID(ctx context.Context, e2NodeID string) error
+	WatchUEs(ctx context.Context, ch chan uenib.Event) error
+	GetUELifecycle(ctx context.Context, id string) (*UELifecycle, error)
+	GetUELifecycles(ctx context.Context) (map[string]*UELifecycle, error)
+	SetUEState(ctx context.Context, id string, state UEState, e2NodeID string, cause string) error
 }
 
 type client struct {
-	client uenib.UEServiceClient
+	store ueStore
+	cache *ueCache
 }
 
 func (c *client) HasUE(ctx context.Context, ue *uenib.RsmUeInfo) bool {
-	list, err := c.GetUEs(ctx)
+	var item *uenib.RsmUeInfo
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		if entry, ok := index.ues[uenib.ID(ue.GetGlobalUeID())]; ok {
+			item = entry.rsmUE
+		}
+		return nil
+	})
 	if err != nil {
 		log.Debug("onos-uenib has no UE")
 		return false
 	}
 
-	for _, item := range list {
-		if item.GetGlobalUeID() == ue.GetGlobalUeID() &&
-			item.GetUeIdList().GetDuUeF1apID().Value == ue.GetUeIdList().GetDuUeF1apID().Value &&
-			item.GetUeIdList().GetCuUeF1apID().Value == ue.GetUeIdList().GetCuUeF1apID().Value &&
-			item.GetUeIdList().GetRANUeNgapID().Value == ue.GetUeIdList().GetRANUeNgapID().Value &&
-			item.GetUeIdList().GetEnbUeS1apID().Value == ue.GetUeIdList().GetEnbUeS1apID().Value &&
-			item.GetUeIdList().GetPreferredIDType().String() == ue.GetUeIdList().GetPreferredIDType().String() &&
-			item.GetCellGlobalId() == ue.GetCellGlobalId() &&
-			item.GetCuE2NodeId() == ue.GetCuE2NodeId() && item.GetDuE2NodeId() == ue.GetDuE2NodeId() {
-			return true
-		}
+	if item != nil &&
+		item.GetUeIdList().GetDuUeF1apID().GetValue() == ue.GetUeIdList().GetDuUeF1apID().GetValue() &&
+		item.GetUeIdList().GetCuUeF1apID().GetValue() == ue.GetUeIdList().GetCuUeF1apID().GetValue() &&
+		item.GetUeIdList().GetRANUeNgapID().GetValue() == ue.GetUeIdList().GetRANUeNgapID().GetValue() &&
+		item.GetUeIdList().GetEnbUeS1apID().GetValue() == ue.GetUeIdList().GetEnbUeS1apID().GetValue() &&
+		item.GetUeIdList().GetPreferredIDType().String() == ue.GetUeIdList().GetPreferredIDType().String() &&
+		item.GetCellGlobalId() == ue.GetCellGlobalId() &&
+		item.GetCuE2NodeId() == ue.GetCuE2NodeId() && item.GetDuE2NodeId() == ue.GetDuE2NodeId() {
+		return true
 	}
 
 	log.Debugf("onos-uenib has UE %v", *ue)
@@ -97,21 +115,24 @@ func (c *client) AddUE(ctx context.Context, ue *uenib.RsmUeInfo) error {
 		Value:   writer.Bytes(),
 	}
 
-	req := &uenib.CreateUERequest{
-		UE: uenibObj,
+	// a UE is added when it attaches
+	lifecycle := &UELifecycle{}
+	lifecycle.transition(UEStateAttached, ue.GetDuE2NodeId(), "attach")
+	uenibObj.Aspects[UELifecycleAspect], err = lifecycle.aspect()
+	if err != nil {
+		return err
 	}
 
-	resp, err := c.client.CreateUE(ctx, req)
+	err = c.store.Create(ctx, uenibObj)
 	if err != nil {
 		log.Warn(err)
 	}
-
-	log.Debugf("CreateUE Resp: %v", resp)
 	return nil
 }
 
 func (c *client) UpdateUE(ctx context.Context, ue *uenib.RsmUeInfo) error {
-	if !c.HasUE(ctx, ue) {
+	// the RAN UE IDs and the E2 nodes of a UE change when it is handed over, so only its global UE ID has to exist
+	if _, err := c.store.Get(ctx, uenib.ID(ue.GetGlobalUeID())); err != nil {
 		return errors.NewNotFound(fmt.Sprintf("UE not found - UE: %v", *ue))
 	}
 
@@ -132,18 +153,7 @@ func (c *client) UpdateUE(ctx context.Context, ue *uenib.RsmUeInfo) error {
 		Value:   writer.Bytes(),
 	}
 
-	req := &uenib.UpdateUERequest{
-		UE: uenibObj,
-	}
-
-	resp, err := c.client.UpdateUE(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	log.Debugf("Update UE Resp: %v", resp)
-
-	return nil
+	return c.store.Update(ctx, uenibObj)
 }
 
 func (c *client) DeleteUE(ctx context.Context, id string) error {
@@ -163,17 +173,7 @@ func (c *client) DeleteUE(ctx context.Context, id string) error {
 		return errors.NewNotFound(fmt.Sprintf("UE not found - UE: %v", *rsmUE))
 	}
 
-	req := &uenib.DeleteUERequest{
-		ID: uenib.ID(rsmUE.GetGlobalUeID()),
-	}
-
-	resp, err := c.client.DeleteUE(ctx, req)
-	if err != nil {
-		return err
-	}
-
-	log.Debugf("DeleteUE Resp: %v", resp)
-	return nil
+	return c.store.Delete(ctx, uenib.ID(rsmUE.GetGlobalUeID()))
 }
 
 func (c *client) DeleteUEWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) error {
@@ -191,35 +191,43 @@ func (c *client) DeleteUEWithPreferredID(ctx context.Context, cuNodeID string, p
 }
 
 func (c *client) DeleteUEWithE2NodeID(ctx context.Context, e2NodeID string) error {
-	ues, err := c.GetUEs(ctx)
+	var ids []string
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		for _, entry := range index.withE2Node(e2NodeID) {
+			ids = append(ids, string(entry.ue.ID))
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
 
-	for _, ue := range ues {
-		if ue.GetDuE2NodeId() == e2NodeID || ue.GetCuE2NodeId() == e2NodeID {
-			err = c.DeleteUE(ctx, ue.GetGlobalUeID())
-			if err != nil {
-				log.Warn(err)
-			}
+	for _, id := range ids {
+		err = c.DeleteUE(ctx, id)
+		if err != nil {
+			log.Warn(err)
 		}
 	}
 	return err
 }
 
 func (c *client) GetUEWithGlobalID(ctx context.Context, id string) (*uenib.RsmUeInfo, error) {
-	list, err := c.GetUEs(ctx)
+	var ue *uenib.RsmUeInfo
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		entry, ok := index.ues[uenib.ID(id)]
+		if !ok {
+			return errors.NewNotFound(fmt.Sprintf("Global UE ID %v does not exist", id))
+		}
+		if entry.err != nil {
+			return entry.err
+		}
+		ue = proto.Clone(entry.rsmUE).(*uenib.RsmUeInfo)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	for _, item := range list {
-		if item.GetGlobalUeID() == id {
-			return item, nil
-		}
-	}
-
-	return nil, errors.NewNotFound(fmt.Sprintf("Global UE ID %v does not exist", id))
+	return ue, nil
 }
 
 func (c *client) GetUEWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) (*uenib.RsmUeInfo, error) {
@@ -237,97 +245,79 @@ func (c *client) GetUEWithPreferredID(ctx context.Context, cuNodeID string, pref
 
 func (c *client) GetUEs(ctx context.Context) ([]*uenib.RsmUeInfo, error) {
 	result := make([]*uenib.RsmUeInfo, 0)
-
-	stream, err := c.client.ListUEs(ctx, &uenib.ListUERequest{})
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		if err := index.invalid(); err != nil {
+			return err
+		}
+		for _, id := range index.ids() {
+			result = append(result, proto.Clone(index.ues[id].rsmUE).(*uenib.RsmUeInfo))
+		}
+		return nil
+	})
 	if err != nil {
 		return []*uenib.RsmUeInfo{}, err
 	}
+	return result, nil
+}
 
-	for {
-		object, err := stream.Recv()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return []*uenib.RsmUeInfo{}, err
-		}
-		ue := object.GetUE()
-		rsmUE := &uenib.RsmUeInfo{}
-		err = ue.GetAspect(rsmUE)
-		if err != nil {
-			return []*uenib.RsmUeInfo{}, err
+// GetUEsWithPreferredID returns the UEs with the UE ID in the CU ordered by global UE ID; with an empty CU ID,
+// the UEs with the UE ID in any CU are returned
+func (c *client) GetUEsWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) ([]*uenib.RsmUeInfo, error) {
+	if preferredType == uenib.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID {
+		ueID = int64(int32(ueID))
+	}
+	result := make([]*uenib.RsmUeInfo, 0)
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		for _, entry := range index.withPreferredID(preferredID{
+			cuNodeID: cuNodeID,
+			idType:   preferredType,
+			value:    ueID,
+		}) {
+			result = append(result, proto.Clone(entry.rsmUE).(*uenib.RsmUeInfo))
 		}
-
-		result = append(result, rsmUE)
+		return nil
+	})
+	if err != nil {
+		return []*uenib.RsmUeInfo{}, err
 	}
 	return result, nil
 }
 
 func (c *client) getUenibUEWithGlobalUeID(ctx context.Context, id string) (uenib.UE, error) {
-	req := &uenib.GetUERequest{
-		ID: uenib.ID(id),
-	}
-
-	resp, err := c.client.GetUE(ctx, req)
-	if err != nil {
-		return uenib.UE{}, err
-	}
-	return resp.GetUE(), nil
+	return c.store.Get(ctx, uenib.ID(id))
 }
 
+// GetUenibUEWithPreferredID returns the first UE with the UE ID in the CU ordered by global UE ID
 func (c *client) GetUenibUEWithPreferredID(ctx context.Context, cuNodeID string, preferredType uenib.UeIdType, ueID int64) (uenib.UE, error) {
-	var result uenib.UE
-	hasUE := false
-	stream, err := c.client.ListUEs(ctx, &uenib.ListUERequest{})
-	if err != nil {
-		return uenib.UE{}, err
+	switch preferredType {
+	case uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, uenib.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, uenib.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID,
+		uenib.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:
+	case uenib.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID:
+		ueID = int64(int32(ueID))
+	default:
+		return uenib.UE{}, errors.NewNotSupported(fmt.Sprintf("ID type %v is not allowed", preferredType.String()))
 	}
 
-	for {
-		object, err := stream.Recv()
-		if err == io.EOF {
-			break
-		} else if err != nil {
-			return uenib.UE{}, err
-		}
-
-		rsmUE := &uenib.RsmUeInfo{}
-		uenibUE := object.GetUE()
-		err = uenibUE.GetAspect(rsmUE)
-		if err != nil {
-			return uenib.UE{}, err
-		}
-		switch preferredType {
-		case uenib.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID:
-			if rsmUE.GetCuE2NodeId() == cuNodeID && rsmUE.GetUeIdList().GetCuUeF1apID().Value == ueID {
-				result = object.GetUE()
-				hasUE = true
-			}
-		case uenib.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID:
-			if rsmUE.GetCuE2NodeId() == cuNodeID && rsmUE.GetUeIdList().GetDuUeF1apID().Value == ueID {
-				result = object.GetUE()
-				hasUE = true
-			}
-		case uenib.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID:
-			if rsmUE.GetCuE2NodeId() == cuNodeID && rsmUE.GetUeIdList().GetRANUeNgapID().Value == ueID {
-				result = object.GetUE()
-				hasUE = true
-			}
-		case uenib.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID:
-			if rsmUE.GetCuE2NodeId() == cuNodeID && rsmUE.GetUeIdList().GetAMFUeNgapID().Value == ueID {
-				result = object.GetUE()
-				hasUE = true
-			}
-		case uenib.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID:
-			if rsmUE.GetCuE2NodeId() == cuNodeID && rsmUE.GetUeIdList().GetEnbUeS1apID().Value == int32(ueID) {
-				result = object.GetUE()
-				hasUE = true
-			}
-		default:
-			return uenib.UE{}, errors.NewNotSupported(fmt.Sprintf("ID type %v is not allowed", preferredType.String()))
+	var result uenib.UE
+	err := c.cache.read(ctx, func(index *ueIndex) error {
+		entries := index.withPreferredID(preferredID{
+			cuNodeID: cuNodeID,
+			idType:   preferredType,
+			value:    ueID,
+		})
+		if len(entries) == 0 {
+			return errors.NewNotFound(fmt.Sprintf("UE ID %v %v does not exist in CU %v", preferredType.String(), ueID, cuNodeID))
 		}
-	}
-	if !hasUE {
-		return uenib.UE{}, errors.NewNotFound(fmt.Sprintf("UE ID %v %v does not exist in CU %v", preferredType.String(), ueID, cuNodeID))
+		result = *proto.Clone(&entries[0].ue).(*uenib.UE)
+		return nil
+	})
+	if err != nil {
+		return uenib.UE{}, err
 	}
 	return result, nil
 }
+
+// WatchUEs sends the existing UEs and then their changes to ch; ch is closed when ctx is done
+func (c *client) WatchUEs(ctx context.Context, ch chan uenib.Event) error {
+	return c.store.Watch(ctx, ch)
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package northbound
+
+import (
+	"context"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging/service"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/drift"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"google.golang.org/grpc"
+)
+
+func NewDiagnosticsService(detector *drift.Detector, e2Manager *e2.Manager) service.Service {
+	return &DiagnosticsService{
+		detector:  detector,
+		e2Manager: e2Manager,
+	}
+}
+
+type DiagnosticsService struct {
+	detector  *drift.Detector
+	e2Manager *e2.Manager
+}
+
+func (s DiagnosticsService) Register(r *grpc.Server) {
+	server := &DiagnosticsServer{
+		detector:  s.detector,
+		e2Manager: s.e2Manager,
+	}
+	api.RegisterDiagnosticsServer(r, server)
+}
+
+type DiagnosticsServer struct {
+	detector  *drift.Detector
+	e2Manager *e2.Manager
+}
+
+func (s DiagnosticsServer) GetDriftReport(ctx context.Context, request *api.GetDriftReportRequest) (*api.GetDriftReportResponse, error) {
+	var report *api.DriftReport
+	if request.Refresh {
+		report = s.detector.Detect(ctx)
+	} else {
+		report = s.detector.LatestReport(ctx)
+	}
+
+	if request.E2NodeID == "" {
+		return &api.GetDriftReportResponse{
+			Report: report,
+		}, nil
+	}
+
+	filtered := &api.DriftReport{
+		Time:     report.Time,
+		Findings: make([]api.DriftFinding, 0),
+		Errors:   report.Errors,
+	}
+	for _, f := range report.Findings {
+		if f.E2NodeID == request.E2NodeID {
+			filtered.Findings = append(filtered.Findings, f)
+		}
+	}
+	return &api.GetDriftReportResponse{
+		Report: filtered,
+	}, nil
+}
+
+func (s DiagnosticsServer) GetSubscriptionHealth(_ context.Context, request *api.GetSubscriptionHealthRequest) (*api.GetSubscriptionHealthResponse, error) {
+	subscriptions := make([]api.SubscriptionHealth, 0)
+	for _, health := range s.e2Manager.SubscriptionHealth() {
+		if request.E2NodeID == "" || health.E2NodeID == request.E2NodeID {
+			subscriptions = append(subscriptions, health)
+		}
+	}
+	return &api.GetSubscriptionHealthResponse{
+		Subscriptions: subscriptions,
+	}, nil
+}
@@ -0,0 +1,51 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package northbound
+
+import (
+	"context"
+
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-lib-go/pkg/logging/service"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
+	"google.golang.org/grpc"
+)
+
+func NewKpiService(store *kpi.Store) service.Service {
+	return &KpiService{
+		store: store,
+	}
+}
+
+type KpiService struct {
+	store *kpi.Store
+}
+
+func (s KpiService) Register(r *grpc.Server) {
+	server := &KpiServer{
+		store: s.store,
+	}
+	api.RegisterKpisServer(r, server)
+}
+
+type KpiServer struct {
+	store *kpi.Store
+}
+
+func (s KpiServer) GetSliceKpis(_ context.Context, request *api.GetSliceKpisRequest) (*api.GetSliceKpisResponse, error) {
+	if request.E2NodeID == "" {
+		return nil, errors.NewInvalid("E2 node ID is empty")
+	}
+	if !request.From.IsZero() && !request.To.IsZero() && request.To.Before(request.From) {
+		return nil, errors.NewInvalid("window ends at %v before it starts at %v", request.To, request.From)
+	}
+
+	samples := s.store.Query(request)
+	return &api.GetSliceKpisResponse{
+		Samples: samples,
+		Summary: kpi.Summarize(samples),
+	}, nil
+}
@@ -58,7 +58,7 @@ func (s Server) CreateSlice(_ context.Context, request *rsmapi.CreateSliceReques
 	return &rsmapi.CreateSliceResponse{
 		Ack: &rsmapi.Ack{
 			Success: ack.Success,
-			Cause:   ack.Reason,
+			Cause:   ack.Cause(),
 		},
 	}, nil
 }
@@ -78,7 +78,7 @@ func (s Server) UpdateSlice(_ context.Context, request *rsmapi.UpdateSliceReques
 	return &rsmapi.UpdateSliceResponse{
 		Ack: &rsmapi.Ack{
 			Success: ack.Success,
-			Cause:   ack.Reason,
+			Cause:   ack.Cause(),
 		},
 	}, nil
 }
@@ -98,7 +98,7 @@ func (s Server) DeleteSlice(_ context.Context, request *rsmapi.DeleteSliceReques
 	return &rsmapi.DeleteSliceResponse{
 		Ack: &rsmapi.Ack{
 			Success: ack.Success,
-			Cause:   ack.Reason,
+			Cause:   ack.Cause(),
 		},
 	}, nil
 }
@@ -118,7 +118,7 @@ func (s Server) SetUeSliceAssociation(_ context.Context, request *rsmapi.SetUeSl
 	return &rsmapi.SetUeSliceAssociationResponse{
 		Ack: &rsmapi.Ack{
 			Success: ack.Success,
-			Cause:   ack.Reason,
+			Cause:   ack.Cause(),
 		},
 	}, nil
 }
@@ -0,0 +1,150 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package northbound
+
+import (
+	"context"
+	"strconv"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/logging/service"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"google.golang.org/grpc"
+)
+
+func NewSlicePairService(rnibClient rnib.TopoClient, rsmReqCh chan *RsmMsg) service.Service {
+	return &SlicePairService{
+		rnibClient: rnibClient,
+		rsmReqCh:   rsmReqCh,
+	}
+}
+
+type SlicePairService struct {
+	rnibClient rnib.TopoClient
+	rsmReqCh   chan *RsmMsg
+}
+
+func (s SlicePairService) Register(r *grpc.Server) {
+	server := &SlicePairServer{
+		rnibClient: s.rnibClient,
+		rsmReqCh:   s.rsmReqCh,
+	}
+	api.RegisterSlicePairsServer(r, server)
+}
+
+type SlicePairServer struct {
+	rnibClient rnib.TopoClient
+	rsmReqCh   chan *RsmMsg
+}
+
+func (s SlicePairServer) CreateSlicePair(_ context.Context, request *api.CreateSlicePairRequest) (*api.CreateSlicePairResponse, error) {
+	var nodeID string
+	if request.SlicePair != nil {
+		nodeID = request.SlicePair.E2NodeID
+	}
+	return &api.CreateSlicePairResponse{
+		Ack: s.send(nodeID, request),
+	}, nil
+}
+
+func (s SlicePairServer) UpdateSlicePair(_ context.Context, request *api.UpdateSlicePairRequest) (*api.UpdateSlicePairResponse, error) {
+	var nodeID string
+	if request.SlicePair != nil {
+		nodeID = request.SlicePair.E2NodeID
+	}
+	return &api.UpdateSlicePairResponse{
+		Ack: s.send(nodeID, request),
+	}, nil
+}
+
+func (s SlicePairServer) DeleteSlicePair(_ context.Context, request *api.DeleteSlicePairRequest) (*api.DeleteSlicePairResponse, error) {
+	return &api.DeleteSlicePairResponse{
+		Ack: s.send(request.E2NodeID, request),
+	}, nil
+}
+
+func (s SlicePairServer) SetUeSlicePairAssociation(_ context.Context, request *api.SetUeSlicePairAssociationRequest) (*api.SetUeSlicePairAssociationResponse, error) {
+	return &api.SetUeSlicePairAssociationResponse{
+		Ack: s.send(request.E2NodeID, request),
+	}, nil
+}
+
+func (s SlicePairServer) GetSlicePairs(ctx context.Context, request *api.GetSlicePairsRequest) (*api.GetSlicePairsResponse, error) {
+	items := make(map[string][]*topoapi.RSMSlicingItem)
+	if request.E2NodeID != "" {
+		nodeItems, err := s.rnibClient.GetRsmSliceItemAspects(ctx, topoapi.ID(request.E2NodeID))
+		if err != nil {
+			return nil, err
+		}
+		items[request.E2NodeID] = nodeItems
+	} else {
+		var err error
+		items, err = s.rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &api.GetSlicePairsResponse{
+		SlicePairs: slicePairs(items),
+	}, nil
+}
+
+// send passes the request to the slicing manager and waits for its result
+func (s SlicePairServer) send(nodeID string, request interface{}) *api.Ack {
+	ackCh := make(chan Ack)
+	msg := &RsmMsg{
+		NodeID:  topoapi.ID(nodeID),
+		Message: request,
+		AckCh:   ackCh,
+	}
+	go func(msg *RsmMsg) {
+		s.rsmReqCh <- msg
+	}(msg)
+
+	ack := <-ackCh
+	return &api.Ack{
+		Success: ack.Success,
+		Cause:   ack.Reason,
+		Control: ack.Control,
+	}
+}
+
+// slicePairs returns the slices created as slice pairs which exist in both DL and UL on the same DU
+func slicePairs(items map[string][]*topoapi.RSMSlicingItem) []*api.SlicePair {
+	pairs := make([]*api.SlicePair, 0)
+	for nodeID, nodeItems := range items {
+		dl := make(map[string]*topoapi.RSMSlicingItem)
+		for _, item := range nodeItems {
+			if item.GetSliceType() == topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE && rnib.IsSlicePairItem(item) {
+				dl[item.GetID()] = item
+			}
+		}
+		for _, ul := range nodeItems {
+			if ul.GetSliceType() != topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE || !rnib.IsSlicePairItem(ul) {
+				continue
+			}
+			dlItem, ok := dl[ul.GetID()]
+			if !ok {
+				continue
+			}
+			pairs = append(pairs, &api.SlicePair{
+				E2NodeID: nodeID,
+				ID:       ul.GetID(),
+				Dl:       sliceParameters(dlItem),
+				Ul:       sliceParameters(ul),
+			})
+		}
+	}
+	return pairs
+}
+
+func sliceParameters(item *topoapi.RSMSlicingItem) api.SliceParameters {
+	return api.SliceParameters{
+		SchedulerType: rsmapi.SchedulerType(item.GetSliceParameters().GetSchedulerType()),
+		Weight:        strconv.Itoa(int(item.GetSliceParameters().GetWeight())),
+	}
+}
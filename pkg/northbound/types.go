@@ -4,11 +4,30 @@
 
 package northbound
 
-import topoapi "github.com/onosproject/onos-api/go/onos/topo"
+import (
+	"fmt"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-rsm/api"
+)
 
 type Ack struct {
 	Success bool
 	Reason  string
+	// Control is the outcome of the E2 control message which decided the result, if any
+	Control *api.ControlOutcome
+}
+
+// Cause returns the reason with the outcome of a failed E2 control, for the responses which only have a cause text
+func (a Ack) Cause() string {
+	if a.Control == nil || a.Control.Outcome == "success" {
+		return a.Reason
+	}
+	cause := a.Control.Outcome
+	if a.Control.Cause != "" {
+		cause = fmt.Sprintf("%v, cause %v/%v", cause, a.Control.CauseGroup, a.Control.Cause)
+	}
+	return fmt.Sprintf("%v [E2 control %v, retryable %v, retries %d]", a.Reason, cause, a.Control.Retryable, a.Control.Retries)
 }
 
 type RsmMsg struct {
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package recorder
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+)
+
+var log = logging.GetLogger()
+
+// Kind is the kind of a recorded message
+type Kind string
+
+const (
+	// KindControl is a control request sent to an E2 node with its outcome
+	KindControl Kind = "control"
+	// KindIndication is an indication received from an E2 node
+	KindIndication Kind = "indication"
+)
+
+// Record is a message exchanged with an E2 node
+type Record struct {
+	Kind                Kind      `json:"kind"`
+	Timestamp           time.Time `json:"timestamp"`
+	NodeID              string    `json:"nodeId"`
+	ServiceModelVersion string    `json:"serviceModelVersion,omitempty"`
+	Subscription        string    `json:"subscription,omitempty"`
+	Header              []byte    `json:"header"`
+	Payload             []byte    `json:"payload"`
+	// OutcomePayload and Error are the response of the E2 node to a control request
+	OutcomePayload []byte `json:"outcomePayload,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+// NewRecorder creates a recorder writing JSON lines to the file; when the file reaches maxSize bytes,
+// it is rotated to path.1, path.1 to path.2 and so on, and the files after maxFiles are deleted
+func NewRecorder(path string, maxSize int64, maxFiles int) (*Recorder, error) {
+	if maxFiles < 1 {
+		maxFiles = 1
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+	r := &Recorder{
+		path:     path,
+		maxSize:  maxSize,
+		maxFiles: maxFiles,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Recorder writes the messages exchanged with the E2 nodes to a rotating file; a nil recorder records nothing
+type Recorder struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	file     *os.File
+	size     int64
+}
+
+// Record writes the record; the failures are logged, so that recording never fails the E2 traffic
+func (r *Recorder) Record(record Record) {
+	if r == nil {
+		return
+	}
+	if record.Timestamp.IsZero() {
+		record.Timestamp = time.Now()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		log.Warnf("Failed to encode record: %v", err)
+		return
+	}
+	line = append(line, '\n')
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return
+	}
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(line)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			log.Warnf("Failed to rotate recording %v: %v", r.path, err)
+			return
+		}
+	}
+	n, err := r.file.Write(line)
+	r.size += int64(n)
+	if err != nil {
+		log.Warnf("Failed to write recording %v: %v", r.path, err)
+	}
+}
+
+// Close closes the recording file
+func (r *Recorder) Close() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
+
+func (r *Recorder) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+func (r *Recorder) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	r.file = nil
+	_ = os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxFiles))
+	for i := r.maxFiles - 1; i >= 1; i-- {
+		_ = os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Read calls f with each record of the recording file, in order
+func Read(path string, f func(record Record) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("invalid record at %v:%d: %v", path, line, err)
+		}
+		if err := f(record); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
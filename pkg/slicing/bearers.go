@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+// The DrbId of SetUeSliceAssociationRequest is either a single DRB ID or one of the following bearer selectors
+const (
+	// bearerSelectorAll selects all bearers of the UE
+	bearerSelectorAll = "all"
+	// bearerSelectorAny is the short form of bearerSelectorAll
+	bearerSelectorAny = "*"
+	// bearerSelectorFiveQi selects the 5G bearers which carry a QoS flow with one of the 5QIs, e.g. "5qi:1,9"
+	bearerSelectorFiveQi = "5qi:"
+	// bearerSelectorQci selects the 4G bearers with one of the QCIs, e.g. "qci:1,9"
+	bearerSelectorQci = "qci:"
+)
+
+// bearerSelector selects bearers of a UE
+type bearerSelector struct {
+	all    bool
+	drbIDs map[int32]bool
+	fiveQi map[int32]bool
+	qci    map[int32]bool
+}
+
+// isBearerSelector returns true if the DRB ID of an association request selects possibly more than one bearer
+func isBearerSelector(drbID string) bool {
+	drbID = strings.TrimSpace(strings.ToLower(drbID))
+	return drbID == bearerSelectorAll || drbID == bearerSelectorAny ||
+		strings.HasPrefix(drbID, bearerSelectorFiveQi) || strings.HasPrefix(drbID, bearerSelectorQci) ||
+		strings.Contains(drbID, ",")
+}
+
+func parseBearerSelector(drbID string) (*bearerSelector, error) {
+	drbID = strings.TrimSpace(strings.ToLower(drbID))
+	selector := &bearerSelector{}
+	var values map[int32]bool
+	var list string
+	switch {
+	case drbID == bearerSelectorAll || drbID == bearerSelectorAny:
+		selector.all = true
+		return selector, nil
+	case strings.HasPrefix(drbID, bearerSelectorFiveQi):
+		selector.fiveQi = make(map[int32]bool)
+		values, list = selector.fiveQi, strings.TrimPrefix(drbID, bearerSelectorFiveQi)
+	case strings.HasPrefix(drbID, bearerSelectorQci):
+		selector.qci = make(map[int32]bool)
+		values, list = selector.qci, strings.TrimPrefix(drbID, bearerSelectorQci)
+	default:
+		selector.drbIDs = make(map[int32]bool)
+		values, list = selector.drbIDs, drbID
+	}
+	for _, v := range strings.Split(list, ",") {
+		value, err := strconv.ParseInt(strings.TrimSpace(v), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bearer selector %v: %v", drbID, err)
+		}
+		values[int32(value)] = true
+	}
+	return selector, nil
+}
+
+// matches returns true if the UE bearer is selected
+func (s *bearerSelector) matches(bID *uenib_api.BearerId) bool {
+	if bID.GetDrbId() == nil {
+		return false
+	}
+	switch {
+	case s.all:
+		return true
+	case s.drbIDs != nil:
+		return s.drbIDs[uenib.DrbValue(bID.GetDrbId())]
+	case s.fiveQi != nil:
+		for _, flow := range bID.GetDrbId().GetFiveGdrbId().GetFlowsMapToDrb() {
+			if flow.GetNonDynamicFiveQi() != nil && s.fiveQi[flow.GetNonDynamicFiveQi().GetFiveQi().GetValue()] {
+				return true
+			}
+		}
+	case s.qci != nil:
+		fourG := bID.GetDrbId().GetFourGdrbId()
+		return fourG != nil && fourG.GetQci() != nil && s.qci[fourG.GetQci().GetValue()]
+	}
+	return false
+}
+
+// setUeSliceAssociationForBearers associates all bearers of the UE picked by the bearer selector with the slices.
+// The bearers are sent in a single control message; if the DU rejects it,
+// a control message is sent for each bearer and the DU is not sent multi-bearer messages until it reconnects.
+func (m *Manager) setUeSliceAssociationForBearers(ctx context.Context, req *rsmapi.SetUeSliceAssociationRequest, nodeID topoapi.ID) error {
+	selector, err := parseBearerSelector(req.GetDrbId())
+	if err != nil {
+		return err
+	}
+
+	duNodeID := req.GetE2NodeId()
+	cuNodeID, err := m.rnibClient.GetSourceCUE2NodeID(ctx, topoapi.ID(duNodeID))
+	if err != nil {
+		return fmt.Errorf("DU %v does not have CU in onos-topo (RNIB) - please add or update CU-DU relation: %v", duNodeID, err)
+	}
+	var duUeF1apID int64
+	hasValidUeID := false
+	for _, ueID := range req.GetUeId() {
+		if ueID.GetType() == rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID {
+			duUeF1apID, err = strconv.ParseInt(ueID.GetUeId(), 10, 64)
+			if err != nil {
+				return fmt.Errorf("failed to convert ue id to int - %v", err)
+			}
+			hasValidUeID = true
+		}
+	}
+	if !hasValidUeID {
+		return fmt.Errorf("need valid du-ue-f1ap-id")
+	}
+	rsmUEInfo, err := m.uenibClient.GetUEWithPreferredID(ctx, string(cuNodeID), uenib_api.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, duUeF1apID)
+	if err != nil {
+		return fmt.Errorf("failed to get UENIB UE info (CuID %v DUID %v UEID %v): err: %v", cuNodeID, duNodeID, duUeF1apID, err)
+	}
+
+	drbIDs := make([]int32, 0)
+	bearerIDs := make([]*e2sm_rsm.BearerId, 0)
+	for _, bID := range rsmUEInfo.GetBearerIdList() {
+		if selector.matches(bID) {
+			drbIDs = append(drbIDs, uenib.DrbValue(bID.GetDrbId()))
+			bearerIDs = append(bearerIDs, bearerIDFromUenib(bID.GetDrbId()))
+		}
+	}
+	if len(drbIDs) == 0 {
+		return errors.NewNotFound(fmt.Sprintf("UE %v has no bearer matching %v", rsmUEInfo.GetGlobalUeID(), req.GetDrbId()))
+	}
+
+	bearerReq := func(drbID int32) *rsmapi.SetUeSliceAssociationRequest {
+		r := *req
+		r.DrbId = strconv.Itoa(int(drbID))
+		return &r
+	}
+	if len(drbIDs) == 1 {
+		return m.setUeSliceAssociation(ctx, bearerReq(drbIDs[0]), nodeID, true)
+	}
+
+	sentAll := false
+	rejected := false
+	if !m.multiBearerUnsupported[nodeID] {
+		err = m.sendMultiBearerAssociation(ctx, req, nodeID, duUeF1apID, bearerIDs)
+		switch {
+		case err == nil:
+			sentAll = true
+		case isControlRejected(err):
+			log.Warnf("DU %v rejected associating %d bearers in one control message, sending one per bearer: %v", nodeID, len(drbIDs), err)
+			rejected = true
+		default:
+			// the DU did not get the message or did not answer in time, which says nothing about multi-bearer support
+			return err
+		}
+	}
+
+	failures := make([]string, 0)
+	succeeded := 0
+	for _, drbID := range drbIDs {
+		err = m.setUeSliceAssociation(ctx, bearerReq(drbID), nodeID, !sentAll)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("DRB %v: %v", drbID, err))
+			continue
+		}
+		succeeded++
+	}
+	if rejected && succeeded > 0 {
+		m.multiBearerUnsupported[nodeID] = true
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to associate %d of %d bearers: %v", len(failures), len(drbIDs), strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// sendMultiBearerAssociation sends a single UE_ASSOCIATE control message with all bearers
+func (m *Manager) sendMultiBearerAssociation(ctx context.Context, req *rsmapi.SetUeSliceAssociationRequest, nodeID topoapi.ID, duUeF1apID int64, bearerIDs []*e2sm_rsm.BearerId) error {
+	hasDlSliceItem := req.GetDlSliceId() != "" && m.slices.HasRsmSliceItemAspect(ctx, nodeID, req.GetDlSliceId(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
+	hasUlSliceItem := req.GetUlSliceId() != "" && m.slices.HasRsmSliceItemAspect(ctx, nodeID, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
+	if !hasDlSliceItem && !hasUlSliceItem {
+		return fmt.Errorf("invalid slice ID")
+	}
+
+	sliceAssoc := &e2sm_rsm.SliceAssociate{
+		DownLinkSliceId: &e2sm_rsm.SliceIdassoc{},
+		UeId: &e2sm_rsm.UeIdentity{
+			UeIdentity: &e2sm_rsm.UeIdentity_DuUeF1ApId{
+				DuUeF1ApId: &e2sm_rsm.DuUeF1ApId{
+					Value: duUeF1apID,
+				},
+			},
+		},
+		BearerId: bearerIDs,
+	}
+	if req.GetDlSliceId() != "" {
+		dlSliceID, err := strconv.Atoi(req.GetDlSliceId())
+		if err != nil {
+			return fmt.Errorf("failed to convert slice id to int: %v", err)
+		}
+		sliceAssoc.DownLinkSliceId.Value = int64(dlSliceID)
+	}
+	if req.GetUlSliceId() != "" {
+		ulSliceID, err := strconv.Atoi(req.GetUlSliceId())
+		if err != nil {
+			return fmt.Errorf("failed to convert slice id to int - %v", err)
+		}
+		sliceAssoc.UplinkSliceId = &e2sm_rsm.SliceIdassoc{
+			Value: int64(ulSliceID),
+		}
+	}
+
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE, nil, sliceAssoc)
+	if err != nil {
+		return fmt.Errorf("failed to create the control message - %v", err)
+	}
+	return m.sendCtrlMsg(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE, nodeID, ctrlMsg)
+}
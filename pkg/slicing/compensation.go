@@ -0,0 +1,413 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+const (
+	journalRetryInterval = 5 * time.Second
+	maxRecoveries        = 100
+)
+
+// CompensationPolicy defines how a NIB write failure after a successful E2 control is compensated
+type CompensationPolicy string
+
+const (
+	// CompensationPolicyRetry records the failed NIB write in the local journal and retries it until it succeeds
+	CompensationPolicyRetry CompensationPolicy = "retry"
+	// CompensationPolicyRollback sends the inverse control message to the E2 node;
+	// if there is no inverse or the rollback fails, the NIB write is journaled instead
+	CompensationPolicyRollback CompensationPolicy = "rollback"
+)
+
+// RecoveryAction is the compensating action taken for a failed NIB write
+type RecoveryAction string
+
+const (
+	// RecoveryJournaled means that the NIB write was recorded in the journal to be retried
+	RecoveryJournaled RecoveryAction = "journaled"
+	// RecoveryReplayed means that a journaled NIB write was applied successfully
+	RecoveryReplayed RecoveryAction = "replayed"
+	// RecoveryRolledBack means that the inverse control message was acknowledged by the E2 node
+	RecoveryRolledBack RecoveryAction = "rolled-back"
+	// RecoveryFailed means that neither the rollback nor the journal could be used
+	RecoveryFailed RecoveryAction = "failed"
+)
+
+// Recovery is a report of a compensating action
+type Recovery struct {
+	Time      time.Time
+	NodeID    topoapi.ID
+	Write     string
+	Action    RecoveryAction
+	JournalID uint64
+	Cause     string
+}
+
+func (r Recovery) String() string {
+	if r.JournalID != 0 {
+		return fmt.Sprintf("%s of %s on node %v (journal entry %d): %s", r.Action, r.Write, r.NodeID, r.JournalID, r.Cause)
+	}
+	return fmt.Sprintf("%s of %s on node %v: %s", r.Action, r.Write, r.NodeID, r.Cause)
+}
+
+// nibWrite is a NIB write which is done after the E2 node acknowledged a control message;
+// item is the change of a slice item of an onos-topo write and ue is the change of a UE-NIB write
+type nibWrite struct {
+	kind   nibWriteKind
+	nodeID topoapi.ID
+	item   *sliceItemDelta
+	ue     *ueSliceDelta
+}
+
+// inverseControl is the control message undoing a control message already acknowledged by the E2 node;
+// undo has the NIB writes restoring the NIBs for the NIB writes done after the control message
+type inverseControl struct {
+	command e2sm_rsm.E2SmRsmCommand
+	nodeID  topoapi.ID
+	ctrlMsg *e2api.ControlMessage
+	undo    []nibWrite
+}
+
+func newCompensator(policy CompensationPolicy, journalPath string, rnibClient rnib.TopoClient, uenibClient uenib.Client) *compensator {
+	if policy == "" {
+		policy = CompensationPolicyRetry
+	}
+	j, err := newJournal(journalPath)
+	if err != nil {
+		log.Warnf("failed to load journal %v - journaled NIB writes will not be durable: %v", journalPath, err)
+		j, _ = newJournal("")
+	}
+	return &compensator{
+		policy:      policy,
+		journal:     j,
+		rnibClient:  rnibClient,
+		uenibClient: uenibClient,
+		recoveries:  make([]Recovery, 0),
+		pending:     make([]Recovery, 0),
+	}
+}
+
+type compensator struct {
+	policy      CompensationPolicy
+	journal     *journal
+	rnibClient  rnib.TopoClient
+	uenibClient uenib.Client
+	mu          sync.Mutex
+	recoveries  []Recovery
+	pending     []Recovery
+}
+
+// Recoveries returns the most recent compensating actions
+func (m *Manager) Recoveries() []Recovery {
+	m.compensator.mu.Lock()
+	defer m.compensator.mu.Unlock()
+	result := make([]Recovery, len(m.compensator.recoveries))
+	copy(result, m.compensator.recoveries)
+	return result
+}
+
+// compensate is called when a NIB write fails although the E2 node acknowledged the control message.
+// It returns nil if the write was journaled and the caller can continue, otherwise an error describing the recovery.
+// The outcome of the inverse control message is only reported as a recovery: the control outcome in the ACK of
+// the northbound request stays the one of the control message which was rolled back.
+func (m *Manager) compensate(ctx context.Context, write nibWrite, inverse *inverseControl, cause error) error {
+	log.Warnf("NIB write %v on node %v failed after the control message was acknowledged: %v", write.kind, write.nodeID, cause)
+	if m.compensator.policy == CompensationPolicyRollback && inverse != nil {
+		err := m.sendControl(ctx, inverse.command, inverse.nodeID, inverse.ctrlMsg, false)
+		if err == nil {
+			m.revert(ctx, inverse)
+			m.compensator.report(Recovery{
+				NodeID: inverse.nodeID,
+				Write:  string(write.kind),
+				Action: RecoveryRolledBack,
+				Cause:  cause.Error(),
+			}, true)
+			return fmt.Errorf("%v - control message was rolled back on node %v", cause, inverse.nodeID)
+		}
+		log.Warnf("failed to roll back control message on node %v, journaling NIB write instead: %v", inverse.nodeID, err)
+	}
+
+	id, err := m.compensator.record(write, cause)
+	if err != nil {
+		m.compensator.report(Recovery{
+			NodeID: write.nodeID,
+			Write:  string(write.kind),
+			Action: RecoveryFailed,
+			Cause:  err.Error(),
+		}, true)
+		return fmt.Errorf("%v - failed to journal NIB write: %v", cause, err)
+	}
+	m.compensator.report(Recovery{
+		NodeID:    write.nodeID,
+		Write:     string(write.kind),
+		Action:    RecoveryJournaled,
+		JournalID: id,
+		Cause:     cause.Error(),
+	}, true)
+	return nil
+}
+
+// writeNIB does a NIB write after the E2 node acknowledged the control message; a failed write is compensated.
+// If the write succeeds, its undo is kept with the inverse control message to restore the NIB when it is rolled back;
+// the undo is built from the slice item or the UE as it was before the write.
+func (m *Manager) writeNIB(ctx context.Context, write nibWrite, inverse *inverseControl, cause string) error {
+	undo, err := m.compensator.write(ctx, write)
+	if err != nil {
+		return m.compensate(ctx, write, inverse, fmt.Errorf("%s: %v", cause, err))
+	}
+	if inverse != nil && undo != nil {
+		inverse.undo = append(inverse.undo, *undo)
+	}
+	return nil
+}
+
+// revert restores the NIBs after the inverse control message was acknowledged;
+// an undo write which fails is journaled
+func (m *Manager) revert(ctx context.Context, inverse *inverseControl) {
+	for i := len(inverse.undo) - 1; i >= 0; i-- {
+		undo := inverse.undo[i]
+		_, err := m.compensator.write(ctx, undo)
+		if err == nil {
+			continue
+		}
+		log.Warnf("failed to restore %v on node %v after the rollback: %v", undo.kind, undo.nodeID, err)
+		id, jErr := m.compensator.record(undo, err)
+		if jErr != nil {
+			m.compensator.report(Recovery{
+				NodeID: undo.nodeID,
+				Write:  string(undo.kind),
+				Action: RecoveryFailed,
+				Cause:  jErr.Error(),
+			}, true)
+			continue
+		}
+		m.compensator.report(Recovery{
+			NodeID:    undo.nodeID,
+			Write:     string(undo.kind),
+			Action:    RecoveryJournaled,
+			JournalID: id,
+			Cause:     err.Error(),
+		}, true)
+	}
+	inverse.undo = nil
+}
+
+// record appends the change of the NIB write to the journal
+func (c *compensator) record(write nibWrite, cause error) (uint64, error) {
+	var payload []byte
+	var err error
+	if write.ue != nil {
+		payload, err = json.Marshal(write.ue)
+	} else {
+		payload, err = json.Marshal(write.item)
+	}
+	if err != nil {
+		return 0, err
+	}
+	return c.journal.append(write.kind, string(write.nodeID), payload, cause)
+}
+
+// write does the NIB write and returns the write undoing it, or nil if the write did not change the NIB
+func (c *compensator) write(ctx context.Context, write nibWrite) (*nibWrite, error) {
+	switch write.kind {
+	case nibWriteAddSliceItem, nibWriteUpdateSliceItem, nibWriteDeleteSliceItem:
+		return c.updateSliceItem(ctx, write)
+	case nibWriteUpdateUESlices:
+		return c.updateUESlices(ctx, write)
+	default:
+		return nil, errors.NewNotSupported(fmt.Sprintf("unknown NIB write kind %v", write.kind))
+	}
+}
+
+// updateSliceItem applies the change to the slice item as it is stored in onos-topo;
+// the slice item is added or deleted if the change requires it
+func (c *compensator) updateSliceItem(ctx context.Context, write nibWrite) (*nibWrite, error) {
+	item, err := c.rnibClient.GetRsmSliceItemAspect(ctx, write.nodeID, write.item.ID, rsmapi.SliceType(write.item.SliceType))
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, err
+		}
+		item = nil
+	}
+
+	var undo *nibWrite
+	if inverse := write.item.inverse(item); inverse != nil {
+		kind := nibWriteUpdateSliceItem
+		if item == nil {
+			kind = nibWriteDeleteSliceItem
+		} else if write.item.Delete {
+			kind = nibWriteAddSliceItem
+		}
+		undo = &nibWrite{
+			kind:   kind,
+			nodeID: write.nodeID,
+			item:   inverse,
+		}
+	}
+
+	var before *topoapi.RSMSlicingItem
+	if item != nil {
+		before = proto.Clone(item).(*topoapi.RSMSlicingItem)
+	}
+	after := write.item.apply(item)
+	switch {
+	case after == nil && before == nil:
+		return nil, nil
+	case after == nil:
+		err = c.rnibClient.DeleteRsmSliceItemAspect(ctx, write.nodeID, write.item.ID)
+		if errors.IsNotFound(err) {
+			err = nil
+		}
+	case before == nil:
+		err = c.rnibClient.AddRsmSliceItemAspect(ctx, write.nodeID, after)
+	default:
+		err = c.rnibClient.UpdateRsmSliceItemAspect(ctx, write.nodeID, after)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return undo, nil
+}
+
+// updateUESlices applies the change to the UE as it is stored in UE-NIB
+func (c *compensator) updateUESlices(ctx context.Context, write nibWrite) (*nibWrite, error) {
+	ue, err := c.uenibClient.GetUEWithGlobalID(ctx, write.ue.GlobalUeID)
+	if err != nil {
+		return nil, err
+	}
+	undo := &nibWrite{
+		kind:   nibWriteUpdateUESlices,
+		nodeID: write.nodeID,
+		ue:     write.ue.inverse(ue),
+	}
+	write.ue.apply(ue)
+	err = c.uenibClient.UpdateUE(ctx, ue)
+	if err != nil {
+		return nil, err
+	}
+	return undo, nil
+}
+
+// report logs the recovery and keeps it in the recovery history;
+// if inRequest is set, it is also reported in the ACK of the northbound request being handled
+func (c *compensator) report(r Recovery, inRequest bool) {
+	r.Time = time.Now()
+	log.Infof("Recovery: %v", r)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recoveries = append(c.recoveries, r)
+	if len(c.recoveries) > maxRecoveries {
+		c.recoveries = c.recoveries[len(c.recoveries)-maxRecoveries:]
+	}
+	if inRequest {
+		c.pending = append(c.pending, r)
+	}
+}
+
+// begin resets the recoveries reported for the northbound request being handled
+func (c *compensator) begin() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending = make([]Recovery, 0)
+}
+
+// end returns the recoveries reported for the northbound request being handled
+func (c *compensator) end() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	reports := make([]string, 0, len(c.pending))
+	for _, r := range c.pending {
+		reports = append(reports, r.String())
+	}
+	c.pending = make([]Recovery, 0)
+	return strings.Join(reports, "; ")
+}
+
+// replay applies the journaled NIB writes in order; once a write for a node fails,
+// the following writes for the same node are kept for the next attempt.
+// It is called by the dispatcher, so that the writes are serialized with the writes of the requests.
+func (c *compensator) replay(ctx context.Context) {
+	blocked := make(map[string]bool)
+	for _, entry := range c.journal.pending() {
+		if blocked[entry.NodeID] {
+			continue
+		}
+		err := c.apply(ctx, entry)
+		if err != nil {
+			log.Debugf("failed to replay journal entry %d (%v on node %v): %v", entry.ID, entry.Kind, entry.NodeID, err)
+			blocked[entry.NodeID] = true
+			if jErr := c.journal.failed(entry.ID, err); jErr != nil {
+				log.Warn(jErr)
+			}
+			continue
+		}
+		if err := c.journal.remove(entry.ID); err != nil {
+			log.Warn(err)
+		}
+		c.report(Recovery{
+			NodeID:    topoapi.ID(entry.NodeID),
+			Write:     string(entry.Kind),
+			Action:    RecoveryReplayed,
+			JournalID: entry.ID,
+			Cause:     entry.LastError,
+		}, false)
+	}
+}
+
+func (c *compensator) apply(ctx context.Context, entry journalEntry) error {
+	write := nibWrite{
+		kind:   entry.Kind,
+		nodeID: topoapi.ID(entry.NodeID),
+	}
+	switch entry.Kind {
+	case nibWriteAddSliceItem, nibWriteUpdateSliceItem, nibWriteDeleteSliceItem:
+		write.item = &sliceItemDelta{}
+		err := json.Unmarshal(entry.Payload, write.item)
+		if err != nil {
+			return err
+		}
+	case nibWriteUpdateUESlices:
+		write.ue = &ueSliceDelta{}
+		err := json.Unmarshal(entry.Payload, write.ue)
+		if err != nil {
+			return err
+		}
+	default:
+		return errors.NewNotSupported(fmt.Sprintf("unknown journal entry kind %v", entry.Kind))
+	}
+	_, err := c.write(ctx, write)
+	return err
+}
+
+// newInverseControl builds the inverse control message; it returns nil if the message cannot be built
+func (m *Manager) newInverseControl(nodeID topoapi.ID, cmdType e2sm_rsm.E2SmRsmCommand, sliceConfig *e2sm_rsm.SliceConfig, sliceAssoc *e2sm_rsm.SliceAssociate) *inverseControl {
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, cmdType, sliceConfig, sliceAssoc)
+	if err != nil {
+		log.Warnf("failed to create the inverse control message %v for node %v: %v", cmdType, nodeID, err)
+		return nil
+	}
+	return &inverseControl{
+		command: cmdType,
+		nodeID:  nodeID,
+		ctrlMsg: ctrlMsg,
+	}
+}
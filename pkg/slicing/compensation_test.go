@@ -0,0 +1,330 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/gogo/protobuf/proto"
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/onosproject/onos-rsm/pkg/northbound"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDUNodeID = topoapi.ID("e2:4/e00/3/c8")
+
+// failingTopo fails the slice item writes to onos-topo while fail is set
+type failingTopo struct {
+	rnib.TopoClient
+	mu   sync.Mutex
+	fail bool
+}
+
+func (f *failingTopo) setFail(fail bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fail = fail
+}
+
+func (f *failingTopo) err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		return errors.NewUnavailable("onos-topo is unavailable")
+	}
+	return nil
+}
+
+func (f *failingTopo) AddRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, msg *topoapi.RSMSlicingItem) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+	return f.TopoClient.AddRsmSliceItemAspect(ctx, nodeID, msg)
+}
+
+func (f *failingTopo) UpdateRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, msg *topoapi.RSMSlicingItem) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+	return f.TopoClient.UpdateRsmSliceItemAspect(ctx, nodeID, msg)
+}
+
+func (f *failingTopo) DeleteRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, sliceID string) error {
+	if err := f.err(); err != nil {
+		return err
+	}
+	return f.TopoClient.DeleteRsmSliceItemAspect(ctx, nodeID, sliceID)
+}
+
+// testNode acknowledges the control messages sent to the DU, except the ones it is told to reject
+type testNode struct {
+	mu       sync.Mutex
+	commands int
+	reject   map[int]bool
+}
+
+// handle rejects the n-th control message if reject[n] is set
+func (n *testNode) handle(_ context.Context, _ topoapi.ID, msg *e2.CtrlMsg) {
+	n.mu.Lock()
+	n.commands++
+	reject := n.reject[n.commands]
+	n.mu.Unlock()
+	if reject {
+		msg.AckCh <- e2.NewAck(nil, errors.NewInvalid("control message rejected"))
+		return
+	}
+	msg.AckCh <- e2.NewAck(&e2api.ControlOutcome{}, nil)
+}
+
+type slicingTest struct {
+	manager     *Manager
+	store       *rnib.MemoryStore
+	topo        *failingTopo
+	uenibClient uenib.Client
+	node        *testNode
+	journalPath string
+}
+
+// newSlicingTest creates a slicing manager for a connected DU; the dispatcher is not run, so that the test
+// calls the handlers directly
+func newSlicingTest(ctx context.Context, t *testing.T, policy CompensationPolicy, opts ...Option) *slicingTest {
+	store, err := rnib.NewMemoryStore("")
+	require.NoError(t, err)
+	require.NoError(t, store.Create(ctx, &topoapi.Object{
+		ID:   testDUNodeID,
+		Type: topoapi.Object_ENTITY,
+		Obj:  &topoapi.Object_Entity{Entity: &topoapi.Entity{KindID: topoapi.E2NODE}},
+	}))
+	topo := &failingTopo{TopoClient: rnib.NewMemoryClient(context.Background(), store)}
+	uenibStore, err := uenib.NewMemoryStore("")
+	require.NoError(t, err)
+	uenibClient := uenib.NewMemoryClient(uenibStore)
+
+	node := &testNode{reject: make(map[int]bool)}
+	dispatcher := e2.NewControlDispatcher()
+	dispatcher.Register(ctx, testDUNodeID, e2.NewV1Codec(), []e2sm_rsm.E2SmRsmCommand{
+		e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE,
+		e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE,
+		e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE,
+		e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE,
+	}, node.handle)
+
+	journalPath := filepath.Join(t.TempDir(), "journal.json")
+	manager := NewManager(append([]Option{
+		WithControlDispatcher(dispatcher),
+		WithRnibClient(topo),
+		WithUenibClient(uenibClient),
+		WithAckTimer(5),
+		WithCompensation(policy, journalPath)}, opts...)...)
+	return &slicingTest{
+		manager:     &manager,
+		store:       store,
+		topo:        topo,
+		uenibClient: uenibClient,
+		node:        node,
+		journalPath: journalPath,
+	}
+}
+
+// request handles the NBI request like the dispatcher and returns its ack
+func (s *slicingTest) request(ctx context.Context, message interface{}) northbound.Ack {
+	ackCh := make(chan northbound.Ack, 1)
+	s.manager.handleNbiMsg(ctx, &northbound.RsmMsg{NodeID: testDUNodeID, Message: message, AckCh: ackCh})
+	return <-ackCh
+}
+
+func createSliceRequest(sliceID string) *rsmapi.CreateSliceRequest {
+	return &rsmapi.CreateSliceRequest{
+		E2NodeId:      string(testDUNodeID),
+		SliceId:       sliceID,
+		SchedulerType: rsmapi.SchedulerType_SCHEDULER_TYPE_ROUND_ROBIN,
+		Weight:        "30",
+		SliceType:     rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+	}
+}
+
+func topoUE(duUeF1apID int64, drbID int32) *topoapi.UeIdentity {
+	return &topoapi.UeIdentity{
+		DuUeF1apID: &topoapi.DuUeF1ApID{Value: duUeF1apID},
+		DrbId:      &topoapi.DrbId{DrbId: &topoapi.DrbId_FiveGdrbId{FiveGdrbId: &topoapi.FiveGDrbId{Value: drbID}}},
+	}
+}
+
+func (s *slicingTest) sliceUEs(ctx context.Context, t *testing.T, sliceID string) []int64 {
+	item, err := s.topo.GetRsmSliceItemAspect(ctx, testDUNodeID, sliceID, rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
+	require.NoError(t, err)
+	ues := make([]int64, 0)
+	for _, ueID := range item.GetUeIdList() {
+		ues = append(ues, ueID.GetDuUeF1apID().GetValue())
+	}
+	return ues
+}
+
+func lastRecovery(t *testing.T, m *Manager) Recovery {
+	recoveries := m.Recoveries()
+	require.NotEmpty(t, recoveries)
+	return recoveries[len(recoveries)-1]
+}
+
+func TestCreateSliceJournaledAndReplayed(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+
+	// the slice is created on the DU, but not in onos-topo
+	s.topo.setFail(true)
+	ack := s.request(ctx, createSliceRequest("1"))
+	assert.True(t, ack.Success)
+	assert.Contains(t, ack.Reason, string(RecoveryJournaled))
+	assert.False(t, s.topo.HasRsmSliceItemAspect(ctx, testDUNodeID, "1", rsmapi.SliceType_SLICE_TYPE_DL_SLICE))
+	require.Len(t, s.manager.compensator.journal.pending(), 1)
+
+	// a replay which fails keeps the entry
+	s.manager.compensator.replay(ctx)
+	pending := s.manager.compensator.journal.pending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+
+	s.topo.setFail(false)
+	s.manager.compensator.replay(ctx)
+	assert.Empty(t, s.manager.compensator.journal.pending())
+	item, err := s.topo.GetRsmSliceItemAspect(ctx, testDUNodeID, "1", rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
+	require.NoError(t, err)
+	assert.Equal(t, int32(30), item.GetSliceParameters().GetWeight())
+	assert.Equal(t, RecoveryReplayed, lastRecovery(t, s.manager).Action)
+}
+
+func TestJournaledDeltaKeepsNewerAssociations(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	require.True(t, s.request(ctx, createSliceRequest("1")).Success)
+	require.NoError(t, s.manager.writeNIB(ctx, nibWrite{
+		kind:   nibWriteUpdateSliceItem,
+		nodeID: testDUNodeID,
+		item:   &sliceItemDelta{ID: "1", SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE, AddUEs: []*topoapi.UeIdentity{topoUE(1, 5)}},
+	}, nil, "test"))
+
+	// the association of UE 2 is journaled, and UE 3 is associated before the journal is replayed
+	s.topo.setFail(true)
+	require.NoError(t, s.manager.writeNIB(ctx, nibWrite{
+		kind:   nibWriteUpdateSliceItem,
+		nodeID: testDUNodeID,
+		item:   &sliceItemDelta{ID: "1", SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE, AddUEs: []*topoapi.UeIdentity{topoUE(2, 5)}},
+	}, nil, "test"))
+	s.topo.setFail(false)
+	require.NoError(t, s.manager.writeNIB(ctx, nibWrite{
+		kind:   nibWriteUpdateSliceItem,
+		nodeID: testDUNodeID,
+		item:   &sliceItemDelta{ID: "1", SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE, AddUEs: []*topoapi.UeIdentity{topoUE(3, 5)}},
+	}, nil, "test"))
+
+	// the journal is replayed after a restart from its file
+	c := newCompensator(CompensationPolicyRetry, s.journalPath, s.topo, s.uenibClient)
+	require.Len(t, c.journal.pending(), 1)
+	c.replay(ctx)
+	assert.Empty(t, c.journal.pending())
+	assert.Equal(t, []int64{1, 3, 2}, s.sliceUEs(ctx, t, "1"))
+}
+
+func TestJournalReplaysNodeInOrder(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	require.True(t, s.request(ctx, createSliceRequest("1")).Success)
+
+	s.topo.setFail(true)
+	for _, delta := range []*sliceItemDelta{
+		{ID: "1", SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE, AddUEs: []*topoapi.UeIdentity{topoUE(1, 5)}},
+		{ID: "1", SliceType: topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE, RemoveUEs: []ueBearerKey{{DuUeF1apID: 1}}, AddUEs: []*topoapi.UeIdentity{topoUE(2, 5)}},
+	} {
+		require.NoError(t, s.manager.writeNIB(ctx, nibWrite{kind: nibWriteUpdateSliceItem, nodeID: testDUNodeID, item: delta}, nil, "test"))
+	}
+
+	// once the first write of the node fails, the next one is not attempted
+	s.manager.compensator.replay(ctx)
+	pending := s.manager.compensator.journal.pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, 0, pending[1].Attempts)
+
+	s.topo.setFail(false)
+	s.manager.compensator.replay(ctx)
+	assert.Empty(t, s.manager.compensator.journal.pending())
+	assert.Equal(t, []int64{2}, s.sliceUEs(ctx, t, "1"))
+}
+
+func TestRollbackKeepsRequestOutcome(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRollback)
+
+	// the slice is deleted again on the DU, and the request fails with the outcome of the create
+	s.topo.setFail(true)
+	ack := s.request(ctx, createSliceRequest("1"))
+	assert.False(t, ack.Success)
+	assert.Contains(t, ack.Reason, "rolled back")
+	require.NotNil(t, ack.Control)
+	assert.Equal(t, string(e2.OutcomeSuccess), ack.Control.Outcome)
+	assert.Equal(t, RecoveryRolledBack, lastRecovery(t, s.manager).Action)
+	assert.Empty(t, s.manager.compensator.journal.pending())
+}
+
+func TestFailedRollbackIsJournaled(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRollback)
+
+	// the DU rejects the inverse control message, which is not the outcome of the request
+	s.node.reject[2] = true
+	s.topo.setFail(true)
+	ack := s.request(ctx, createSliceRequest("1"))
+	assert.True(t, ack.Success)
+	assert.Contains(t, ack.Reason, string(RecoveryJournaled))
+	require.NotNil(t, ack.Control)
+	assert.Equal(t, string(e2.OutcomeSuccess), ack.Control.Outcome)
+	assert.Len(t, s.manager.compensator.journal.pending(), 1)
+}
+
+func TestSliceItemDeltaInverse(t *testing.T) {
+	item := &topoapi.RSMSlicingItem{
+		ID:              "1",
+		SliceDesc:       "slice",
+		SliceType:       topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE,
+		SliceParameters: &topoapi.RSMSliceParameters{Weight: 10},
+		UeIdList:        []*topoapi.UeIdentity{topoUE(1, 5), topoUE(2, 5)},
+	}
+	for name, delta := range map[string]*sliceItemDelta{
+		"update": {ID: "1", SliceType: item.SliceType, Parameters: &topoapi.RSMSliceParameters{Weight: 20}},
+		"move":   {ID: "1", SliceType: item.SliceType, RemoveUEs: []ueBearerKey{{DuUeF1apID: 1, DrbID: 5}}, AddUEs: []*topoapi.UeIdentity{topoUE(3, 5)}},
+		"delete": {ID: "1", SliceType: item.SliceType, Delete: true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			inverse := delta.inverse(item)
+			require.NotNil(t, inverse)
+			after := delta.apply(proto.Clone(item).(*topoapi.RSMSlicingItem))
+			restored := inverse.apply(after)
+			require.NotNil(t, restored)
+			assert.Equal(t, item.GetSliceParameters().GetWeight(), restored.GetSliceParameters().GetWeight())
+			assert.Equal(t, item.GetSliceDesc(), restored.GetSliceDesc())
+			ues := make(map[int64]bool)
+			for _, ueID := range restored.GetUeIdList() {
+				ues[ueID.GetDuUeF1apID().GetValue()] = true
+			}
+			assert.Equal(t, map[int64]bool{1: true, 2: true}, ues)
+		})
+	}
+
+	// the inverse of a create deletes the slice item
+	create := &sliceItemDelta{ID: "2", SliceType: item.SliceType, Parameters: &topoapi.RSMSliceParameters{}}
+	assert.True(t, create.inverse(nil).Delete)
+	assert.Nil(t, (&sliceItemDelta{ID: "2", Delete: true}).inverse(nil))
+}
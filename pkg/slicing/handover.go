@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"strconv"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
+)
+
+// handleHandover moves the slice associations of a handed-over UE from the source DU to the target DU;
+// an association is re-issued only if a slice with the same ID and type exists on the target DU
+func (m *Manager) handleHandover(ctx context.Context, event monitoring.HandoverEvent) {
+	m.compensator.begin()
+	defer func() {
+		if recoveries := m.compensator.end(); recoveries != "" {
+			log.Infof("Recoveries during handover of UE %v: %v", event.GlobalUeID, recoveries)
+		}
+	}()
+
+	sourceDuNodeID := topoapi.ID(event.Source.GetDuE2NodeId())
+	targetDuNodeID := topoapi.ID(event.Target.GetDuE2NodeId())
+	sourceDuUeF1apID := event.Source.GetUeIdList().GetDuUeF1apID().GetValue()
+	log.Infof("Handover of UE %v from DU %v to DU %v", event.GlobalUeID, sourceDuNodeID, targetDuNodeID)
+
+	// the UE has left the source DU
+	items, err := m.slices.GetRsmSliceItemAspects(ctx, sourceDuNodeID)
+	if err != nil {
+		log.Debugf("no slices on source DU %v: %v", sourceDuNodeID, err)
+	}
+	for _, item := range items {
+		changed := false
+		for _, ueID := range item.GetUeIdList() {
+			if ueID.GetDuUeF1apID().GetValue() == sourceDuUeF1apID {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		delta := &sliceItemDelta{
+			ID:        item.GetID(),
+			SliceType: item.GetSliceType(),
+			RemoveUEs: []ueBearerKey{{DuUeF1apID: sourceDuUeF1apID}},
+		}
+		err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateSliceItem, nodeID: sourceDuNodeID, item: delta}, nil, "failed to remove the UE handed out from the slice")
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+	m.rememberSlices(ctx, sourceDuNodeID)
+
+	type association struct {
+		dlSliceID string
+		ulSliceID string
+	}
+	associations := make(map[int32]*association)
+	drbIDs := make([]int32, 0)
+	for _, slice := range event.Source.GetSliceList() {
+		if topoapi.ID(slice.GetDuE2NodeId()) != sourceDuNodeID {
+			continue
+		}
+		drbID := slice.GetDrbId().GetFiveGdrbId().GetValue()
+		if slice.GetDrbId().GetFourGdrbId() != nil {
+			drbID = slice.GetDrbId().GetFourGdrbId().GetValue()
+		}
+		assoc, ok := associations[drbID]
+		if !ok {
+			assoc = &association{}
+			associations[drbID] = assoc
+			drbIDs = append(drbIDs, drbID)
+		}
+		switch slice.GetSliceType() {
+		case uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE:
+			if m.slices.HasRsmSliceItemAspect(ctx, targetDuNodeID, slice.GetID(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE) {
+				assoc.dlSliceID = slice.GetID()
+			}
+		case uenib_api.RSMSliceType_SLICE_TYPE_UL_SLICE:
+			if m.slices.HasRsmSliceItemAspect(ctx, targetDuNodeID, slice.GetID(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE) {
+				assoc.ulSliceID = slice.GetID()
+			}
+		}
+	}
+
+	for _, drbID := range drbIDs {
+		assoc := associations[drbID]
+		if assoc.dlSliceID == "" && assoc.ulSliceID == "" {
+			log.Infof("UE %v DRB %v: target DU %v has none of the slices of the source DU - the UE stays in the default slice", event.GlobalUeID, drbID, targetDuNodeID)
+			continue
+		}
+		req := &rsmapi.SetUeSliceAssociationRequest{
+			E2NodeId: string(targetDuNodeID),
+			UeId: []*rsmapi.UeId{
+				{
+					UeId: strconv.FormatInt(event.Target.GetUeIdList().GetDuUeF1apID().GetValue(), 10),
+					Type: rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID,
+				},
+			},
+			DlSliceId: assoc.dlSliceID,
+			UlSliceId: assoc.ulSliceID,
+			DrbId:     strconv.Itoa(int(drbID)),
+		}
+		err = m.handleNbiSetUeSliceAssociationRequest(ctx, req, targetDuNodeID)
+		if err != nil {
+			log.Warnf("failed to re-issue slice association of UE %v DRB %v on DU %v: %v", event.GlobalUeID, drbID, targetDuNodeID, err)
+		}
+	}
+	m.rememberSlices(ctx, targetDuNodeID)
+}
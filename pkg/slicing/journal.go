@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// nibWriteKind is the kind of NIB write recorded in the journal
+type nibWriteKind string
+
+const (
+	nibWriteAddSliceItem    nibWriteKind = "add-slice-item"
+	nibWriteUpdateSliceItem nibWriteKind = "update-slice-item"
+	nibWriteDeleteSliceItem nibWriteKind = "delete-slice-item"
+	nibWriteUpdateUESlices  nibWriteKind = "update-ue-slices"
+)
+
+// journalEntry is a NIB write which failed after the E2 node acknowledged the control message
+type journalEntry struct {
+	ID        uint64          `json:"id"`
+	Kind      nibWriteKind    `json:"kind"`
+	NodeID    string          `json:"nodeId"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"createdAt"`
+	LastError string          `json:"lastError,omitempty"`
+}
+
+// journal keeps pending NIB writes; if path is set, every change is persisted to the file
+type journal struct {
+	path    string
+	mu      sync.Mutex
+	nextID  uint64
+	entries []*journalEntry
+}
+
+func newJournal(path string) (*journal, error) {
+	j := &journal{
+		path:    path,
+		nextID:  1,
+		entries: make([]*journalEntry, 0),
+	}
+	if path == "" {
+		return j, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return j, nil
+	}
+
+	err = json.Unmarshal(data, &j.entries)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range j.entries {
+		if e.ID >= j.nextID {
+			j.nextID = e.ID + 1
+		}
+	}
+	return j, nil
+}
+
+// append adds a new entry to the journal and returns its ID
+func (j *journal) append(kind nibWriteKind, nodeID string, payload []byte, cause error) (uint64, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	entry := &journalEntry{
+		ID:        j.nextID,
+		Kind:      kind,
+		NodeID:    nodeID,
+		Payload:   payload,
+		CreatedAt: time.Now(),
+	}
+	if cause != nil {
+		entry.LastError = cause.Error()
+	}
+	j.entries = append(j.entries, entry)
+	if err := j.persist(); err != nil {
+		j.entries = j.entries[:len(j.entries)-1]
+		return 0, err
+	}
+	j.nextID++
+	return entry.ID, nil
+}
+
+// remove deletes the entry with the given ID
+func (j *journal) remove(id uint64) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := 0; i < len(j.entries); i++ {
+		if j.entries[i].ID == id {
+			j.entries = append(j.entries[:i], j.entries[i+1:]...)
+			break
+		}
+	}
+	return j.persist()
+}
+
+// failed records another failed attempt for the entry with the given ID
+func (j *journal) failed(id uint64, cause error) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, e := range j.entries {
+		if e.ID == id {
+			e.Attempts++
+			e.LastError = cause.Error()
+			break
+		}
+	}
+	return j.persist()
+}
+
+// pending returns a copy of the entries in the order they were appended
+func (j *journal) pending() []journalEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	result := make([]journalEntry, 0, len(j.entries))
+	for _, e := range j.entries {
+		result = append(result, *e)
+	}
+	return result
+}
+
+// persist writes all entries to a temporary file and renames it over the journal file
+func (j *journal) persist() error {
+	if j.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return err
+	}
+	tmp := j.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, j.path)
+}
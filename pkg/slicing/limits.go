@@ -0,0 +1,92 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"strconv"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// The limits the DU advertises in its RSM RAN function are checked before any control message is sent
+
+// checkSliceLimit returns an error if the DU cannot have one more slice of the type
+func (m *Manager) checkSliceLimit(ctx context.Context, nodeID topoapi.ID, sliceType rsmapi.SliceType) error {
+	if m.capabilities == nil {
+		return nil
+	}
+	caps, err := m.capabilities.Get(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	topoSliceType := topoapi.RSMSliceType(sliceType)
+	max := caps.MaxSlices(topoSliceType)
+	if max <= 0 {
+		return nil
+	}
+	items, err := m.slices.GetRsmSliceItemAspects(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	count := int32(0)
+	for _, item := range items {
+		if item.GetSliceType() == topoSliceType {
+			count++
+		}
+	}
+	if count >= max {
+		return errors.NewForbidden("DU %v already has %d %v slices, the maximum it supports", nodeID, count, sliceType)
+	}
+	return nil
+}
+
+// checkUeLimit returns an error if a slice of the request is full; a UE already in the slice does not count
+func (m *Manager) checkUeLimit(ctx context.Context, req *rsmapi.SetUeSliceAssociationRequest, nodeID topoapi.ID) error {
+	if m.capabilities == nil {
+		return nil
+	}
+	caps, err := m.capabilities.Get(ctx, nodeID)
+	if err != nil {
+		return err
+	}
+	if caps.MaxUesPerSlice <= 0 {
+		return nil
+	}
+
+	var duUeF1apID int64
+	for _, ueID := range req.GetUeId() {
+		if ueID.GetType() == rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID {
+			duUeF1apID, _ = strconv.ParseInt(ueID.GetUeId(), 10, 64)
+		}
+	}
+	slices := map[rsmapi.SliceType]string{
+		rsmapi.SliceType_SLICE_TYPE_DL_SLICE: req.GetDlSliceId(),
+		rsmapi.SliceType_SLICE_TYPE_UL_SLICE: req.GetUlSliceId(),
+	}
+	for sliceType, sliceID := range slices {
+		if sliceID == "" {
+			continue
+		}
+		item, err := m.rnibClient.GetRsmSliceItemAspect(ctx, nodeID, sliceID, sliceType)
+		if err != nil {
+			// the association fails on the missing slice
+			continue
+		}
+		ues := make(map[int64]bool)
+		for _, ue := range item.GetUeIdList() {
+			ues[ue.GetDuUeF1apID().GetValue()] = true
+		}
+		if ues[duUeF1apID] {
+			continue
+		}
+		if int32(len(ues)) >= caps.MaxUesPerSlice {
+			return errors.NewForbidden("%v slice %v on DU %v already has %d UEs, the maximum it supports", sliceType, sliceID, nodeID, len(ues))
+		}
+	}
+	return nil
+}
@@ -6,16 +6,23 @@ package slicing
 
 import (
 	"context"
+	goerrors "errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
 	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
 	topoapi "github.com/onosproject/onos-api/go/onos/topo"
 	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
 	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
 	e2sm_v2_ies "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-v2-ies"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/drift"
+	"github.com/onosproject/onos-rsm/pkg/metrics"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
 	"github.com/onosproject/onos-rsm/pkg/northbound"
@@ -24,16 +31,41 @@ import (
 
 var log = logging.GetLogger()
 
+// sliceDesc is the description of the slices created by the slice requests
+const sliceDesc = "Slice created by onos-RSM xAPP"
+
 type Manager struct {
-	rsmMsgCh              chan *northbound.RsmMsg
-	ctrlReqChsSliceCreate map[string]chan *e2.CtrlMsg
-	ctrlReqChsSliceUpdate map[string]chan *e2.CtrlMsg
-	ctrlReqChsSliceDelete map[string]chan *e2.CtrlMsg
-	ctrlReqChsUeAssociate map[string]chan *e2.CtrlMsg
-	rnibClient            rnib.TopoClient
-	uenibClient           uenib.Client
-	ctrlMsgHandler        e2.ControlMessageHandler
-	ackTimer              int
+	rsmMsgCh       chan *northbound.RsmMsg
+	ctrlDispatcher *e2.ControlDispatcher
+	rnibClient     rnib.TopoClient
+	capabilities   *rnib.CapabilityCache
+	slices         sliceReader
+	uenibClient    uenib.Client
+	ctrlMsgHandler e2.ControlMessageHandler
+	ackTimer       int
+	// ctrlRetryDeadline is added to the ACK timer for the retries of the E2 manager
+	ctrlRetryDeadline time.Duration
+	compensator       *compensator
+	outcomes          *outcomeRecorder
+	nodeEventCh       chan e2.NodeEvent
+	restorer          *restorer
+	handoverCh        chan monitoring.HandoverEvent
+	repairCh          chan *drift.Repair
+	identities        *monitoring.UEIdentityResolver
+	// multiBearerUnsupported has the DUs which rejected a UE_ASSOCIATE with more than one bearer
+	multiBearerUnsupported map[topoapi.ID]bool
+	// draining is closed to stop taking new work; drained is closed once the work in flight is done
+	draining     chan struct{}
+	restoresDone chan struct{}
+	drained      chan struct{}
+	// stopped is closed once the dispatcher and the restorer have returned
+	stopped chan struct{}
+}
+
+// sliceReader reads the slices of the E2 nodes; it is the capability cache, or onos-topo if there is no cache
+type sliceReader interface {
+	HasRsmSliceItemAspect(ctx context.Context, nodeID topoapi.ID, sliceID string, sliceType rsmapi.SliceType) bool
+	GetRsmSliceItemAspects(ctx context.Context, nodeID topoapi.ID) ([]*topoapi.RSMSlicingItem, error)
 }
 
 func NewManager(opts ...Option) Manager {
@@ -43,58 +75,173 @@ func NewManager(opts ...Option) Manager {
 	for _, opt := range opts {
 		opt.apply(&options)
 	}
+	var slices sliceReader = options.App.RnibClient
+	if options.App.CapabilityCache != nil {
+		slices = options.App.CapabilityCache
+	}
 
 	return Manager{
-		rsmMsgCh:              options.Chans.RsmMsgCh,
-		ctrlReqChsSliceCreate: options.Chans.CtrlReqChsSliceCreate,
-		ctrlReqChsSliceUpdate: options.Chans.CtrlReqChsSliceUpdate,
-		ctrlReqChsSliceDelete: options.Chans.CtrlReqChsSliceDelete,
-		ctrlReqChsUeAssociate: options.Chans.CtrlReqChsUeAssociate,
-		rnibClient:            options.App.RnibClient,
-		uenibClient:           options.App.UenibClient,
-		ctrlMsgHandler:        e2.NewControlMessageHandler(),
-		ackTimer:              options.App.AckTimer,
+		rsmMsgCh:               options.Chans.RsmMsgCh,
+		ctrlDispatcher:         options.App.CtrlDispatcher,
+		rnibClient:             options.App.RnibClient,
+		capabilities:           options.App.CapabilityCache,
+		slices:                 slices,
+		uenibClient:            options.App.UenibClient,
+		ctrlMsgHandler:         e2.NewControlMessageHandler(options.App.CtrlDispatcher),
+		ackTimer:               options.App.AckTimer,
+		ctrlRetryDeadline:      options.App.CtrlRetryPolicy.Deadline,
+		compensator:            newCompensator(options.App.CompensationPolicy, options.App.JournalPath, options.App.RnibClient, options.App.UenibClient),
+		outcomes:               newOutcomeRecorder(),
+		nodeEventCh:            options.Chans.NodeEventCh,
+		restorer:               newRestorer(options.App.ReconnectPolicy),
+		handoverCh:             options.Chans.HandoverCh,
+		repairCh:               options.Chans.RepairCh,
+		identities:             options.App.IdentityResolver,
+		multiBearerUnsupported: make(map[topoapi.ID]bool),
+		draining:               make(chan struct{}),
+		restoresDone:           make(chan struct{}),
+		drained:                make(chan struct{}),
+		stopped:                make(chan struct{}),
 	}
 }
 
+// Run starts the dispatcher; it stops after the message being handled when the context is canceled
 func (m *Manager) Run(ctx context.Context) {
-	go m.DispatchNbiMsg(ctx)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		m.runRestores(ctx)
+		close(m.restoresDone)
+	}()
+	go func() {
+		defer wg.Done()
+		m.DispatchNbiMsg(ctx)
+		close(m.drained)
+	}()
+	go func() {
+		wg.Wait()
+		close(m.stopped)
+	}()
+}
+
+// Stopped returns a channel which is closed once the manager has stopped after its context was canceled
+func (m *Manager) Stopped() <-chan struct{} {
+	return m.stopped
+}
+
+// Drain stops taking NBI requests, node events, handovers, drift repairs and association restores;
+// it returns once the ones in flight are done, or with an error when the context is done first.
+// It must be called once, before the context of Run is canceled.
+func (m *Manager) Drain(ctx context.Context) error {
+	close(m.draining)
+	select {
+	case <-m.drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("slicing manager still has work in flight: %v", ctx.Err())
+	}
 }
 
 func (m *Manager) DispatchNbiMsg(ctx context.Context) {
 	log.Info("Run nbi msg dispatcher")
-	for msg := range m.rsmMsgCh {
-		log.Debugf("Received message from NBI: %v", msg)
-		var ack northbound.Ack
-		var err error
-		switch msg.Message.(type) {
-		case *rsmapi.CreateSliceRequest:
-			err = m.handleNbiCreateSliceRequest(ctx, msg.Message.(*rsmapi.CreateSliceRequest), msg.NodeID)
-		case *rsmapi.UpdateSliceRequest:
-			err = m.handleNbiUpdateSliceRequest(ctx, msg.Message.(*rsmapi.UpdateSliceRequest), msg.NodeID)
-		case *rsmapi.DeleteSliceRequest:
-			err = m.handleNbiDeleteSliceRequest(ctx, msg.Message.(*rsmapi.DeleteSliceRequest), msg.NodeID)
-		case *rsmapi.SetUeSliceAssociationRequest:
-			err = m.handleNbiSetUeSliceAssociationRequest(ctx, msg.Message.(*rsmapi.SetUeSliceAssociationRequest), msg.NodeID)
-		default:
-			err = fmt.Errorf("unknown msg type: %v", msg)
-		}
-		if err != nil {
-			ack = northbound.Ack{
-				Success: false,
-				Reason:  err.Error(),
-			}
-		} else {
-			ack = northbound.Ack{
-				Success: true,
+	// node events, handovers, drift repairs, journal replays and the NIB updates of association restores are
+	// handled here as well, so that they are serialized with NBI requests
+	nodeEventCh, handoverCh, repairCh, rsmMsgCh := m.nodeEventCh, m.handoverCh, m.repairCh, m.rsmMsgCh
+	draining := m.draining
+	var restoresDone chan struct{}
+	m.compensator.replay(ctx)
+	replayTicker := time.NewTicker(journalRetryInterval)
+	defer replayTicker.Stop()
+	replayCh := replayTicker.C
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-draining:
+			// only the restores in flight are still handed over until the restorer has returned
+			log.Info("Draining nbi msg dispatcher")
+			nodeEventCh, handoverCh, repairCh, rsmMsgCh, replayCh = nil, nil, nil, nil, nil
+			draining, restoresDone = nil, m.restoresDone
+		case <-restoresDone:
+			return
+		case <-replayCh:
+			m.compensator.replay(ctx)
+		case event := <-nodeEventCh:
+			log.Debugf("Received node event: %v", event)
+			m.handleNodeEvent(ctx, event)
+		case event := <-handoverCh:
+			m.handleHandover(ctx, event)
+		case restored := <-m.restorer.restored:
+			m.handleRestoredAssociation(ctx, restored)
+		case repair := <-repairCh:
+			repair.Run(ctx)
+		case msg, ok := <-rsmMsgCh:
+			if !ok {
+				return
 			}
+			m.handleNbiMsg(ctx, msg)
+		}
+	}
+}
+
+func (m *Manager) handleNbiMsg(ctx context.Context, msg *northbound.RsmMsg) {
+	log.Debugf("Received message from NBI: %v", msg)
+	var ack northbound.Ack
+	var err error
+	m.compensator.begin()
+	m.outcomes.begin()
+	switch msg.Message.(type) {
+	case *rsmapi.CreateSliceRequest:
+		err = m.handleNbiCreateSliceRequest(ctx, msg.Message.(*rsmapi.CreateSliceRequest), msg.NodeID)
+	case *rsmapi.UpdateSliceRequest:
+		err = m.handleNbiUpdateSliceRequest(ctx, msg.Message.(*rsmapi.UpdateSliceRequest), msg.NodeID)
+	case *rsmapi.DeleteSliceRequest:
+		err = m.handleNbiDeleteSliceRequest(ctx, msg.Message.(*rsmapi.DeleteSliceRequest), msg.NodeID)
+	case *rsmapi.SetUeSliceAssociationRequest:
+		var req *rsmapi.SetUeSliceAssociationRequest
+		req, err = m.resolveUE(ctx, msg.Message.(*rsmapi.SetUeSliceAssociationRequest))
+		if err == nil {
+			msg.NodeID = topoapi.ID(req.GetE2NodeId())
+			err = m.handleNbiSetUeSliceAssociationRequest(ctx, req, msg.NodeID)
+		}
+	case *api.CreateSlicePairRequest:
+		err = m.handleCreateSlicePairRequest(ctx, msg.Message.(*api.CreateSlicePairRequest), msg.NodeID)
+	case *api.UpdateSlicePairRequest:
+		err = m.handleUpdateSlicePairRequest(ctx, msg.Message.(*api.UpdateSlicePairRequest), msg.NodeID)
+	case *api.DeleteSlicePairRequest:
+		err = m.handleDeleteSlicePairRequest(ctx, msg.Message.(*api.DeleteSlicePairRequest), msg.NodeID)
+	case *api.SetUeSlicePairAssociationRequest:
+		msg.NodeID, err = m.handleSetUeSlicePairAssociationRequest(ctx, msg.Message.(*api.SetUeSlicePairAssociationRequest))
+	default:
+		err = fmt.Errorf("unknown msg type: %v", msg)
+	}
+	recoveries := m.compensator.end()
+	control := m.outcomes.end(err == nil)
+	if err != nil {
+		ack = northbound.Ack{
+			Success: false,
+			Reason:  err.Error(),
+			Control: control,
+		}
+	} else {
+		ack = northbound.Ack{
+			Success: true,
+			Reason:  recoveries,
+			Control: control,
 		}
-		msg.AckCh <- ack
 	}
+	m.rememberSlices(ctx, msg.NodeID)
+	msg.AckCh <- ack
 }
 
 func (m *Manager) handleNbiCreateSliceRequest(ctx context.Context, req *rsmapi.CreateSliceRequest, nodeID topoapi.ID) error {
 	log.Infof("Called Create Slice: %v", req)
+	return m.createSlice(ctx, req, nodeID, sliceDesc)
+}
+
+// createSlice creates the slice with the given description in onos-topo
+func (m *Manager) createSlice(ctx context.Context, req *rsmapi.CreateSliceRequest, nodeID topoapi.ID, desc string) error {
 	sliceID, err := strconv.Atoi(req.SliceId)
 	if err != nil {
 		return fmt.Errorf("failed to convert slice id to int - %v", err.Error())
@@ -138,58 +285,39 @@ func (m *Manager) handleNbiCreateSliceRequest(ctx context.Context, req *rsmapi.C
 		},
 		SliceType: sliceType,
 	}
-	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(cmdType, sliceConfig, nil)
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, cmdType, sliceConfig, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create the control message - %v", err.Error())
 	}
 
-	hasSliceItem := m.rnibClient.HasRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.GetSliceType())
+	hasSliceItem := m.slices.HasRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.GetSliceType())
 
 	if hasSliceItem {
 		return fmt.Errorf("slice ID %v already exists", sliceID)
 	}
-
-	// send control message
-	ackCh := make(chan e2.Ack)
-	msg := &e2.CtrlMsg{
-		CtrlMsg: ctrlMsg,
-		AckCh:   ackCh,
+	if err = m.checkSliceLimit(ctx, nodeID, req.GetSliceType()); err != nil {
+		return err
 	}
-	go func() {
-		m.ctrlReqChsSliceCreate[string(nodeID)] <- msg
-	}()
 
-	// ackTimer -1 is for uenib/topo debugging and integration test
-	if m.ackTimer != -1 {
-		var ack e2.Ack
-		select {
-		case <-time.After(time.Duration(m.ackTimer) * time.Second):
-			return fmt.Errorf("timeout happens: E2 SBI could not send ACK until timer expired")
-		case ack = <-ackCh:
-		}
-
-		if !ack.Success {
-			return fmt.Errorf("%v", ack.Reason)
-		}
+	// send control message
+	err = m.sendCtrlMsg(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE, nodeID, ctrlMsg)
+	if err != nil {
+		return err
 	}
 
-	value := &topoapi.RSMSlicingItem{
+	value := &sliceItemDelta{
 		ID:        req.SliceId,
-		SliceDesc: "Slice created by onos-RSM xAPP",
-		SliceParameters: &topoapi.RSMSliceParameters{
+		SliceType: topoapi.RSMSliceType(req.SliceType),
+		SliceDesc: desc,
+		Parameters: &topoapi.RSMSliceParameters{
 			SchedulerType: topoapi.RSMSchedulerType(req.SchedulerType),
 			Weight:        weight,
 		},
-		SliceType: topoapi.RSMSliceType(req.SliceType),
-		UeIdList:  make([]*topoapi.UeIdentity, 0),
 	}
 
-	err = m.rnibClient.AddRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), value)
-	if err != nil {
-		return fmt.Errorf("failed to create slice information to onos-topo although control message was sent: %v", err)
-	}
-
-	return nil
+	inverse := m.newInverseControl(nodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE, sliceConfig, nil)
+	return m.writeNIB(ctx, nibWrite{kind: nibWriteAddSliceItem, nodeID: topoapi.ID(req.E2NodeId), item: value}, inverse,
+		"failed to create slice information to onos-topo although control message was sent")
 }
 
 func (m *Manager) handleNbiUpdateSliceRequest(ctx context.Context, req *rsmapi.UpdateSliceRequest, nodeID topoapi.ID) error {
@@ -237,64 +365,45 @@ func (m *Manager) handleNbiUpdateSliceRequest(ctx context.Context, req *rsmapi.U
 		},
 		SliceType: sliceType,
 	}
-	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(cmdType, sliceConfig, nil)
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, cmdType, sliceConfig, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create the control message - %v", err.Error())
 	}
 
-	hasSliceItem := m.rnibClient.HasRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.GetSliceType())
+	hasSliceItem := m.slices.HasRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.GetSliceType())
 	if !hasSliceItem {
 		return fmt.Errorf("no slice ID %v in node %v", sliceID, nodeID)
 	}
 
-	// send control message
-	ackCh := make(chan e2.Ack)
-	msg := &e2.CtrlMsg{
-		CtrlMsg: ctrlMsg,
-		AckCh:   ackCh,
-	}
-	go func() {
-		m.ctrlReqChsSliceUpdate[string(nodeID)] <- msg
-	}()
-
-	// ackTimer -1 is for uenib/topo debugging and integration test
-	if m.ackTimer != -1 {
-		var ack e2.Ack
-		select {
-		case <-time.After(time.Duration(m.ackTimer) * time.Second):
-			return fmt.Errorf("timeout happens: E2 SBI could not send ACK until timer expired")
-		case ack = <-ackCh:
-		}
-
-		if !ack.Success {
-			return fmt.Errorf("%v", ack.Reason)
+	// the inverse control message restores the previous slice configuration
+	var inverse *inverseControl
+	prevSliceAspect, err := m.rnibClient.GetRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.GetSliceType())
+	if err == nil {
+		prevSliceConfig, err := sliceConfigFromTopo(prevSliceAspect)
+		if err == nil {
+			inverse = m.newInverseControl(nodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE, prevSliceConfig, nil)
 		}
 	}
 
-	sliceAspect, err := m.rnibClient.GetRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.GetSliceType())
+	// send control message
+	err = m.sendCtrlMsg(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE, nodeID, ctrlMsg)
 	if err != nil {
-		return fmt.Errorf("failed to get slice aspect - slice ID %v in node %v: err: %v", sliceID, nodeID, err)
-	}
-
-	ueIDList := sliceAspect.GetUeIdList()
-	if len(ueIDList) == 0 {
-		ueIDList = make([]*topoapi.UeIdentity, 0)
+		return err
 	}
 
-	value := &topoapi.RSMSlicingItem{
+	value := &sliceItemDelta{
 		ID:        req.SliceId,
-		SliceDesc: "Slice created by onos-RSM xAPP",
-		SliceParameters: &topoapi.RSMSliceParameters{
+		SliceType: topoapi.RSMSliceType(req.SliceType),
+		Parameters: &topoapi.RSMSliceParameters{
 			SchedulerType: topoapi.RSMSchedulerType(req.SchedulerType),
 			Weight:        weight,
 		},
-		SliceType: topoapi.RSMSliceType(req.SliceType),
-		UeIdList:  ueIDList,
 	}
 
-	err = m.rnibClient.UpdateRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), value)
+	err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateSliceItem, nodeID: topoapi.ID(req.E2NodeId), item: value}, inverse,
+		"failed to update slice information to onos-topo although control message was sent")
 	if err != nil {
-		return fmt.Errorf("failed to update slice information to onos-topo although control message was sent: %v", err)
+		return err
 	}
 
 	ues, err := m.uenibClient.GetUEs(ctx)
@@ -302,19 +411,29 @@ func (m *Manager) handleNbiUpdateSliceRequest(ctx context.Context, req *rsmapi.U
 		return fmt.Errorf("failed to get UEs in UENIB: %v", err)
 	}
 
+	key := ueSliceKey{
+		ID:        req.SliceId,
+		SliceType: uenib_api.RSMSliceType(req.SliceType),
+	}
 	for i := 0; i < len(ues); i++ {
 		changed := false
 		for j := 0; j < len(ues[i].SliceList); j++ {
-			if ues[i].SliceList[j].ID == req.SliceId && ues[i].SliceList[j].SliceType == uenib_api.RSMSliceType(req.SliceType) {
-				ues[i].SliceList[j].SliceParameters.Weight = weight
-				ues[i].SliceList[j].SliceParameters.SchedulerType = uenib_api.RSMSchedulerType(req.SchedulerType)
+			if key.matches(ues[i].SliceList[j]) {
 				changed = true
 			}
 		}
 		if changed {
-			err = m.uenibClient.UpdateUE(ctx, ues[i])
+			delta := &ueSliceDelta{
+				GlobalUeID:   ues[i].GetGlobalUeID(),
+				ParametersOf: &key,
+				Parameters: &uenib_api.RSMSliceParameters{
+					SchedulerType: uenib_api.RSMSchedulerType(req.SchedulerType),
+					Weight:        weight,
+				},
+			}
+			err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateUESlices, nodeID: nodeID, ue: delta}, inverse, "failed to update UENIB")
 			if err != nil {
-				return fmt.Errorf("failed to update UENIB: %v", err)
+				return err
 			}
 		}
 	}
@@ -347,43 +466,36 @@ func (m *Manager) handleNbiDeleteSliceRequest(ctx context.Context, req *rsmapi.D
 		SliceType: sliceType,
 	}
 
-	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(cmdType, sliceConfig, nil)
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, cmdType, sliceConfig, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create the control message - %v", err.Error())
 	}
 
-	hasSliceItem := m.rnibClient.HasRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.SliceType)
+	hasSliceItem := m.slices.HasRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.SliceType)
 	if !hasSliceItem {
 		return fmt.Errorf("no slice ID %v in node %v", sliceID, nodeID)
 	}
 
-	// send control message
-	ackCh := make(chan e2.Ack)
-	msg := &e2.CtrlMsg{
-		CtrlMsg: ctrlMsg,
-		AckCh:   ackCh,
-	}
-	go func() {
-		m.ctrlReqChsSliceDelete[string(nodeID)] <- msg
-	}()
-
-	// ackTimer -1 is for uenib/topo debugging and integration test
-	if m.ackTimer != -1 {
-		var ack e2.Ack
-		select {
-		case <-time.After(time.Duration(m.ackTimer) * time.Second):
-			return fmt.Errorf("timeout happens: E2 SBI could not send ACK until timer expired")
-		case ack = <-ackCh:
+	// the inverse control message creates the slice again with its previous configuration
+	var inverse *inverseControl
+	prevSliceAspect, err := m.rnibClient.GetRsmSliceItemAspect(ctx, topoapi.ID(req.E2NodeId), req.SliceId, req.SliceType)
+	if err == nil {
+		prevSliceConfig, err := sliceConfigFromTopo(prevSliceAspect)
+		if err == nil {
+			inverse = m.newInverseControl(nodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE, prevSliceConfig, nil)
 		}
+	}
 
-		if !ack.Success {
-			return fmt.Errorf("%v", ack.Reason)
-		}
+	// send control message
+	err = m.sendCtrlMsg(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE, nodeID, ctrlMsg)
+	if err != nil {
+		return err
 	}
 
-	err = m.rnibClient.DeleteRsmSliceItemAspect(ctx, nodeID, req.SliceId)
+	err = m.writeNIB(ctx, nibWrite{kind: nibWriteDeleteSliceItem, nodeID: nodeID, item: &sliceItemDelta{ID: req.SliceId, SliceType: topoapi.RSMSliceType(req.SliceType), Delete: true}}, inverse,
+		"failed to delete slice information to onos-topo although control message was sent")
 	if err != nil {
-		return fmt.Errorf("failed to delete slice information to onos-topo although control message was sent: %v", err)
+		return err
 	}
 
 	ues, err := m.uenibClient.GetUEs(ctx)
@@ -391,19 +503,25 @@ func (m *Manager) handleNbiDeleteSliceRequest(ctx context.Context, req *rsmapi.D
 		return fmt.Errorf("failed to get UEs in UENIB: %v", err)
 	}
 
+	key := ueSliceKey{
+		ID:        req.SliceId,
+		SliceType: uenib_api.RSMSliceType(req.SliceType),
+	}
 	for i := 0; i < len(ues); i++ {
 		changed := false
 		for j := 0; j < len(ues[i].SliceList); j++ {
-			if ues[i].SliceList[j].ID == req.SliceId && ues[i].SliceList[j].SliceType == uenib_api.RSMSliceType(req.SliceType) {
-				ues[i].SliceList = append(ues[i].SliceList[:j], ues[i].SliceList[j+1:]...)
-				j--
+			if key.matches(ues[i].SliceList[j]) {
 				changed = true
 			}
 		}
 		if changed {
-			err = m.uenibClient.UpdateUE(ctx, ues[i])
+			delta := &ueSliceDelta{
+				GlobalUeID: ues[i].GetGlobalUeID(),
+				Remove:     []ueSliceKey{key},
+			}
+			err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateUESlices, nodeID: nodeID, ue: delta}, inverse, "failed to update UENIB")
 			if err != nil {
-				return fmt.Errorf("failed to update UENIB: %v", err)
+				return err
 			}
 		}
 	}
@@ -413,6 +531,18 @@ func (m *Manager) handleNbiDeleteSliceRequest(ctx context.Context, req *rsmapi.D
 
 func (m *Manager) handleNbiSetUeSliceAssociationRequest(ctx context.Context, req *rsmapi.SetUeSliceAssociationRequest, nodeID topoapi.ID) error {
 	log.Infof("Called SetUeSliceAssociation: %v", req)
+	if err := m.checkUeLimit(ctx, req, nodeID); err != nil {
+		return err
+	}
+	if isBearerSelector(req.GetDrbId()) {
+		return m.setUeSliceAssociationForBearers(ctx, req, nodeID)
+	}
+	return m.setUeSliceAssociation(ctx, req, nodeID, true)
+}
+
+// setUeSliceAssociation associates a single bearer of the UE with the slices;
+// if sendCtrl is not set, the control message was already acknowledged and only the NIBs are updated
+func (m *Manager) setUeSliceAssociation(ctx context.Context, req *rsmapi.SetUeSliceAssociationRequest, nodeID topoapi.ID, sendCtrl bool) error {
 	var err error
 	duNodeID := req.E2NodeId
 	cuNodeID, err := m.rnibClient.GetSourceCUE2NodeID(ctx, topoapi.ID(duNodeID))
@@ -492,8 +622,8 @@ func (m *Manager) handleNbiSetUeSliceAssociationRequest(ctx context.Context, req
 		return fmt.Errorf("need valid du-ue-f1ap-id")
 	}
 
-	hasUlSliceItem := m.rnibClient.HasRsmSliceItemAspect(ctx, topoapi.ID(duNodeID), req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
-	hasDlSliceItem := m.rnibClient.HasRsmSliceItemAspect(ctx, topoapi.ID(duNodeID), req.GetDlSliceId(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
+	hasUlSliceItem := m.slices.HasRsmSliceItemAspect(ctx, topoapi.ID(duNodeID), req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
+	hasDlSliceItem := m.slices.HasRsmSliceItemAspect(ctx, topoapi.ID(duNodeID), req.GetDlSliceId(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
 
 	if !hasUlSliceItem && !hasDlSliceItem {
 		return fmt.Errorf("invalid slice ID")
@@ -512,7 +642,8 @@ func (m *Manager) handleNbiSetUeSliceAssociationRequest(ctx context.Context, req
 		return fmt.Errorf("failed to get UENIB UE info (CuID %v DUID %v UEID %v): err: %v", cuNodeID, duNodeID, ueID, err)
 	}
 
-	if rsmUEInfo.GetDuE2NodeId() == "" {
+	hasDuNodeID := rsmUEInfo.GetDuE2NodeId() != ""
+	if !hasDuNodeID {
 		rsmUEInfo.DuE2NodeId = duNodeID
 	} else if rsmUEInfo.GetDuE2NodeId() != duNodeID {
 		return fmt.Errorf("DU ID in UENIB and received DU ID are not matched - received DU ID: %v DU ID in uenib: %v", duNodeID, rsmUEInfo.GetDuE2NodeId())
@@ -607,38 +738,68 @@ func (m *Manager) handleNbiSetUeSliceAssociationRequest(ctx context.Context, req
 		}
 	}
 
-	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(cmdType, nil, sliceAssoc)
-	if err != nil {
-		return fmt.Errorf("failed to create the control message - %v", err)
+	// the inverse control message associates the bearer with its previous slices, if it had any
+	var inverse *inverseControl
+	var prevDlSliceID, prevUlSliceID *e2sm_rsm.SliceIdassoc
+	for _, slice := range rsmUEInfo.GetSliceList() {
+		if slice.GetDrbId().GetFiveGdrbId().GetValue() != int32(drbID) && slice.GetDrbId().GetFourGdrbId().GetValue() != int32(drbID) {
+			continue
+		}
+		prevSliceID, err := strconv.Atoi(slice.GetID())
+		if err != nil {
+			continue
+		}
+		switch slice.GetSliceType() {
+		case uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE:
+			prevDlSliceID = &e2sm_rsm.SliceIdassoc{Value: int64(prevSliceID)}
+		case uenib_api.RSMSliceType_SLICE_TYPE_UL_SLICE:
+			prevUlSliceID = &e2sm_rsm.SliceIdassoc{Value: int64(prevSliceID)}
+		}
 	}
-
-	// send control message
-	ackCh := make(chan e2.Ack)
-	msg := &e2.CtrlMsg{
-		CtrlMsg: ctrlMsg,
-		AckCh:   ackCh,
+	// a direction without a previous slice is not rolled back: E2SM-RSM cannot dissociate a bearer from a slice,
+	// so the inverse sends the new DL slice again and leaves out the UL slice
+	inverses := make(map[rsmapi.SliceType]*inverseControl)
+	if prevDlSliceID != nil || prevUlSliceID != nil {
+		inverseDlSliceID := prevDlSliceID
+		if inverseDlSliceID == nil {
+			inverseDlSliceID = sliceAssoc.DownLinkSliceId
+		}
+		inverse = m.newInverseControl(nodeID, cmdType, nil, &e2sm_rsm.SliceAssociate{
+			DownLinkSliceId: inverseDlSliceID,
+			UplinkSliceId:   prevUlSliceID,
+			UeId:            ueID,
+			BearerId:        bearerIDs,
+		})
+		if prevDlSliceID != nil {
+			inverses[rsmapi.SliceType_SLICE_TYPE_DL_SLICE] = inverse
+		}
+		if prevUlSliceID != nil {
+			inverses[rsmapi.SliceType_SLICE_TYPE_UL_SLICE] = inverse
+		}
 	}
-	go func() {
-		m.ctrlReqChsUeAssociate[string(nodeID)] <- msg
-	}()
 
-	// ackTimer -1 is for uenib/topo debugging and integration test
-	if m.ackTimer != -1 {
-		var ack e2.Ack
-		select {
-		case <-time.After(time.Duration(m.ackTimer) * time.Second):
-			return fmt.Errorf("timeout happens: E2 SBI could not send ACK until timer expired")
-		case ack = <-ackCh:
+	// send control message
+	if sendCtrl {
+		ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, cmdType, nil, sliceAssoc)
+		if err != nil {
+			return fmt.Errorf("failed to create the control message - %v", err)
 		}
-
-		if !ack.Success {
-			return fmt.Errorf("%v", ack.Reason)
+		err = m.sendCtrlMsg(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE, nodeID, ctrlMsg)
+		if err != nil {
+			return err
 		}
 	}
 
-	err = m.uenibClient.UpdateUE(ctx, rsmUEInfo)
-	if err != nil {
-		return fmt.Errorf("tried to update du e2node ID on uenib (because there was no du ID) but failed to update du id UENIB UE info (CuID %v DUID %v UEID %v uenib UE info %v): err: %v", cuNodeID, duNodeID, ueID, rsmUEInfo, err)
+	if !hasDuNodeID {
+		delta := &ueSliceDelta{
+			GlobalUeID: rsmUEInfo.GetGlobalUeID(),
+			DuE2NodeID: duNodeID,
+		}
+		err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateUESlices, nodeID: topoapi.ID(duNodeID), ue: delta}, inverse,
+			fmt.Sprintf("tried to update du e2node ID on uenib (because there was no du ID) but failed to update du id UENIB UE info (CuID %v DUID %v UEID %v uenib UE info %v)", cuNodeID, duNodeID, ueID, rsmUEInfo))
+		if err != nil {
+			return err
+		}
 	}
 
 	// Update topo
@@ -659,6 +820,17 @@ func (m *Manager) handleNbiSetUeSliceAssociationRequest(ctx context.Context, req
 			Value: int32(EnbUeS1apID),
 		},
 	}
+	// the IDs the request does not have are taken from UE-NIB, so that the association is restored
+	// by the NGAP IDs of the UE once the DU reconnects
+	if CuUeF1apID == 0 {
+		ueIDforTopo.CuUeF1apID.Value = rsmUEInfo.GetUeIdList().GetCuUeF1apID().GetValue()
+	}
+	if RanUeNgapID == 0 {
+		ueIDforTopo.RANUeNgapID.Value = rsmUEInfo.GetUeIdList().GetRANUeNgapID().GetValue()
+	}
+	if AmfUeNgapID == 0 {
+		ueIDforTopo.AMFUeNgapID.Value = rsmUEInfo.GetUeIdList().GetAMFUeNgapID().GetValue()
+	}
 
 	var topoDrbID *topoapi.DrbId
 	var uenibDrbID *uenib_api.DrbId
@@ -763,215 +935,208 @@ func (m *Manager) handleNbiSetUeSliceAssociationRequest(ctx context.Context, req
 		}
 	}
 
+	ueIDforTopo.DrbId = topoDrbID
+	bearerAssoc := &ueBearerAssociation{
+		duNodeID:   topoapi.ID(duNodeID),
+		cuNodeID:   cuNodeID,
+		duUeF1apID: DuUeF1apID,
+		drbID:      int32(drbID),
+		topoUeID:   ueIDforTopo,
+		uenibDrbID: uenibDrbID,
+		rsmUEInfo:  rsmUEInfo,
+		inverses:   inverses,
+	}
 	if hasUlSliceItem {
-		ulSliceItems, err := m.rnibClient.GetRsmSliceItemAspects(ctx, topoapi.ID(duNodeID))
+		err = m.associateSliceInNIBs(ctx, bearerAssoc, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
 		if err != nil {
-			return fmt.Errorf("failed to get slice item list from R-NIB: %v", err)
-		}
-		for _, oldDlItem := range ulSliceItems {
-			changed := false
-			for i := 0; i < len(oldDlItem.UeIdList); i++ {
-				if oldDlItem.UeIdList[i].GetDrbId().GetFiveGdrbId() != nil {
-					if oldDlItem.UeIdList[i].GetDrbId().GetFiveGdrbId().GetValue() == int32(drbID) &&
-						oldDlItem.UeIdList[i].GetDuUeF1apID().GetValue() == DuUeF1apID &&
-						oldDlItem.SliceType == topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE {
-						oldDlItem.UeIdList = append(oldDlItem.UeIdList[:i], oldDlItem.UeIdList[i+1:]...)
-						i--
-						changed = true
-					}
-				} else if oldDlItem.UeIdList[i].GetDrbId().GetFourGdrbId() != nil && oldDlItem.UeIdList[i].GetDuUeF1apID().GetValue() == DuUeF1apID {
-					if oldDlItem.UeIdList[i].GetDrbId().GetFourGdrbId().GetValue() == int32(drbID) &&
-						oldDlItem.UeIdList[i].GetDuUeF1apID().GetValue() == DuUeF1apID &&
-						oldDlItem.SliceType == topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE {
-						oldDlItem.UeIdList = append(oldDlItem.UeIdList[:i], oldDlItem.UeIdList[i+1:]...)
-						i--
-						changed = true
-					}
-				}
-			}
-			if changed {
-				err = m.rnibClient.UpdateRsmSliceItemAspect(ctx, topoapi.ID(req.GetE2NodeId()), oldDlItem)
-				if err != nil {
-					return fmt.Errorf("failed to update UL slice item top onos-topo(ID - %v, sliceID - %v, sliceType - %v): %v", duNodeID, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE, err)
-				}
-			}
+			return err
 		}
-
-		ulSliceItem, err := m.rnibClient.GetRsmSliceItemAspect(ctx, topoapi.ID(duNodeID), req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
+	}
+	if hasDlSliceItem {
+		err = m.associateSliceInNIBs(ctx, bearerAssoc, req.GetDlSliceId(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
 		if err != nil {
-			return fmt.Errorf("failed to get UL slice item (ID - %v, sliceID - %v, sliceType - %v): %v", duNodeID, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE, err)
-		}
-
-		if len(ulSliceItem.GetUeIdList()) == 0 {
-			ulSliceItem.UeIdList = make([]*topoapi.UeIdentity, 0)
+			return err
 		}
+	}
+	return nil
+}
 
-		ueIDforTopo.DrbId = topoDrbID
-		ulSliceItem.UeIdList = append(ulSliceItem.UeIdList, ueIDforTopo)
-
-		err = m.rnibClient.UpdateRsmSliceItemAspect(ctx, topoapi.ID(req.GetE2NodeId()), ulSliceItem)
-		if err != nil {
-			return fmt.Errorf("failed to update UL slice item top onos-topo(ID - %v, sliceID - %v, sliceType - %v): %v", duNodeID, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE, err)
-		}
+// sendCtrlMsg sends the control message to the E2 node and waits for the ACK
+func (m *Manager) sendCtrlMsg(ctx context.Context, command e2sm_rsm.E2SmRsmCommand, nodeID topoapi.ID, ctrlMsg *e2api.ControlMessage) error {
+	return m.sendControl(ctx, command, nodeID, ctrlMsg, true)
+}
 
-		// Update uenib
-		if rsmUEInfo.GetSliceList() == nil || len(rsmUEInfo.GetSliceList()) == 0 {
-			rsmUEInfo.SliceList = make([]*uenib_api.SliceInfo, 0)
+// sendControl sends the control message to the E2 node and waits for the ACK;
+// if record is set, the outcome is reported in the response of the NBI request being handled
+func (m *Manager) sendControl(ctx context.Context, command e2sm_rsm.E2SmRsmCommand, nodeID topoapi.ID, ctrlMsg *e2api.ControlMessage, record bool) error {
+	if err := m.ctrlDispatcher.Check(nodeID, command); err != nil {
+		return err
+	}
+	ackCh := make(chan e2.Ack, 1)
+	msg := &e2.CtrlMsg{
+		CtrlMsg: ctrlMsg,
+		AckCh:   ackCh,
+	}
+	go func() {
+		if err := m.ctrlDispatcher.Send(ctx, nodeID, command, msg); err != nil {
+			ackCh <- e2.NewAck(nil, err)
 		}
+	}()
 
-		var ulSliceSchedulerType uenib_api.RSMSchedulerType
-		switch ulSliceItem.GetSliceParameters().GetSchedulerType() {
-		case topoapi.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN:
-			ulSliceSchedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN
-		case topoapi.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR:
-			ulSliceSchedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR
-		case topoapi.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED:
-			ulSliceSchedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED
-		default:
-			return fmt.Errorf("not supported scheduler type: %v", ulSliceItem.GetSliceParameters().GetSchedulerType())
+	// ackTimer -1 is for uenib/topo debugging and integration test
+	if m.ackTimer != -1 {
+		var ack e2.Ack
+		start := time.Now()
+		select {
+		case <-time.After(time.Duration(m.ackTimer)*time.Second + m.ctrlRetryDeadline):
+			ack = e2.NewTimeoutAck("timeout happens: E2 SBI could not send ACK until timer expired")
+			metrics.ObserveAckTimeout(string(nodeID), command.String())
+		case <-ctx.Done():
+			return ctx.Err()
+		case ack = <-ackCh:
+			metrics.ObserveControl(string(nodeID), command.String(), ack.Success, controlFailureCause(ack), time.Since(start))
 		}
-
-		isUenibSliceUpdated := false
-		for i := 0; i < len(rsmUEInfo.SliceList); i++ {
-			if rsmUEInfo.SliceList[i].GetDrbId().GetFiveGdrbId() != nil {
-				if rsmUEInfo.SliceList[i].GetDrbId().GetFiveGdrbId().GetValue() == int32(drbID) {
-					rsmUEInfo.SliceList[i].DrbId = uenibDrbID
-					isUenibSliceUpdated = true
-				}
-			}
-			if rsmUEInfo.SliceList[i].GetDrbId().GetFourGdrbId() != nil {
-				if rsmUEInfo.SliceList[i].GetDrbId().GetFourGdrbId().GetValue() == int32(drbID) {
-					rsmUEInfo.SliceList[i].DrbId = uenibDrbID
-					isUenibSliceUpdated = true
-				}
-			}
+		if record {
+			m.outcomes.record(nodeID, ack)
 		}
 
-		if !isUenibSliceUpdated {
-			sliceInfo := &uenib_api.SliceInfo{
-				DuE2NodeId: duNodeID,
-				CuE2NodeId: string(cuNodeID),
-				ID:         req.GetUlSliceId(),
-				SliceParameters: &uenib_api.RSMSliceParameters{
-					SchedulerType: ulSliceSchedulerType,
-					Weight:        ulSliceItem.GetSliceParameters().Weight,
-					QosLevel:      ulSliceItem.GetSliceParameters().QosLevel,
-				},
-				SliceType: uenib_api.RSMSliceType_SLICE_TYPE_UL_SLICE,
-				DrbId:     uenibDrbID,
-			}
-
-			rsmUEInfo.SliceList = append(rsmUEInfo.SliceList, sliceInfo)
-		}
-		err = m.uenibClient.UpdateUE(ctx, rsmUEInfo)
-		if err != nil {
-			return fmt.Errorf("Failed to update uenib: %v", err)
+		if !ack.Success {
+			return &controlFailure{ack: ack}
 		}
 	}
+	return nil
+}
 
-	if hasDlSliceItem {
-		dlSliceItems, err := m.rnibClient.GetRsmSliceItemAspects(ctx, topoapi.ID(duNodeID))
-		if err != nil {
-			return fmt.Errorf("failed to get slice item list from R-NIB: %v", err)
-		}
-		for _, oldDlItem := range dlSliceItems {
-			changed := false
-			for i := 0; i < len(oldDlItem.UeIdList); i++ {
-				if oldDlItem.UeIdList[i].GetDrbId().GetFiveGdrbId() != nil {
-					if oldDlItem.UeIdList[i].GetDrbId().GetFiveGdrbId().GetValue() == int32(drbID) &&
-						oldDlItem.UeIdList[i].GetDuUeF1apID().GetValue() == DuUeF1apID &&
-						oldDlItem.SliceType == topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE {
-						oldDlItem.UeIdList = append(oldDlItem.UeIdList[:i], oldDlItem.UeIdList[i+1:]...)
-						i--
-						changed = true
-					}
-				} else if oldDlItem.UeIdList[i].GetDrbId().GetFourGdrbId() != nil && oldDlItem.UeIdList[i].GetDuUeF1apID().GetValue() == DuUeF1apID {
-					if oldDlItem.UeIdList[i].GetDrbId().GetFourGdrbId().GetValue() == int32(drbID) &&
-						oldDlItem.UeIdList[i].GetDuUeF1apID().GetValue() == DuUeF1apID &&
-						oldDlItem.SliceType == topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE {
-						oldDlItem.UeIdList = append(oldDlItem.UeIdList[:i], oldDlItem.UeIdList[i+1:]...)
-						i--
-						changed = true
-					}
-				}
-			}
-			if changed {
-				err = m.rnibClient.UpdateRsmSliceItemAspect(ctx, topoapi.ID(req.GetE2NodeId()), oldDlItem)
-				if err != nil {
-					return fmt.Errorf("failed to update UL slice item top onos-topo(ID - %v, sliceID - %v, sliceType - %v): %v", duNodeID, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE, err)
-				}
-			}
-		}
+// controlFailure is the error of a control message which was not acknowledged
+type controlFailure struct {
+	ack e2.Ack
+}
 
-		dlSliceItem, err := m.rnibClient.GetRsmSliceItemAspect(ctx, topoapi.ID(duNodeID), req.GetDlSliceId(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
-		if err != nil {
-			return fmt.Errorf("failed to get DL slice item (ID - %v, sliceID - %v, sliceType - %v): %v", duNodeID, req.GetDlSliceId(), rsmapi.SliceType_SLICE_TYPE_DL_SLICE, err)
-		}
+func (f *controlFailure) Error() string {
+	return f.ack.Reason
+}
 
-		if len(dlSliceItem.GetUeIdList()) == 0 {
-			dlSliceItem.UeIdList = make([]*topoapi.UeIdentity, 0)
-		}
+// isControlRejected returns true if the E2 node rejected the control message with an E2AP cause which is not worth
+// a retry, as opposed to a control message which did not reach the node, timed out or hit an overload
+func isControlRejected(err error) bool {
+	var failure *controlFailure
+	return goerrors.As(err, &failure) && failure.ack.Outcome == e2.OutcomeFailure && !failure.ack.Retryable
+}
 
-		ueIDforTopo.DrbId = topoDrbID
-		dlSliceItem.UeIdList = append(dlSliceItem.UeIdList, ueIDforTopo)
+// controlFailureCause returns the E2AP cause of a failed control message, or its outcome if the E2 node sent none
+func controlFailureCause(ack e2.Ack) string {
+	if ack.Cause != "" {
+		return ack.Cause
+	}
+	return string(ack.Outcome)
+}
 
-		err = m.rnibClient.UpdateRsmSliceItemAspect(ctx, topoapi.ID(req.GetE2NodeId()), dlSliceItem)
-		if err != nil {
-			return fmt.Errorf("failed to update UL slice item top onos-topo(ID - %v, sliceID - %v, sliceType - %v): %v", duNodeID, req.GetUlSliceId(), rsmapi.SliceType_SLICE_TYPE_UL_SLICE, err)
-		}
+// ueBearerAssociation is a UE bearer whose association with slices was acknowledged by the DU
+type ueBearerAssociation struct {
+	duNodeID   topoapi.ID
+	cuNodeID   topoapi.ID
+	duUeF1apID int64
+	drbID      int32
+	topoUeID   *topoapi.UeIdentity
+	uenibDrbID *uenib_api.DrbId
+	rsmUEInfo  *uenib_api.RsmUeInfo
+	// inverses has the inverse control message of each slice type which can be rolled back
+	inverses map[rsmapi.SliceType]*inverseControl
+}
 
-		// Update uenib
-		if rsmUEInfo.GetSliceList() == nil || len(rsmUEInfo.GetSliceList()) == 0 {
-			rsmUEInfo.SliceList = make([]*uenib_api.SliceInfo, 0)
+// associateSliceInNIBs moves the UE bearer from its previous slice of the given type to the new slice in onos-topo and UE-NIB
+func (m *Manager) associateSliceInNIBs(ctx context.Context, a *ueBearerAssociation, sliceID string, sliceType rsmapi.SliceType) error {
+	topoSliceType := topoapi.RSMSliceType(sliceType)
+	inverse := a.inverses[sliceType]
+	items, err := m.slices.GetRsmSliceItemAspects(ctx, a.duNodeID)
+	if err != nil {
+		return fmt.Errorf("failed to get slice item list from R-NIB: %v", err)
+	}
+	bearer := ueBearerKey{
+		DuUeF1apID: a.duUeF1apID,
+		DrbID:      a.drbID,
+	}
+	for _, oldItem := range items {
+		if oldItem.GetSliceType() != topoSliceType {
+			continue
 		}
-
-		var dlSliceSchedulerType uenib_api.RSMSchedulerType
-		switch dlSliceItem.GetSliceParameters().GetSchedulerType() {
-		case topoapi.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN:
-			dlSliceSchedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN
-		case topoapi.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR:
-			dlSliceSchedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR
-		case topoapi.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED:
-			dlSliceSchedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED
-		default:
-			return fmt.Errorf("not supported scheduler type: %v", dlSliceItem.GetSliceParameters().GetSchedulerType())
+		changed := false
+		for _, ueID := range oldItem.GetUeIdList() {
+			if rnib.DrbValue(ueID.GetDrbId()) == a.drbID && ueID.GetDuUeF1apID().GetValue() == a.duUeF1apID {
+				changed = true
+			}
 		}
-
-		for i := 0; i < len(rsmUEInfo.SliceList); i++ {
-			if rsmUEInfo.SliceList[i].GetDrbId().GetFiveGdrbId() != nil {
-				if rsmUEInfo.SliceList[i].GetDrbId().GetFiveGdrbId().GetValue() == int32(drbID) {
-					rsmUEInfo.SliceList = append(rsmUEInfo.SliceList[:i], rsmUEInfo.SliceList[i+1:]...)
-					i--
-				}
+		if changed {
+			delta := &sliceItemDelta{
+				ID:        oldItem.GetID(),
+				SliceType: topoSliceType,
+				RemoveUEs: []ueBearerKey{bearer},
 			}
-			if rsmUEInfo.SliceList[i].GetDrbId().GetFourGdrbId() != nil {
-				if rsmUEInfo.SliceList[i].GetDrbId().GetFourGdrbId().GetValue() == int32(drbID) {
-					rsmUEInfo.SliceList = append(rsmUEInfo.SliceList[:i], rsmUEInfo.SliceList[i+1:]...)
-					i--
-				}
+			err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateSliceItem, nodeID: a.duNodeID, item: delta}, inverse,
+				fmt.Sprintf("failed to update slice item to onos-topo(ID - %v, sliceID - %v, sliceType - %v)", a.duNodeID, oldItem.GetID(), sliceType))
+			if err != nil {
+				return err
 			}
 		}
+	}
 
-		sliceInfo := &uenib_api.SliceInfo{
-			DuE2NodeId: duNodeID,
-			CuE2NodeId: string(cuNodeID),
-			ID:         req.GetDlSliceId(),
-			SliceParameters: &uenib_api.RSMSliceParameters{
-				SchedulerType: dlSliceSchedulerType,
-				Weight:        dlSliceItem.GetSliceParameters().Weight,
-				QosLevel:      dlSliceItem.GetSliceParameters().QosLevel,
-			},
-			SliceType: uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE,
-			DrbId:     uenibDrbID,
-		}
+	sliceItem, err := m.rnibClient.GetRsmSliceItemAspect(ctx, a.duNodeID, sliceID, sliceType)
+	if err != nil {
+		return fmt.Errorf("failed to get slice item (ID - %v, sliceID - %v, sliceType - %v): %v", a.duNodeID, sliceID, sliceType, err)
+	}
+	delta := &sliceItemDelta{
+		ID:        sliceID,
+		SliceType: topoSliceType,
+		AddUEs:    []*topoapi.UeIdentity{a.topoUeID},
+	}
+	err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateSliceItem, nodeID: a.duNodeID, item: delta}, inverse,
+		fmt.Sprintf("failed to update slice item to onos-topo(ID - %v, sliceID - %v, sliceType - %v)", a.duNodeID, sliceID, sliceType))
+	if err != nil {
+		return err
+	}
 
-		rsmUEInfo.SliceList = append(rsmUEInfo.SliceList, sliceInfo)
+	// Update uenib
+	var schedulerType uenib_api.RSMSchedulerType
+	switch sliceItem.GetSliceParameters().GetSchedulerType() {
+	case topoapi.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN:
+		schedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN
+	case topoapi.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR:
+		schedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR
+	case topoapi.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED:
+		schedulerType = uenib_api.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED
+	default:
+		return fmt.Errorf("not supported scheduler type: %v", sliceItem.GetSliceParameters().GetSchedulerType())
+	}
 
-		err = m.uenibClient.UpdateUE(ctx, rsmUEInfo)
-		if err != nil {
-			return fmt.Errorf("Failed to update uenib: %v", err)
+	if len(a.rsmUEInfo.GetSliceList()) == 0 {
+		a.rsmUEInfo.SliceList = make([]*uenib_api.SliceInfo, 0)
+	}
+	for i := 0; i < len(a.rsmUEInfo.SliceList); i++ {
+		if uenib.DrbValue(a.rsmUEInfo.SliceList[i].GetDrbId()) == a.drbID && a.rsmUEInfo.SliceList[i].GetSliceType() == uenib_api.RSMSliceType(sliceType) {
+			a.rsmUEInfo.SliceList = append(a.rsmUEInfo.SliceList[:i], a.rsmUEInfo.SliceList[i+1:]...)
+			i--
 		}
 	}
-
-	return nil
+	slice := &uenib_api.SliceInfo{
+		DuE2NodeId: string(a.duNodeID),
+		CuE2NodeId: string(a.cuNodeID),
+		ID:         sliceID,
+		SliceParameters: &uenib_api.RSMSliceParameters{
+			SchedulerType: schedulerType,
+			Weight:        sliceItem.GetSliceParameters().GetWeight(),
+			QosLevel:      sliceItem.GetSliceParameters().GetQosLevel(),
+		},
+		SliceType: uenib_api.RSMSliceType(sliceType),
+		DrbId:     a.uenibDrbID,
+	}
+	a.rsmUEInfo.SliceList = append(a.rsmUEInfo.SliceList, slice)
+	ueDelta := &ueSliceDelta{
+		GlobalUeID: a.rsmUEInfo.GetGlobalUeID(),
+		Remove: []ueSliceKey{{
+			SliceType: uenib_api.RSMSliceType(sliceType),
+			DrbID:     a.drbID,
+		}},
+		Add: []*uenib_api.SliceInfo{slice},
+	}
+	return m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateUESlices, nodeID: a.duNodeID, ue: ueDelta}, inverse, "Failed to update uenib")
 }
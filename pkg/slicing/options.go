@@ -5,6 +5,8 @@
 package slicing
 
 import (
+	"github.com/onosproject/onos-rsm/pkg/drift"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
 	"github.com/onosproject/onos-rsm/pkg/northbound"
@@ -20,13 +22,11 @@ type Options struct {
 type Channels struct {
 	RsmMsgCh chan *northbound.RsmMsg
 
-	CtrlReqChsSliceCreate map[string]chan *e2.CtrlMsg
+	NodeEventCh chan e2.NodeEvent
 
-	CtrlReqChsSliceUpdate map[string]chan *e2.CtrlMsg
+	HandoverCh chan monitoring.HandoverEvent
 
-	CtrlReqChsSliceDelete map[string]chan *e2.CtrlMsg
-
-	CtrlReqChsUeAssociate map[string]chan *e2.CtrlMsg
+	RepairCh chan *drift.Repair
 }
 
 type AppOptions struct {
@@ -34,7 +34,21 @@ type AppOptions struct {
 
 	UenibClient uenib.Client
 
+	CapabilityCache *rnib.CapabilityCache
+
+	CtrlDispatcher *e2.ControlDispatcher
+
 	AckTimer int
+
+	CtrlRetryPolicy e2.RetryPolicy
+
+	CompensationPolicy CompensationPolicy
+
+	JournalPath string
+
+	ReconnectPolicy ReconnectPolicy
+
+	IdentityResolver *monitoring.UEIdentityResolver
 }
 
 type Option interface {
@@ -55,15 +69,9 @@ func newOption(f func(*Options)) Option {
 	}
 }
 
-func WithCtrlReqChs(ctrlReqChsSliceCreate map[string]chan *e2.CtrlMsg,
-	ctrlReqChsSliceUpdate map[string]chan *e2.CtrlMsg,
-	ctrlReqChsSliceDelete map[string]chan *e2.CtrlMsg,
-	ctrlReqChsUeAssociate map[string]chan *e2.CtrlMsg) Option {
+func WithControlDispatcher(ctrlDispatcher *e2.ControlDispatcher) Option {
 	return newOption(func(options *Options) {
-		options.Chans.CtrlReqChsSliceCreate = ctrlReqChsSliceCreate
-		options.Chans.CtrlReqChsSliceUpdate = ctrlReqChsSliceUpdate
-		options.Chans.CtrlReqChsSliceDelete = ctrlReqChsSliceDelete
-		options.Chans.CtrlReqChsUeAssociate = ctrlReqChsUeAssociate
+		options.App.CtrlDispatcher = ctrlDispatcher
 	})
 }
 
@@ -85,8 +93,61 @@ func WithUenibClient(uenibClient uenib.Client) Option {
 	})
 }
 
+// WithCapabilityCache sets the cache of the E2 node capabilities used to enforce the DU limits
+func WithCapabilityCache(cache *rnib.CapabilityCache) Option {
+	return newOption(func(options *Options) {
+		options.App.CapabilityCache = cache
+	})
+}
+
 func WithAckTimer(ackTimer int) Option {
 	return newOption(func(options *Options) {
 		options.App.AckTimer = ackTimer
 	})
 }
+
+// WithControlRetryPolicy sets the retry policy of the E2 manager, so that the ACK timer covers the retries
+func WithControlRetryPolicy(policy e2.RetryPolicy) Option {
+	return newOption(func(options *Options) {
+		options.App.CtrlRetryPolicy = policy
+	})
+}
+
+func WithCompensation(policy CompensationPolicy, journalPath string) Option {
+	return newOption(func(options *Options) {
+		options.App.CompensationPolicy = policy
+		options.App.JournalPath = journalPath
+	})
+}
+
+func WithNodeEventCh(nodeEventCh chan e2.NodeEvent) Option {
+	return newOption(func(options *Options) {
+		options.Chans.NodeEventCh = nodeEventCh
+	})
+}
+
+func WithReconnectPolicy(policy ReconnectPolicy) Option {
+	return newOption(func(options *Options) {
+		options.App.ReconnectPolicy = policy
+	})
+}
+
+// WithUEIdentityResolver sets the resolver whose map of UE identity keys is used to look up UEs
+func WithUEIdentityResolver(resolver *monitoring.UEIdentityResolver) Option {
+	return newOption(func(options *Options) {
+		options.App.IdentityResolver = resolver
+	})
+}
+
+func WithHandoverCh(handoverCh chan monitoring.HandoverEvent) Option {
+	return newOption(func(options *Options) {
+		options.Chans.HandoverCh = handoverCh
+	})
+}
+
+// WithRepairCh sets the channel of the drift repairs, which are run by the dispatcher
+func WithRepairCh(repairCh chan *drift.Repair) Option {
+	return newOption(func(options *Options) {
+		options.Chans.RepairCh = repairCh
+	})
+}
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"sync"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+)
+
+// outcomeRecorder keeps the control outcomes of the NBI request being handled, so that the
+// structured ACK of the E2 node reaches the northbound response
+type outcomeRecorder struct {
+	mu        sync.Mutex
+	recording bool
+	last      *api.ControlOutcome
+	// failed is the first failed control of the request; the controls after it are rollbacks
+	failed *api.ControlOutcome
+}
+
+func newOutcomeRecorder() *outcomeRecorder {
+	return &outcomeRecorder{}
+}
+
+func (r *outcomeRecorder) begin() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = true
+	r.last = nil
+	r.failed = nil
+}
+
+func (r *outcomeRecorder) record(nodeID topoapi.ID, ack e2.Ack) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.recording {
+		return
+	}
+	outcome := ack.ControlOutcome(string(nodeID))
+	r.last = outcome
+	if !ack.Success && r.failed == nil {
+		r.failed = outcome
+	}
+}
+
+// end returns the outcome which decided the result of the request: the failed control of a failed request,
+// otherwise the last control
+func (r *outcomeRecorder) end(success bool) *api.ControlOutcome {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.recording = false
+	if !success && r.failed != nil {
+		return r.failed
+	}
+	return r.last
+}
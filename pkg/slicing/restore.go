@@ -0,0 +1,511 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+)
+
+const (
+	restoreRetryInterval = 10 * time.Second
+	restoreTimeout       = 5 * time.Minute
+)
+
+// ReconnectPolicy defines what happens to the slices of a DU when it reconnects
+type ReconnectPolicy string
+
+const (
+	// ReconnectPolicyRestore replays the last-known slices and UE associations on the DU
+	ReconnectPolicyRestore ReconnectPolicy = "restore"
+	// ReconnectPolicyDrop discards the last-known slices and UE associations of the DU
+	ReconnectPolicyDrop ReconnectPolicy = "drop"
+)
+
+// pendingAssociation is a UE-slice association to be replayed once the UE is back on the DU;
+// ueID is the UE as last known in onos-topo, whose DU UE F1AP ID may have been given to another UE since
+type pendingAssociation struct {
+	ueID       *topoapi.UeIdentity
+	globalUeID string
+	dlSliceID  string
+	ulSliceID  string
+}
+
+// restoredAssociation is a UE-slice association replayed on the DU for the UE with the ueID it has now
+type restoredAssociation struct {
+	nodeID topoapi.ID
+	assoc  *pendingAssociation
+	ue     *uenib_api.RsmUeInfo
+	ueID   *topoapi.UeIdentity
+}
+
+type pendingRestore struct {
+	deadline     time.Time
+	associations []*pendingAssociation
+}
+
+func newRestorer(policy ReconnectPolicy) *restorer {
+	if policy == "" {
+		policy = ReconnectPolicyRestore
+	}
+	return &restorer{
+		policy:    policy,
+		lastKnown: make(map[topoapi.ID][]*topoapi.RSMSlicingItem),
+		pending:   make(map[topoapi.ID]*pendingRestore),
+		kick:      make(chan struct{}, 1),
+		restored:  make(chan *restoredAssociation),
+	}
+}
+
+// restorer keeps the last-known slice configuration of each DU
+type restorer struct {
+	policy    ReconnectPolicy
+	mu        sync.RWMutex
+	lastKnown map[topoapi.ID][]*topoapi.RSMSlicingItem
+	pending   map[topoapi.ID]*pendingRestore
+	// kick triggers the replay of the pending associations
+	kick chan struct{}
+	// restored has the associations replayed on the DUs whose NIB update is pending
+	restored chan *restoredAssociation
+}
+
+func (r *restorer) setLastKnown(nodeID topoapi.ID, items []*topoapi.RSMSlicingItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	copied := make([]*topoapi.RSMSlicingItem, 0, len(items))
+	for _, item := range items {
+		copied = append(copied, proto.Clone(item).(*topoapi.RSMSlicingItem))
+	}
+	r.lastKnown[nodeID] = copied
+}
+
+func (r *restorer) getLastKnown(nodeID topoapi.ID) ([]*topoapi.RSMSlicingItem, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	items, ok := r.lastKnown[nodeID]
+	return items, ok
+}
+
+// rememberSlices records the slices of the node currently stored in onos-topo as its last-known configuration;
+// if the node has no slice list in onos-topo (e.g., it was just removed), the last-known configuration is kept
+func (m *Manager) rememberSlices(ctx context.Context, nodeID topoapi.ID) {
+	items, err := m.slices.GetRsmSliceItemAspects(ctx, nodeID)
+	if err != nil {
+		log.Debugf("keeping last-known slices of node %v: %v", nodeID, err)
+		return
+	}
+	m.restorer.setLastKnown(nodeID, items)
+}
+
+func (m *Manager) handleNodeEvent(ctx context.Context, event e2.NodeEvent) {
+	switch event.Type {
+	case e2.NodeConnected:
+		// the DU may come back with a different software version
+		delete(m.multiBearerUnsupported, event.NodeID)
+		m.handleNodeConnected(ctx, event.NodeID)
+	case e2.NodeDisconnected:
+		delete(m.multiBearerUnsupported, event.NodeID)
+		m.restorer.mu.Lock()
+		delete(m.restorer.pending, event.NodeID)
+		m.restorer.mu.Unlock()
+	}
+}
+
+func (m *Manager) handleNodeConnected(ctx context.Context, nodeID topoapi.ID) {
+	items, ok := m.restorer.getLastKnown(nodeID)
+	if !ok {
+		// first connection since onos-rsm started - whatever is in onos-topo is the current configuration
+		m.rememberSlices(ctx, nodeID)
+		return
+	}
+
+	if m.restorer.policy == ReconnectPolicyDrop {
+		log.Infof("Node %v reconnected - dropping its last-known slices %v", nodeID, items)
+		m.restorer.mu.Lock()
+		delete(m.restorer.lastKnown, nodeID)
+		m.restorer.mu.Unlock()
+		return
+	}
+
+	log.Infof("Node %v reconnected - restoring its last-known slices %v", nodeID, items)
+	associations := make(map[string]*pendingAssociation)
+	for _, item := range items {
+		err := m.restoreSlice(ctx, nodeID, item)
+		if err != nil {
+			log.Warnf("failed to restore slice %v (%v) on node %v: %v", item.GetID(), item.GetSliceType(), nodeID, err)
+			continue
+		}
+		for _, ueID := range item.GetUeIdList() {
+			key := fmt.Sprintf("%d/%d", ueID.GetDuUeF1apID().GetValue(), rnib.DrbValue(ueID.GetDrbId()))
+			assoc, ok := associations[key]
+			if !ok {
+				assoc = &pendingAssociation{
+					ueID: ueID,
+				}
+				associations[key] = assoc
+			}
+			switch item.GetSliceType() {
+			case topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE:
+				assoc.dlSliceID = item.GetID()
+			case topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE:
+				assoc.ulSliceID = item.GetID()
+			}
+		}
+	}
+
+	if len(associations) == 0 {
+		return
+	}
+	restore := &pendingRestore{
+		deadline:     time.Now().Add(restoreTimeout),
+		associations: make([]*pendingAssociation, 0, len(associations)),
+	}
+	for _, assoc := range associations {
+		restore.associations = append(restore.associations, assoc)
+	}
+	m.restorer.mu.Lock()
+	m.restorer.pending[nodeID] = restore
+	m.restorer.mu.Unlock()
+	select {
+	case m.restorer.kick <- struct{}{}:
+	default:
+	}
+}
+
+// restoreSlice sends SLICE_CREATE for a last-known slice and adds it to onos-topo if it is missing
+func (m *Manager) restoreSlice(ctx context.Context, nodeID topoapi.ID, item *topoapi.RSMSlicingItem) error {
+	sliceConfig, err := sliceConfigFromTopo(item)
+	if err != nil {
+		return err
+	}
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE, sliceConfig, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create the control message - %v", err.Error())
+	}
+	err = m.sendCtrlMsg(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE, nodeID, ctrlMsg)
+	if err != nil {
+		return err
+	}
+
+	if m.slices.HasRsmSliceItemAspect(ctx, nodeID, item.GetID(), rsmapi.SliceType(item.GetSliceType())) {
+		return nil
+	}
+	// UEs are added back to the slice once their association is restored
+	value := &sliceItemDelta{
+		ID:         item.GetID(),
+		SliceType:  item.GetSliceType(),
+		SliceDesc:  item.GetSliceDesc(),
+		Parameters: item.GetSliceParameters(),
+	}
+	if value.Parameters == nil {
+		value.Parameters = &topoapi.RSMSliceParameters{}
+	}
+	return m.writeNIB(ctx, nibWrite{kind: nibWriteAddSliceItem, nodeID: nodeID, item: value}, nil,
+		"failed to create slice information to onos-topo although control message was sent")
+}
+
+// runRestores replays the pending UE associations until the context is done; the control messages are sent here,
+// so that the dispatcher does not wait for them, and the NIBs are updated by the dispatcher
+func (m *Manager) runRestores(ctx context.Context) {
+	ticker := time.NewTicker(restoreRetryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-m.draining:
+			return
+		case <-ticker.C:
+		case <-m.restorer.kick:
+		}
+		m.restoreAssociations(ctx)
+	}
+}
+
+// restoreAssociations replays the pending UE associations; associations which cannot be restored
+// before the deadline (e.g., the UE never attached again) are dropped
+func (m *Manager) restoreAssociations(ctx context.Context) {
+	for _, snapshot := range m.restorer.snapshot() {
+		nodeID, restore := snapshot.nodeID, snapshot.restore
+		for _, assoc := range snapshot.associations {
+			select {
+			case <-m.draining:
+				return
+			default:
+			}
+			restored, err := m.restoreAssociation(ctx, nodeID, assoc)
+			if err != nil {
+				log.Debugf("failed to restore association of DU UE F1AP ID %v on node %v: %v", assoc.ueID.GetDuUeF1apID().GetValue(), nodeID, err)
+				continue
+			}
+			select {
+			case m.restorer.restored <- restored:
+			case <-ctx.Done():
+				return
+			}
+			m.restorer.done(nodeID, restore, assoc)
+		}
+		m.restorer.expire(nodeID, restore)
+	}
+}
+
+// restoreSnapshot is a copy of the pending associations of a node
+type restoreSnapshot struct {
+	nodeID       topoapi.ID
+	restore      *pendingRestore
+	associations []*pendingAssociation
+}
+
+// snapshot returns a copy of the pending restores, so that they are replayed without holding the lock
+func (r *restorer) snapshot() []restoreSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snapshots := make([]restoreSnapshot, 0, len(r.pending))
+	for nodeID, restore := range r.pending {
+		snapshots = append(snapshots, restoreSnapshot{
+			nodeID:       nodeID,
+			restore:      restore,
+			associations: append([]*pendingAssociation{}, restore.associations...),
+		})
+	}
+	return snapshots
+}
+
+// done removes the restored association; the restores of a node replaced or removed meanwhile are left as they are
+func (r *restorer) done(nodeID topoapi.ID, restore *pendingRestore, assoc *pendingAssociation) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending[nodeID] != restore {
+		return
+	}
+	for i, a := range restore.associations {
+		if a == assoc {
+			restore.associations = append(restore.associations[:i], restore.associations[i+1:]...)
+			break
+		}
+	}
+	if len(restore.associations) == 0 {
+		log.Infof("Restored all UE associations on node %v", nodeID)
+		delete(r.pending, nodeID)
+	}
+}
+
+// expire drops the restores of the node once their deadline is over
+func (r *restorer) expire(nodeID topoapi.ID, restore *pendingRestore) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.pending[nodeID] != restore || time.Now().Before(restore.deadline) {
+		return
+	}
+	log.Warnf("Dropping %d UE associations on node %v which could not be restored", len(restore.associations), nodeID)
+	delete(r.pending, nodeID)
+}
+
+// restoredUE looks up the UE of a last-known association in UE-NIB by its global UE ID;
+// the UE has to be back on the DU, with the DU UE F1AP ID it has now
+func (m *Manager) restoredUE(ctx context.Context, nodeID topoapi.ID, assoc *pendingAssociation) (*uenib_api.RsmUeInfo, error) {
+	if assoc.globalUeID == "" {
+		globalUeID, err := m.lookupGlobalUeID(ctx, nodeID, assoc.ueID)
+		if err != nil {
+			return nil, err
+		}
+		assoc.globalUeID = globalUeID
+	}
+	ue, err := m.uenibClient.GetUEWithGlobalID(ctx, assoc.globalUeID)
+	if err != nil {
+		return nil, err
+	}
+	if ue.GetDuE2NodeId() != string(nodeID) {
+		return nil, errors.NewUnavailable(fmt.Sprintf("UE %v is not back on node %v", assoc.globalUeID, nodeID))
+	}
+	return ue, nil
+}
+
+// lookupGlobalUeID returns the global UE ID of a UE stored in onos-topo by its RAN UE NGAP ID on the CU,
+// which the DU does not change, or by its AMF UE NGAP ID
+func (m *Manager) lookupGlobalUeID(ctx context.Context, nodeID topoapi.ID, ueID *topoapi.UeIdentity) (string, error) {
+	cuNodeID, err := m.rnibClient.GetSourceCUE2NodeID(ctx, nodeID)
+	if err != nil {
+		return "", err
+	}
+	ranUeNgapID := ueID.GetRANUeNgapID().GetValue()
+	amfUeNgapID := ueID.GetAMFUeNgapID().GetValue()
+	if ranUeNgapID != 0 && m.identities != nil {
+		key := monitoring.UEIdentityKey{
+			Type:  uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID,
+			Scope: string(cuNodeID),
+			Value: ranUeNgapID,
+		}
+		if globalUeID, ok := m.identities.Lookup(key); ok {
+			return globalUeID, nil
+		}
+	}
+	if ranUeNgapID == 0 && amfUeNgapID == 0 {
+		return "", errors.NewNotFound(fmt.Sprintf("UE with DU UE F1AP ID %v on node %v has neither a RAN UE NGAP ID nor an AMF UE NGAP ID", ueID.GetDuUeF1apID().GetValue(), nodeID))
+	}
+	ues, err := m.uenibClient.GetUEs(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, ue := range ues {
+		if ue.GetCuE2NodeId() != string(cuNodeID) {
+			continue
+		}
+		if (ranUeNgapID == 0 || ue.GetUeIdList().GetRANUeNgapID().GetValue() == ranUeNgapID) &&
+			(amfUeNgapID == 0 || ue.GetUeIdList().GetAMFUeNgapID().GetValue() == amfUeNgapID) {
+			return ue.GetGlobalUeID(), nil
+		}
+	}
+	return "", errors.NewNotFound(fmt.Sprintf("UE with RAN UE NGAP ID %v and AMF UE NGAP ID %v is not attached to CU %v", ranUeNgapID, amfUeNgapID, cuNodeID))
+}
+
+// restoreAssociation sends UE_ASSOCIATE for a last-known association to the UE as it is now on the DU
+func (m *Manager) restoreAssociation(ctx context.Context, nodeID topoapi.ID, assoc *pendingAssociation) (*restoredAssociation, error) {
+	ue, err := m.restoredUE(ctx, nodeID, assoc)
+	if err != nil {
+		return nil, err
+	}
+	drbID := rnib.DrbValue(assoc.ueID.GetDrbId())
+	hasBearer := false
+	for _, bID := range ue.GetBearerIdList() {
+		if uenib.DrbValue(bID.GetDrbId()) == drbID {
+			hasBearer = true
+		}
+	}
+	if !hasBearer {
+		return nil, errors.NewUnavailable(fmt.Sprintf("UE %v has no DRB %v on node %v yet", ue.GetGlobalUeID(), drbID, nodeID))
+	}
+
+	duUeF1apID := ue.GetUeIdList().GetDuUeF1apID().GetValue()
+	sliceAssoc := &e2sm_rsm.SliceAssociate{
+		DownLinkSliceId: &e2sm_rsm.SliceIdassoc{},
+		UeId: &e2sm_rsm.UeIdentity{
+			UeIdentity: &e2sm_rsm.UeIdentity_DuUeF1ApId{
+				DuUeF1ApId: &e2sm_rsm.DuUeF1ApId{
+					Value: duUeF1apID,
+				},
+			},
+		},
+		BearerId: []*e2sm_rsm.BearerId{bearerIDFromTopo(assoc.ueID.GetDrbId())},
+	}
+	if assoc.dlSliceID != "" {
+		dlSliceID, err := strconv.Atoi(assoc.dlSliceID)
+		if err != nil {
+			return nil, err
+		}
+		sliceAssoc.DownLinkSliceId.Value = int64(dlSliceID)
+	}
+	if assoc.ulSliceID != "" {
+		ulSliceID, err := strconv.Atoi(assoc.ulSliceID)
+		if err != nil {
+			return nil, err
+		}
+		sliceAssoc.UplinkSliceId = &e2sm_rsm.SliceIdassoc{
+			Value: int64(ulSliceID),
+		}
+	}
+
+	ctrlMsg, err := m.ctrlMsgHandler.CreateControlRequest(nodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE, nil, sliceAssoc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the control message - %v", err)
+	}
+	// the outcome is not recorded, as it does not belong to the NBI request the dispatcher may be handling
+	err = m.sendControl(ctx, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE, nodeID, ctrlMsg, false)
+	if err != nil {
+		return nil, err
+	}
+
+	ueID := proto.Clone(assoc.ueID).(*topoapi.UeIdentity)
+	ueID.DuUeF1apID = &topoapi.DuUeF1ApID{
+		Value: duUeF1apID,
+	}
+	ueID.CuUeF1apID = &topoapi.CuUeF1ApID{
+		Value: ue.GetUeIdList().GetCuUeF1apID().GetValue(),
+	}
+	return &restoredAssociation{
+		nodeID: nodeID,
+		assoc:  assoc,
+		ue:     ue,
+		ueID:   ueID,
+	}, nil
+}
+
+// handleRestoredAssociation adds the UE of an association replayed on the DU back to the slices in the NIBs
+func (m *Manager) handleRestoredAssociation(ctx context.Context, restored *restoredAssociation) {
+	m.compensator.begin()
+	defer func() {
+		if recoveries := m.compensator.end(); recoveries != "" {
+			log.Infof("Recoveries during the restore of UE %v on node %v: %v", restored.ue.GetGlobalUeID(), restored.nodeID, recoveries)
+		}
+	}()
+
+	nodeID := restored.nodeID
+	drbID := rnib.DrbValue(restored.ueID.GetDrbId())
+	delta := &ueSliceDelta{
+		GlobalUeID: restored.ue.GetGlobalUeID(),
+	}
+	for _, slice := range []struct {
+		id        string
+		sliceType rsmapi.SliceType
+	}{{restored.assoc.dlSliceID, rsmapi.SliceType_SLICE_TYPE_DL_SLICE}, {restored.assoc.ulSliceID, rsmapi.SliceType_SLICE_TYPE_UL_SLICE}} {
+		if slice.id == "" {
+			continue
+		}
+		item, err := m.rnibClient.GetRsmSliceItemAspect(ctx, nodeID, slice.id, slice.sliceType)
+		if err != nil {
+			log.Warnf("failed to add UE %v back to slice %v on node %v: %v", restored.ue.GetGlobalUeID(), slice.id, nodeID, err)
+			continue
+		}
+		itemDelta := &sliceItemDelta{
+			ID:        slice.id,
+			SliceType: topoapi.RSMSliceType(slice.sliceType),
+			RemoveUEs: []ueBearerKey{bearerKey(restored.ueID)},
+			AddUEs:    []*topoapi.UeIdentity{restored.ueID},
+		}
+		err = m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateSliceItem, nodeID: nodeID, item: itemDelta}, nil, "failed to update slice item to onos-topo")
+		if err != nil {
+			log.Warn(err)
+		}
+
+		delta.Remove = append(delta.Remove, ueSliceKey{
+			SliceType: uenib_api.RSMSliceType(slice.sliceType),
+			DrbID:     drbID,
+		})
+		delta.Add = append(delta.Add, &uenib_api.SliceInfo{
+			DuE2NodeId: string(nodeID),
+			CuE2NodeId: restored.ue.GetCuE2NodeId(),
+			ID:         slice.id,
+			SliceParameters: &uenib_api.RSMSliceParameters{
+				SchedulerType: uenib_api.RSMSchedulerType(item.GetSliceParameters().GetSchedulerType()),
+				Weight:        item.GetSliceParameters().GetWeight(),
+				QosLevel:      item.GetSliceParameters().GetQosLevel(),
+			},
+			SliceType: uenib_api.RSMSliceType(slice.sliceType),
+			DrbId:     uenibDrbIDFromTopo(restored.ueID.GetDrbId()),
+		})
+	}
+
+	if len(delta.Add) > 0 {
+		err := m.writeNIB(ctx, nibWrite{kind: nibWriteUpdateUESlices, nodeID: nodeID, ue: delta}, nil, "failed to update UENIB")
+		if err != nil {
+			log.Warn(err)
+		}
+	}
+	m.rememberSlices(ctx, nodeID)
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testCUNodeID is the CU of the test DU by their E2 node IDs
+const testCUNodeID = topoapi.ID("e2:4/e00/2/64")
+
+func (s *slicingTest) createCU(ctx context.Context, t *testing.T) {
+	require.NoError(t, s.store.Create(ctx, &topoapi.Object{
+		ID:   testCUNodeID,
+		Type: topoapi.Object_ENTITY,
+		Obj:  &topoapi.Object_Entity{Entity: &topoapi.Entity{KindID: topoapi.E2NODE}},
+	}))
+}
+
+// testUE is a UE on the test DU with DRB 5
+func testUE(globalUeID string, duNodeID topoapi.ID, duUeF1apID int64, ranUeNgapID int64) *uenib_api.RsmUeInfo {
+	return &uenib_api.RsmUeInfo{
+		GlobalUeID: globalUeID,
+		UeIdList: &uenib_api.UeIdentity{
+			DuUeF1apID:  &uenib_api.DuUeF1ApID{Value: duUeF1apID},
+			CuUeF1apID:  &uenib_api.CuUeF1ApID{Value: duUeF1apID},
+			RANUeNgapID: &uenib_api.RanUeNgapID{Value: ranUeNgapID},
+		},
+		BearerIdList: []*uenib_api.BearerId{{
+			BearerId: &uenib_api.BearerId_DrbId{DrbId: &uenib_api.DrbId{
+				DrbId: &uenib_api.DrbId_FiveGdrbId{FiveGdrbId: &uenib_api.FiveGDrbId{
+					Value: 5,
+					Qfi:   &uenib_api.Qfi{Value: 1},
+					FlowsMapToDrb: []*uenib_api.QoSflowLevelParameters{{
+						QosFlowLevelParameters: &uenib_api.QoSflowLevelParameters_NonDynamicFiveQi{
+							NonDynamicFiveQi: &uenib_api.NonDynamicFiveQi{FiveQi: &uenib_api.FiveQi{Value: 9}},
+						},
+					}},
+				}},
+			}},
+		}},
+		CuE2NodeId: string(testCUNodeID),
+		DuE2NodeId: string(duNodeID),
+	}
+}
+
+// addUE adds the UE to UE-NIB and waits until its cache has the UE
+func (s *slicingTest) addUE(ctx context.Context, t *testing.T, ue *uenib_api.RsmUeInfo) {
+	require.NoError(t, s.uenibClient.AddUE(ctx, ue))
+	s.waitUE(ctx, t, ue)
+}
+
+// updateUE updates the UE in UE-NIB and waits until its cache has the update
+func (s *slicingTest) updateUE(ctx context.Context, t *testing.T, ue *uenib_api.RsmUeInfo) {
+	require.NoError(t, s.uenibClient.UpdateUE(ctx, ue))
+	s.waitUE(ctx, t, ue)
+}
+
+func (s *slicingTest) waitUE(ctx context.Context, t *testing.T, ue *uenib_api.RsmUeInfo) {
+	require.Eventually(t, func() bool {
+		cached, err := s.uenibClient.GetUEWithGlobalID(ctx, ue.GetGlobalUeID())
+		return err == nil && cached.GetDuE2NodeId() == ue.GetDuE2NodeId() &&
+			cached.GetUeIdList().GetDuUeF1apID().GetValue() == ue.GetUeIdList().GetDuUeF1apID().GetValue()
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func associateRequest(duUeF1apID string, sliceID string) *rsmapi.SetUeSliceAssociationRequest {
+	return &rsmapi.SetUeSliceAssociationRequest{
+		E2NodeId: string(testDUNodeID),
+		UeId: []*rsmapi.UeId{{
+			UeId: duUeF1apID,
+			Type: rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID,
+		}},
+		DlSliceId: sliceID,
+		DrbId:     "5",
+	}
+}
+
+func (n *testNode) sent() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.commands
+}
+
+// restoreAssociations replays the pending associations and handles the replayed ones like the dispatcher
+func (s *slicingTest) restoreAssociations(ctx context.Context) int {
+	done := make(chan struct{})
+	go func() {
+		s.manager.restoreAssociations(ctx)
+		close(done)
+	}()
+	restored := 0
+	for {
+		select {
+		case r := <-s.manager.restorer.restored:
+			s.manager.handleRestoredAssociation(ctx, r)
+			restored++
+		case <-done:
+			return restored
+		}
+	}
+}
+
+func (s *slicingTest) pendingAssociations() int {
+	s.manager.restorer.mu.RLock()
+	defer s.manager.restorer.mu.RUnlock()
+	if restore, ok := s.manager.restorer.pending[testDUNodeID]; ok {
+		return len(restore.associations)
+	}
+	return 0
+}
+
+// reconnect disconnects the DU, which comes back without its slices
+func (s *slicingTest) reconnect(ctx context.Context, t *testing.T) {
+	s.manager.handleNodeEvent(ctx, e2.NodeEvent{Type: e2.NodeDisconnected, NodeID: testDUNodeID})
+	require.NoError(t, s.topo.DeleteRsmSliceList(ctx, testDUNodeID))
+	s.manager.handleNodeEvent(ctx, e2.NodeEvent{Type: e2.NodeConnected, NodeID: testDUNodeID})
+}
+
+func TestRestoreOnReconnect(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	s.createCU(ctx, t)
+	s.addUE(ctx, t, testUE("ue-1", testDUNodeID, 1, 7))
+	require.True(t, s.request(ctx, createSliceRequest("1")).Success)
+	ack := s.request(ctx, associateRequest("1", "1"))
+	require.True(t, ack.Success, ack.Reason)
+	require.Equal(t, 2, s.node.sent())
+
+	// the UE is not back on the DU yet: the slice is restored, and its association waits for the UE
+	s.updateUE(ctx, t, testUE("ue-1", "", 1, 7))
+	s.reconnect(ctx, t)
+	assert.Equal(t, 3, s.node.sent())
+	assert.Empty(t, s.sliceUEs(ctx, t, "1"))
+	assert.Equal(t, 0, s.restoreAssociations(ctx))
+	assert.Equal(t, 1, s.pendingAssociations())
+
+	// the UE is back with another DU UE F1AP ID, which the association is restored for
+	s.updateUE(ctx, t, testUE("ue-1", testDUNodeID, 2, 7))
+	assert.Equal(t, 1, s.restoreAssociations(ctx))
+	assert.Equal(t, 4, s.node.sent())
+	assert.Equal(t, 0, s.pendingAssociations())
+	assert.Equal(t, []int64{2}, s.sliceUEs(ctx, t, "1"))
+	ue, err := s.uenibClient.GetUEWithGlobalID(ctx, "ue-1")
+	require.NoError(t, err)
+	require.Len(t, ue.GetSliceList(), 1)
+	assert.Equal(t, "1", ue.GetSliceList()[0].GetID())
+	assert.Equal(t, uenib_api.RSMSliceType_SLICE_TYPE_DL_SLICE, ue.GetSliceList()[0].GetSliceType())
+
+	// the restored configuration is the last-known one of the next reconnection
+	items, ok := s.manager.restorer.getLastKnown(testDUNodeID)
+	require.True(t, ok)
+	require.Len(t, items, 1)
+	assert.Equal(t, int64(2), items[0].GetUeIdList()[0].GetDuUeF1apID().GetValue())
+}
+
+func TestRestoreExpires(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	s.createCU(ctx, t)
+	s.addUE(ctx, t, testUE("ue-1", testDUNodeID, 1, 7))
+	require.True(t, s.request(ctx, createSliceRequest("1")).Success)
+	require.True(t, s.request(ctx, associateRequest("1", "1")).Success)
+
+	s.updateUE(ctx, t, testUE("ue-1", "", 1, 7))
+	s.reconnect(ctx, t)
+	require.Equal(t, 1, s.pendingAssociations())
+
+	// the UE never comes back
+	s.manager.restorer.mu.Lock()
+	s.manager.restorer.pending[testDUNodeID].deadline = time.Now().Add(-time.Second)
+	s.manager.restorer.mu.Unlock()
+	assert.Equal(t, 0, s.restoreAssociations(ctx))
+	assert.Equal(t, 0, s.pendingAssociations())
+}
+
+func TestDropOnReconnect(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry, WithReconnectPolicy(ReconnectPolicyDrop))
+	require.True(t, s.request(ctx, createSliceRequest("1")).Success)
+	require.Equal(t, 1, s.node.sent())
+
+	s.reconnect(ctx, t)
+	assert.Equal(t, 1, s.node.sent())
+	assert.False(t, s.topo.HasRsmSliceItemAspect(ctx, testDUNodeID, "1", rsmapi.SliceType_SLICE_TYPE_DL_SLICE))
+	_, ok := s.manager.restorer.getLastKnown(testDUNodeID)
+	assert.False(t, ok)
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+)
+
+// ueBearerKey selects UE bearers in the UE list of a slice item by DU UE F1AP ID, and by DRB ID if it is set
+type ueBearerKey struct {
+	DuUeF1apID int64 `json:"duUeF1apId"`
+	DrbID      int32 `json:"drbId,omitempty"`
+}
+
+func (k ueBearerKey) matches(ueID *topoapi.UeIdentity) bool {
+	return ueID.GetDuUeF1apID().GetValue() == k.DuUeF1apID &&
+		(k.DrbID == 0 || rnib.DrbValue(ueID.GetDrbId()) == k.DrbID)
+}
+
+func bearerKey(ueID *topoapi.UeIdentity) ueBearerKey {
+	return ueBearerKey{
+		DuUeF1apID: ueID.GetDuUeF1apID().GetValue(),
+		DrbID:      rnib.DrbValue(ueID.GetDrbId()),
+	}
+}
+
+// sliceItemDelta is a change of a slice item of a DU. Like a ueSliceDelta, it is applied to the slice item as it is
+// stored in onos-topo at the time of the write, so that a journaled write does not overwrite newer UE associations.
+type sliceItemDelta struct {
+	ID        string
+	SliceType topoapi.RSMSliceType
+	// Delete removes the slice item
+	Delete bool
+	// Parameters is set on the slice item, which is added if it is missing; SliceDesc is set if it is not empty
+	SliceDesc  string
+	Parameters *topoapi.RSMSliceParameters
+	// RemoveUEs selects the UE bearers removed from the slice item
+	RemoveUEs []ueBearerKey
+	// AddUEs has the UE bearers added to the slice item after the removal
+	AddUEs []*topoapi.UeIdentity
+}
+
+// apply returns the slice item after the change; it returns nil if the slice item is deleted, or is missing
+// and the change has no parameters to add it with
+func (d *sliceItemDelta) apply(item *topoapi.RSMSlicingItem) *topoapi.RSMSlicingItem {
+	if d.Delete {
+		return nil
+	}
+	if item == nil {
+		if d.Parameters == nil {
+			return nil
+		}
+		item = &topoapi.RSMSlicingItem{
+			ID:        d.ID,
+			SliceType: d.SliceType,
+		}
+	}
+	if d.SliceDesc != "" {
+		item.SliceDesc = d.SliceDesc
+	}
+	if d.Parameters != nil {
+		item.SliceParameters = proto.Clone(d.Parameters).(*topoapi.RSMSliceParameters)
+	}
+	ueIDList := make([]*topoapi.UeIdentity, 0, len(item.GetUeIdList())+len(d.AddUEs))
+	for _, ueID := range item.GetUeIdList() {
+		removed := false
+		for _, key := range d.RemoveUEs {
+			if key.matches(ueID) {
+				removed = true
+				break
+			}
+		}
+		if !removed {
+			ueIDList = append(ueIDList, ueID)
+		}
+	}
+	for _, ueID := range d.AddUEs {
+		ueIDList = append(ueIDList, proto.Clone(ueID).(*topoapi.UeIdentity))
+	}
+	item.UeIdList = ueIDList
+	return item
+}
+
+// inverse returns the delta which restores the slice item changed by the delta; item is the slice item
+// before the change, or nil if it was missing. It returns nil if the delta does not change the slice item.
+func (d *sliceItemDelta) inverse(item *topoapi.RSMSlicingItem) *sliceItemDelta {
+	inverse := &sliceItemDelta{
+		ID:        d.ID,
+		SliceType: d.SliceType,
+	}
+	if item == nil {
+		if d.Delete || d.Parameters == nil {
+			return nil
+		}
+		inverse.Delete = true
+		return inverse
+	}
+	if d.Delete || d.Parameters != nil {
+		inverse.SliceDesc = item.GetSliceDesc()
+		inverse.Parameters = item.GetSliceParameters()
+		if inverse.Parameters == nil {
+			inverse.Parameters = &topoapi.RSMSliceParameters{}
+		}
+	}
+	if d.Delete {
+		for _, ueID := range item.GetUeIdList() {
+			inverse.AddUEs = append(inverse.AddUEs, proto.Clone(ueID).(*topoapi.UeIdentity))
+		}
+		return inverse
+	}
+	inverse.RemoveUEs = append([]ueBearerKey{}, d.RemoveUEs...)
+	for _, ueID := range d.AddUEs {
+		inverse.RemoveUEs = append(inverse.RemoveUEs, bearerKey(ueID))
+	}
+	for _, ueID := range item.GetUeIdList() {
+		for _, key := range inverse.RemoveUEs {
+			if key.matches(ueID) {
+				inverse.AddUEs = append(inverse.AddUEs, proto.Clone(ueID).(*topoapi.UeIdentity))
+				break
+			}
+		}
+	}
+	return inverse
+}
+
+type sliceItemDeltaJSON struct {
+	ID         string                      `json:"id"`
+	SliceType  topoapi.RSMSliceType        `json:"sliceType"`
+	Delete     bool                        `json:"delete,omitempty"`
+	SliceDesc  string                      `json:"sliceDesc,omitempty"`
+	Parameters *topoapi.RSMSliceParameters `json:"parameters,omitempty"`
+	RemoveUEs  []ueBearerKey               `json:"removeUes,omitempty"`
+	AddUEs     []json.RawMessage           `json:"addUes,omitempty"`
+}
+
+// MarshalJSON encodes the UE bearers with jsonpb, which handles their DRB ID oneof
+func (d *sliceItemDelta) MarshalJSON() ([]byte, error) {
+	value := sliceItemDeltaJSON{
+		ID:         d.ID,
+		SliceType:  d.SliceType,
+		Delete:     d.Delete,
+		SliceDesc:  d.SliceDesc,
+		Parameters: d.Parameters,
+		RemoveUEs:  d.RemoveUEs,
+		AddUEs:     make([]json.RawMessage, 0, len(d.AddUEs)),
+	}
+	jm := jsonpb.Marshaler{}
+	for _, ueID := range d.AddUEs {
+		writer := bytes.Buffer{}
+		if err := jm.Marshal(&writer, ueID); err != nil {
+			return nil, err
+		}
+		value.AddUEs = append(value.AddUEs, writer.Bytes())
+	}
+	return json.Marshal(value)
+}
+
+func (d *sliceItemDelta) UnmarshalJSON(data []byte) error {
+	value := sliceItemDeltaJSON{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	d.ID = value.ID
+	d.SliceType = value.SliceType
+	d.Delete = value.Delete
+	d.SliceDesc = value.SliceDesc
+	d.Parameters = value.Parameters
+	d.RemoveUEs = value.RemoveUEs
+	d.AddUEs = make([]*topoapi.UeIdentity, 0, len(value.AddUEs))
+	for _, raw := range value.AddUEs {
+		ueID := &topoapi.UeIdentity{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(raw), ueID); err != nil {
+			return err
+		}
+		d.AddUEs = append(d.AddUEs, ueID)
+	}
+	return nil
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+)
+
+// A slice pair is the DL slice and the UL slice with the same slice ID on a DU created by a slice pair request,
+// which marks both slices with rnib.SlicePairDesc; the pair requests apply to both directions
+// and undo the first direction if the second one fails
+
+func (m *Manager) hasSlicePair(ctx context.Context, nodeID topoapi.ID, sliceID string) (bool, bool) {
+	return m.slices.HasRsmSliceItemAspect(ctx, nodeID, sliceID, rsmapi.SliceType_SLICE_TYPE_DL_SLICE),
+		m.slices.HasRsmSliceItemAspect(ctx, nodeID, sliceID, rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
+}
+
+// getSlicePair returns the DL and the UL slice of the slice pair
+func (m *Manager) getSlicePair(ctx context.Context, nodeID topoapi.ID, sliceID string) (*topoapi.RSMSlicingItem, *topoapi.RSMSlicingItem, error) {
+	hasDl, hasUl := m.hasSlicePair(ctx, nodeID, sliceID)
+	if !hasDl || !hasUl {
+		return nil, nil, errors.NewNotFound(fmt.Sprintf("slice pair %v does not exist on node %v (DL: %v, UL: %v)", sliceID, nodeID, hasDl, hasUl))
+	}
+	dl, err := m.rnibClient.GetRsmSliceItemAspect(ctx, nodeID, sliceID, rsmapi.SliceType_SLICE_TYPE_DL_SLICE)
+	if err != nil {
+		return nil, nil, err
+	}
+	ul, err := m.rnibClient.GetRsmSliceItemAspect(ctx, nodeID, sliceID, rsmapi.SliceType_SLICE_TYPE_UL_SLICE)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !rnib.IsSlicePairItem(dl) || !rnib.IsSlicePairItem(ul) {
+		return nil, nil, errors.NewNotFound(fmt.Sprintf("DL and UL slices %v on node %v were not created as a slice pair", sliceID, nodeID))
+	}
+	return dl, ul, nil
+}
+
+func (m *Manager) handleCreateSlicePairRequest(ctx context.Context, req *api.CreateSlicePairRequest, nodeID topoapi.ID) error {
+	log.Infof("Called Create Slice Pair: %v", req.SlicePair)
+	pair := req.SlicePair
+	if pair == nil || pair.ID == "" {
+		return errors.NewInvalid("slice pair ID is empty")
+	}
+	hasDl, hasUl := m.hasSlicePair(ctx, nodeID, pair.ID)
+	if hasDl || hasUl {
+		return errors.NewAlreadyExists(fmt.Sprintf("slice ID %v already exists (DL: %v, UL: %v)", pair.ID, hasDl, hasUl))
+	}
+	// both directions are checked first, so that the DL slice is not created if the UL one cannot be
+	if err := m.checkSliceLimit(ctx, nodeID, rsmapi.SliceType_SLICE_TYPE_DL_SLICE); err != nil {
+		return err
+	}
+	if err := m.checkSliceLimit(ctx, nodeID, rsmapi.SliceType_SLICE_TYPE_UL_SLICE); err != nil {
+		return err
+	}
+
+	err := m.createSlice(ctx, &rsmapi.CreateSliceRequest{
+		E2NodeId:      pair.E2NodeID,
+		SliceId:       pair.ID,
+		SchedulerType: pair.Dl.SchedulerType,
+		Weight:        pair.Dl.Weight,
+		SliceType:     rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+	}, nodeID, rnib.SlicePairDesc)
+	if err != nil {
+		return fmt.Errorf("failed to create DL slice of slice pair %v: %v", pair.ID, err)
+	}
+
+	err = m.createSlice(ctx, &rsmapi.CreateSliceRequest{
+		E2NodeId:      pair.E2NodeID,
+		SliceId:       pair.ID,
+		SchedulerType: pair.Ul.SchedulerType,
+		Weight:        pair.Ul.Weight,
+		SliceType:     rsmapi.SliceType_SLICE_TYPE_UL_SLICE,
+	}, nodeID, rnib.SlicePairDesc)
+	if err != nil {
+		rollbackErr := m.handleNbiDeleteSliceRequest(ctx, &rsmapi.DeleteSliceRequest{
+			E2NodeId:  pair.E2NodeID,
+			SliceId:   pair.ID,
+			SliceType: rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+		}, nodeID)
+		if rollbackErr != nil {
+			return fmt.Errorf("failed to create UL slice of slice pair %v: %v - failed to delete its DL slice: %v", pair.ID, err, rollbackErr)
+		}
+		return fmt.Errorf("failed to create UL slice of slice pair %v: %v - its DL slice was deleted", pair.ID, err)
+	}
+	return nil
+}
+
+func (m *Manager) handleUpdateSlicePairRequest(ctx context.Context, req *api.UpdateSlicePairRequest, nodeID topoapi.ID) error {
+	log.Infof("Called Update Slice Pair: %v", req.SlicePair)
+	pair := req.SlicePair
+	if pair == nil || pair.ID == "" {
+		return errors.NewInvalid("slice pair ID is empty")
+	}
+	prevDl, _, err := m.getSlicePair(ctx, nodeID, pair.ID)
+	if err != nil {
+		return err
+	}
+
+	err = m.handleNbiUpdateSliceRequest(ctx, &rsmapi.UpdateSliceRequest{
+		E2NodeId:      pair.E2NodeID,
+		SliceId:       pair.ID,
+		SchedulerType: pair.Dl.SchedulerType,
+		Weight:        pair.Dl.Weight,
+		SliceType:     rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+	}, nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to update DL slice of slice pair %v: %v", pair.ID, err)
+	}
+
+	err = m.handleNbiUpdateSliceRequest(ctx, &rsmapi.UpdateSliceRequest{
+		E2NodeId:      pair.E2NodeID,
+		SliceId:       pair.ID,
+		SchedulerType: pair.Ul.SchedulerType,
+		Weight:        pair.Ul.Weight,
+		SliceType:     rsmapi.SliceType_SLICE_TYPE_UL_SLICE,
+	}, nodeID)
+	if err != nil {
+		rollbackErr := m.handleNbiUpdateSliceRequest(ctx, &rsmapi.UpdateSliceRequest{
+			E2NodeId:      pair.E2NodeID,
+			SliceId:       pair.ID,
+			SchedulerType: rsmapi.SchedulerType(prevDl.GetSliceParameters().GetSchedulerType()),
+			Weight:        strconv.Itoa(int(prevDl.GetSliceParameters().GetWeight())),
+			SliceType:     rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+		}, nodeID)
+		if rollbackErr != nil {
+			return fmt.Errorf("failed to update UL slice of slice pair %v: %v - failed to revert its DL slice: %v", pair.ID, err, rollbackErr)
+		}
+		return fmt.Errorf("failed to update UL slice of slice pair %v: %v - its DL slice was reverted", pair.ID, err)
+	}
+	return nil
+}
+
+func (m *Manager) handleDeleteSlicePairRequest(ctx context.Context, req *api.DeleteSlicePairRequest, nodeID topoapi.ID) error {
+	log.Infof("Called Delete Slice Pair: %v", req)
+	_, prevUl, err := m.getSlicePair(ctx, nodeID, req.ID)
+	if err != nil {
+		return err
+	}
+
+	err = m.handleNbiDeleteSliceRequest(ctx, &rsmapi.DeleteSliceRequest{
+		E2NodeId:  req.E2NodeID,
+		SliceId:   req.ID,
+		SliceType: rsmapi.SliceType_SLICE_TYPE_UL_SLICE,
+	}, nodeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete UL slice of slice pair %v: %v", req.ID, err)
+	}
+
+	err = m.handleNbiDeleteSliceRequest(ctx, &rsmapi.DeleteSliceRequest{
+		E2NodeId:  req.E2NodeID,
+		SliceId:   req.ID,
+		SliceType: rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+	}, nodeID)
+	if err != nil {
+		// the UE associations of the UL slice are gone, but the slice itself is recreated
+		rollbackErr := m.createSlice(ctx, &rsmapi.CreateSliceRequest{
+			E2NodeId:      req.E2NodeID,
+			SliceId:       req.ID,
+			SchedulerType: rsmapi.SchedulerType(prevUl.GetSliceParameters().GetSchedulerType()),
+			Weight:        strconv.Itoa(int(prevUl.GetSliceParameters().GetWeight())),
+			SliceType:     rsmapi.SliceType_SLICE_TYPE_UL_SLICE,
+		}, nodeID, rnib.SlicePairDesc)
+		if rollbackErr != nil {
+			return fmt.Errorf("failed to delete DL slice of slice pair %v: %v - failed to recreate its UL slice: %v", req.ID, err, rollbackErr)
+		}
+		return fmt.Errorf("failed to delete DL slice of slice pair %v: %v - its UL slice was recreated", req.ID, err)
+	}
+	return nil
+}
+
+// handleSetUeSlicePairAssociationRequest associates the UE bearers with both slices of the pair in one control message
+func (m *Manager) handleSetUeSlicePairAssociationRequest(ctx context.Context, req *api.SetUeSlicePairAssociationRequest) (topoapi.ID, error) {
+	log.Infof("Called SetUeSlicePairAssociation: %v", req)
+	assocReq, err := m.resolveUE(ctx, &rsmapi.SetUeSliceAssociationRequest{
+		E2NodeId:  req.E2NodeID,
+		UeId:      req.UeID,
+		DlSliceId: req.SlicePairID,
+		UlSliceId: req.SlicePairID,
+		DrbId:     req.DrbID,
+	})
+	if err != nil {
+		return topoapi.ID(req.E2NodeID), err
+	}
+	nodeID := topoapi.ID(assocReq.GetE2NodeId())
+	if _, _, err := m.getSlicePair(ctx, nodeID, req.SlicePairID); err != nil {
+		return nodeID, err
+	}
+	return nodeID, m.handleNbiSetUeSliceAssociationRequest(ctx, assocReq, nodeID)
+}
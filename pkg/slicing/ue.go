@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+// resolveUE fills in the DU E2 node ID and all RAN UE IDs of the UE named in the association request.
+// The UE can be named by its DU UE F1AP ID together with the DU E2 node ID, by its CU UE F1AP ID together with
+// the DU or CU E2 node ID, by its AMF UE NGAP ID or RAN UE NGAP ID, or by its global UE ID in UE-NIB,
+// which is given as a UE ID with the api.GlobalUeIDPrefix.
+func (m *Manager) resolveUE(ctx context.Context, req *rsmapi.SetUeSliceAssociationRequest) (*rsmapi.SetUeSliceAssociationRequest, error) {
+	var globalUeID string
+	ids := make(map[rsmapi.UeIdType]int64)
+	for _, ueID := range req.GetUeId() {
+		if ueID.GetUeId() == "" {
+			continue
+		}
+		if strings.HasPrefix(ueID.GetUeId(), api.GlobalUeIDPrefix) {
+			globalUeID = strings.TrimPrefix(ueID.GetUeId(), api.GlobalUeIDPrefix)
+			if globalUeID == "" {
+				return nil, errors.NewInvalid(fmt.Sprintf("UE ID %v has no global UE ID", ueID.GetUeId()))
+			}
+			continue
+		}
+		value, err := strconv.ParseInt(ueID.GetUeId(), 10, 64)
+		if err != nil {
+			return nil, errors.NewInvalid(fmt.Sprintf("%v %v is not a number - a global UE ID needs the prefix %v", ueID.GetType(), ueID.GetUeId(), api.GlobalUeIDPrefix))
+		}
+		ids[ueID.GetType()] = value
+	}
+
+	if _, ok := ids[rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID]; ok && req.GetE2NodeId() != "" && globalUeID == "" {
+		// the UE is already named the way the DU knows it
+		return req, nil
+	}
+
+	var ue *uenib_api.RsmUeInfo
+	var err error
+	switch {
+	case globalUeID != "":
+		ue, err = m.uenibClient.GetUEWithGlobalID(ctx, globalUeID)
+	case hasUeID(ids, rsmapi.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID):
+		ue, err = m.findUE(ctx, req.GetE2NodeId(), uenib_api.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, ids[rsmapi.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID])
+	case hasUeID(ids, rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID):
+		ue, err = m.findUE(ctx, req.GetE2NodeId(), uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, ids[rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID])
+	case hasUeID(ids, rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID) && req.GetE2NodeId() != "":
+		ue, err = m.findUE(ctx, req.GetE2NodeId(), uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, ids[rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID])
+	default:
+		return nil, errors.NewInvalid("need du-ue-f1ap-id with the DU E2 node ID, cu-ue-f1ap-id with the E2 node ID, amf-ue-ngap-id, ran-ue-ngap-id or the global UE ID")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve UE %v: %v", req.GetUeId(), err)
+	}
+	if ue.GetDuE2NodeId() == "" {
+		return nil, errors.NewNotFound(fmt.Sprintf("UE %v is not served by any DU in UE-NIB", ue.GetGlobalUeID()))
+	}
+	if req.GetE2NodeId() != "" && req.GetE2NodeId() != ue.GetDuE2NodeId() && req.GetE2NodeId() != ue.GetCuE2NodeId() {
+		return nil, errors.NewInvalid(fmt.Sprintf("UE %v is served by DU %v, not by %v", ue.GetGlobalUeID(), ue.GetDuE2NodeId(), req.GetE2NodeId()))
+	}
+	log.Debugf("Resolved UE %v to global UE ID %v on DU %v", req.GetUeId(), ue.GetGlobalUeID(), ue.GetDuE2NodeId())
+
+	resolved := *req
+	resolved.E2NodeId = ue.GetDuE2NodeId()
+	resolved.UeId = []*rsmapi.UeId{
+		{
+			UeId: strconv.FormatInt(ue.GetUeIdList().GetDuUeF1apID().GetValue(), 10),
+			Type: rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID,
+		},
+		{
+			UeId: strconv.FormatInt(ue.GetUeIdList().GetCuUeF1apID().GetValue(), 10),
+			Type: rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID,
+		},
+		{
+			UeId: strconv.FormatInt(ue.GetUeIdList().GetRANUeNgapID().GetValue(), 10),
+			Type: rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID,
+		},
+		{
+			UeId: strconv.FormatInt(ue.GetUeIdList().GetAMFUeNgapID().GetValue(), 10),
+			Type: rsmapi.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID,
+		},
+		{
+			UeId: strconv.FormatInt(int64(ue.GetUeIdList().GetEnbUeS1apID().GetValue()), 10),
+			Type: rsmapi.UeIdType_UE_ID_TYPE_ENB_UE_S1_AP_ID,
+		},
+	}
+	return &resolved, nil
+}
+
+// findUE looks up the UE with the given ID; if the E2 node ID is set,
+// only the UEs of the node (or, for a DU, of its CU) are looked up
+func (m *Manager) findUE(ctx context.Context, e2NodeID string, idType uenib_api.UeIdType, id int64) (*uenib_api.RsmUeInfo, error) {
+	cuNodeID := e2NodeID
+	if e2NodeID != "" {
+		if cuID, err := m.rnibClient.GetSourceCUE2NodeID(ctx, topoapi.ID(e2NodeID)); err == nil {
+			cuNodeID = string(cuID)
+		}
+	}
+
+	// a RAN UE NGAP ID identifies a UE on its CU
+	if idType == uenib_api.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID && cuNodeID != "" && m.identities != nil {
+		key := monitoring.UEIdentityKey{
+			Type:  idType,
+			Scope: cuNodeID,
+			Value: id,
+		}
+		if globalUeID, ok := m.identities.Lookup(key); ok {
+			ue, err := m.uenibClient.GetUEWithGlobalID(ctx, globalUeID)
+			if err == nil && ue.GetCuE2NodeId() == cuNodeID && uenib.UEIDValue(ue.GetUeIdList(), idType) == id {
+				return ue, nil
+			}
+		}
+	}
+
+	ues, err := m.uenibClient.GetUEsWithPreferredID(ctx, cuNodeID, idType, id)
+	if err != nil {
+		return nil, err
+	}
+	if len(ues) > 1 {
+		return nil, errors.NewConflict(fmt.Sprintf("more than one UE with %v %v - please set the E2 node ID", idType, id))
+	}
+	if len(ues) == 0 {
+		return nil, errors.NewNotFound(fmt.Sprintf("no UE with %v %v", idType, id))
+	}
+	return ues[0], nil
+}
+
+func hasUeID(ids map[rsmapi.UeIdType]int64, idType rsmapi.UeIdType) bool {
+	_, ok := ids[idType]
+	return ok
+}
@@ -0,0 +1,171 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-rsm/api"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func resolveRequest(e2NodeID topoapi.ID, idType rsmapi.UeIdType, ueID string) *rsmapi.SetUeSliceAssociationRequest {
+	return &rsmapi.SetUeSliceAssociationRequest{
+		E2NodeId: string(e2NodeID),
+		UeId: []*rsmapi.UeId{{
+			UeId: ueID,
+			Type: idType,
+		}},
+		DlSliceId: "1",
+		DrbId:     "5",
+	}
+}
+
+func resolvedID(t *testing.T, req *rsmapi.SetUeSliceAssociationRequest, idType rsmapi.UeIdType) string {
+	for _, ueID := range req.GetUeId() {
+		if ueID.GetType() == idType {
+			return ueID.GetUeId()
+		}
+	}
+	require.Failf(t, "missing UE ID", "%v", idType)
+	return ""
+}
+
+func TestResolveUEByDUUEF1APID(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+
+	// the DU UE F1AP ID of a UE on the DU is used as it is, even if the UE is not in UE-NIB
+	req := resolveRequest(testDUNodeID, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, "1")
+	resolved, err := s.manager.resolveUE(ctx, req)
+	require.NoError(t, err)
+	assert.Same(t, req, resolved)
+
+	// without the DU, the DU UE F1AP ID does not name the UE
+	_, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, "1"))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestResolveUEByGlobalID(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	s.addUE(ctx, t, testUE("ue-1", testDUNodeID, 1, 7))
+
+	resolved, err := s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, api.GlobalUeIDPrefix+"ue-1"))
+	require.NoError(t, err)
+	assert.Equal(t, string(testDUNodeID), resolved.GetE2NodeId())
+	assert.Equal(t, "1", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID))
+	assert.Equal(t, "7", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID))
+	assert.Equal(t, "1", resolved.GetDlSliceId())
+
+	// the global UE ID wins over the DU UE F1AP ID
+	req := resolveRequest(testDUNodeID, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, "9")
+	req.UeId = append(req.UeId, &rsmapi.UeId{UeId: api.GlobalUeIDPrefix + "ue-1"})
+	resolved, err = s.manager.resolveUE(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "1", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID))
+
+	_, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, api.GlobalUeIDPrefix+"ue-2"))
+	assert.Error(t, err)
+}
+
+func TestResolveUEByNGAPID(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	ue := testUE("ue-1", testDUNodeID, 1, 7)
+	ue.UeIdList.AMFUeNgapID = &uenib_api.AmfUeNgapID{Value: 101}
+	s.addUE(ctx, t, ue)
+
+	resolved, err := s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID, "101"))
+	require.NoError(t, err)
+	assert.Equal(t, string(testDUNodeID), resolved.GetE2NodeId())
+	assert.Equal(t, "1", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID))
+
+	resolved, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, "7"))
+	require.NoError(t, err)
+	assert.Equal(t, "101", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_AMF_UE_NGAP_ID))
+
+	_, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, "8"))
+	assert.Error(t, err)
+}
+
+func TestResolveUEOfOtherCU(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	s.addUE(ctx, t, testUE("ue-1", testDUNodeID, 1, 7))
+	other := testUE("ue-2", "e2:4/e00/3/c9", 2, 7)
+	other.CuE2NodeId = "e2:4/e00/2/65"
+	s.addUE(ctx, t, other)
+
+	// the RAN UE NGAP ID is only unique in a CU
+	_, err := s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, "7"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "more than one UE")
+
+	resolved, err := s.manager.resolveUE(ctx, resolveRequest(testCUNodeID, rsmapi.UeIdType_UE_ID_TYPE_RAN_UE_NGAP_ID, "7"))
+	require.NoError(t, err)
+	assert.Equal(t, string(testDUNodeID), resolved.GetE2NodeId())
+	assert.Equal(t, "1", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID))
+}
+
+func TestResolveUEByCUUEF1APID(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	s.createCU(ctx, t)
+	ue := testUE("ue-1", testDUNodeID, 1, 7)
+	ue.UeIdList.CuUeF1apID.Value = 11
+	s.addUE(ctx, t, ue)
+
+	resolved, err := s.manager.resolveUE(ctx, resolveRequest(testCUNodeID, rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, "11"))
+	require.NoError(t, err)
+	assert.Equal(t, string(testDUNodeID), resolved.GetE2NodeId())
+	assert.Equal(t, "1", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID))
+
+	// named by the DU, the UE is looked up in the CU of the DU
+	require.Eventually(t, func() bool {
+		cuNodeID, err := s.topo.GetSourceCUE2NodeID(ctx, testDUNodeID)
+		return err == nil && cuNodeID == testCUNodeID
+	}, 5*time.Second, 10*time.Millisecond)
+	resolved, err = s.manager.resolveUE(ctx, resolveRequest(testDUNodeID, rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, "11"))
+	require.NoError(t, err)
+	assert.Equal(t, "1", resolvedID(t, resolved, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID))
+
+	// without the E2 node, the CU UE F1AP ID does not name the UE
+	_, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, "11"))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestResolveInvalidUEID(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+
+	_, err := s.manager.resolveUE(ctx, resolveRequest(testDUNodeID, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, "ue-1"))
+	assert.True(t, errors.IsInvalid(err))
+	_, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, api.GlobalUeIDPrefix))
+	assert.True(t, errors.IsInvalid(err))
+	_, err = s.manager.resolveUE(ctx, resolveRequest(testDUNodeID, rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, ""))
+	assert.True(t, errors.IsInvalid(err))
+}
+
+func TestResolveUEOnOtherNode(t *testing.T) {
+	ctx := context.Background()
+	s := newSlicingTest(ctx, t, CompensationPolicyRetry)
+	s.addUE(ctx, t, testUE("ue-1", testDUNodeID, 1, 7))
+	s.addUE(ctx, t, testUE("ue-2", "", 2, 8))
+
+	_, err := s.manager.resolveUE(ctx, resolveRequest("e2:4/e00/3/c9", rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, api.GlobalUeIDPrefix+"ue-1"))
+	assert.True(t, errors.IsInvalid(err))
+
+	// a UE that is not on a DU cannot be associated
+	_, err = s.manager.resolveUE(ctx, resolveRequest("", rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID, api.GlobalUeIDPrefix+"ue-2"))
+	assert.True(t, errors.IsNotFound(err))
+}
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package slicing
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+)
+
+// ueSliceKey selects slice entries of a UE by slice type, and by slice ID and DRB ID if they are set
+type ueSliceKey struct {
+	ID        string                 `json:"id,omitempty"`
+	SliceType uenib_api.RSMSliceType `json:"sliceType"`
+	DrbID     int32                  `json:"drbId,omitempty"`
+}
+
+func (k ueSliceKey) matches(slice *uenib_api.SliceInfo) bool {
+	return slice.GetSliceType() == k.SliceType &&
+		(k.ID == "" || slice.GetID() == k.ID) &&
+		(k.DrbID == 0 || uenib.DrbValue(slice.GetDrbId()) == k.DrbID)
+}
+
+func sliceKey(slice *uenib_api.SliceInfo) ueSliceKey {
+	return ueSliceKey{
+		ID:        slice.GetID(),
+		SliceType: slice.GetSliceType(),
+		DrbID:     uenib.DrbValue(slice.GetDrbId()),
+	}
+}
+
+// ueSliceDelta is a change of the slice list of a UE. It is applied to the UE as it is stored in UE-NIB
+// at the time of the write, so that a journaled write does not overwrite newer changes of the UE.
+type ueSliceDelta struct {
+	GlobalUeID string
+	// DuE2NodeID is set on the UE if it has no DU yet
+	DuE2NodeID string
+	// Remove selects the slice entries removed from the UE
+	Remove []ueSliceKey
+	// Parameters has the scheduler type and weight set on the slice entries selected by ParametersOf
+	ParametersOf *ueSliceKey
+	Parameters   *uenib_api.RSMSliceParameters
+	// Add has the slice entries added to the UE after the removal
+	Add []*uenib_api.SliceInfo
+}
+
+// apply changes the slice list of the UE
+func (d *ueSliceDelta) apply(ue *uenib_api.RsmUeInfo) {
+	if ue.GetDuE2NodeId() == "" {
+		ue.DuE2NodeId = d.DuE2NodeID
+	}
+	sliceList := make([]*uenib_api.SliceInfo, 0, len(ue.GetSliceList())+len(d.Add))
+	for _, slice := range ue.GetSliceList() {
+		removed := false
+		for _, key := range d.Remove {
+			if key.matches(slice) {
+				removed = true
+				break
+			}
+		}
+		if removed {
+			continue
+		}
+		if d.ParametersOf != nil && d.ParametersOf.matches(slice) {
+			if slice.SliceParameters == nil {
+				slice.SliceParameters = &uenib_api.RSMSliceParameters{}
+			}
+			slice.SliceParameters.SchedulerType = d.Parameters.GetSchedulerType()
+			slice.SliceParameters.Weight = d.Parameters.GetWeight()
+		}
+		sliceList = append(sliceList, slice)
+	}
+	for _, slice := range d.Add {
+		sliceList = append(sliceList, proto.Clone(slice).(*uenib_api.SliceInfo))
+	}
+	ue.SliceList = sliceList
+}
+
+// inverse returns the delta which restores the slice entries of the UE changed by the delta
+func (d *ueSliceDelta) inverse(ue *uenib_api.RsmUeInfo) *ueSliceDelta {
+	inverse := &ueSliceDelta{
+		GlobalUeID: d.GlobalUeID,
+		Remove:     append([]ueSliceKey{}, d.Remove...),
+		Add:        make([]*uenib_api.SliceInfo, 0),
+	}
+	for _, slice := range d.Add {
+		inverse.Remove = append(inverse.Remove, sliceKey(slice))
+	}
+	if d.ParametersOf != nil {
+		inverse.Remove = append(inverse.Remove, *d.ParametersOf)
+	}
+	for _, slice := range ue.GetSliceList() {
+		for _, key := range inverse.Remove {
+			if key.matches(slice) {
+				inverse.Add = append(inverse.Add, proto.Clone(slice).(*uenib_api.SliceInfo))
+				break
+			}
+		}
+	}
+	return inverse
+}
+
+type ueSliceDeltaJSON struct {
+	GlobalUeID   string                        `json:"globalUeId"`
+	DuE2NodeID   string                        `json:"duE2NodeId,omitempty"`
+	Remove       []ueSliceKey                  `json:"remove,omitempty"`
+	ParametersOf *ueSliceKey                   `json:"parametersOf,omitempty"`
+	Parameters   *uenib_api.RSMSliceParameters `json:"parameters,omitempty"`
+	Add          []json.RawMessage             `json:"add,omitempty"`
+}
+
+// MarshalJSON encodes the slice entries with jsonpb, which handles their DRB ID oneof
+func (d *ueSliceDelta) MarshalJSON() ([]byte, error) {
+	value := ueSliceDeltaJSON{
+		GlobalUeID:   d.GlobalUeID,
+		DuE2NodeID:   d.DuE2NodeID,
+		Remove:       d.Remove,
+		ParametersOf: d.ParametersOf,
+		Parameters:   d.Parameters,
+		Add:          make([]json.RawMessage, 0, len(d.Add)),
+	}
+	jm := jsonpb.Marshaler{}
+	for _, slice := range d.Add {
+		writer := bytes.Buffer{}
+		if err := jm.Marshal(&writer, slice); err != nil {
+			return nil, err
+		}
+		value.Add = append(value.Add, writer.Bytes())
+	}
+	return json.Marshal(value)
+}
+
+func (d *ueSliceDelta) UnmarshalJSON(data []byte) error {
+	value := ueSliceDeltaJSON{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	d.GlobalUeID = value.GlobalUeID
+	d.DuE2NodeID = value.DuE2NodeID
+	d.Remove = value.Remove
+	d.ParametersOf = value.ParametersOf
+	d.Parameters = value.Parameters
+	d.Add = make([]*uenib_api.SliceInfo, 0, len(value.Add))
+	for _, raw := range value.Add {
+		slice := &uenib_api.SliceInfo{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(raw), slice); err != nil {
+			return err
+		}
+		d.Add = append(d.Add, slice)
+	}
+	return nil
+}
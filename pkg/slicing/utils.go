@@ -3,3 +3,254 @@
 // SPDX-License-Identifier: Apache-2.0
 
 package slicing
+
+import (
+	"fmt"
+	"strconv"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	e2sm_v2_ies "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-v2-ies"
+)
+
+// sliceConfigFromTopo builds the E2SM-RSM slice configuration of a slice stored in onos-topo
+func sliceConfigFromTopo(item *topoapi.RSMSlicingItem) (*e2sm_rsm.SliceConfig, error) {
+	sliceID, err := strconv.Atoi(item.GetID())
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert slice id to int - %v", err.Error())
+	}
+
+	var sliceSchedulerType e2sm_rsm.SchedulerType
+	switch item.GetSliceParameters().GetSchedulerType() {
+	case topoapi.RSMSchedulerType_SCHEDULER_TYPE_ROUND_ROBIN:
+		sliceSchedulerType = e2sm_rsm.SchedulerType_SCHEDULER_TYPE_ROUND_ROBIN
+	case topoapi.RSMSchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR:
+		sliceSchedulerType = e2sm_rsm.SchedulerType_SCHEDULER_TYPE_PROPORTIONALLY_FAIR
+	case topoapi.RSMSchedulerType_SCHEDULER_TYPE_QOS_BASED:
+		sliceSchedulerType = e2sm_rsm.SchedulerType_SCHEDULER_TYPE_QOS_BASED
+	default:
+		sliceSchedulerType = e2sm_rsm.SchedulerType_SCHEDULER_TYPE_ROUND_ROBIN
+	}
+
+	var sliceType e2sm_rsm.SliceType
+	switch item.GetSliceType() {
+	case topoapi.RSMSliceType_SLICE_TYPE_DL_SLICE:
+		sliceType = e2sm_rsm.SliceType_SLICE_TYPE_DL_SLICE
+	case topoapi.RSMSliceType_SLICE_TYPE_UL_SLICE:
+		sliceType = e2sm_rsm.SliceType_SLICE_TYPE_UL_SLICE
+	default:
+		sliceType = e2sm_rsm.SliceType_SLICE_TYPE_DL_SLICE
+	}
+
+	weight := item.GetSliceParameters().GetWeight()
+	return &e2sm_rsm.SliceConfig{
+		SliceId: &e2sm_rsm.SliceId{
+			Value: int64(sliceID),
+		},
+		SliceConfigParameters: &e2sm_rsm.SliceParameters{
+			SchedulerType: sliceSchedulerType,
+			Weight:        &weight,
+		},
+		SliceType: sliceType,
+	}, nil
+}
+
+// bearerIDFromTopo builds the E2SM-RSM bearer ID of a DRB stored in onos-topo
+func bearerIDFromTopo(drbID *topoapi.DrbId) *e2sm_rsm.BearerId {
+	if drbID.GetFourGdrbId() != nil {
+		bearerID := &e2sm_rsm.BearerId{
+			BearerId: &e2sm_rsm.BearerId_DrbId{
+				DrbId: &e2sm_rsm.DrbId{
+					DrbId: &e2sm_rsm.DrbId_FourGdrbId{
+						FourGdrbId: &e2sm_rsm.FourGDrbId{
+							Value: drbID.GetFourGdrbId().GetValue(),
+						},
+					},
+				},
+			},
+		}
+		if drbID.GetFourGdrbId().GetQci() != nil {
+			bearerID.GetDrbId().GetFourGdrbId().Qci = &e2sm_v2_ies.Qci{
+				Value: drbID.GetFourGdrbId().GetQci().GetValue(),
+			}
+		}
+		return bearerID
+	}
+
+	bearerID := &e2sm_rsm.BearerId{
+		BearerId: &e2sm_rsm.BearerId_DrbId{
+			DrbId: &e2sm_rsm.DrbId{
+				DrbId: &e2sm_rsm.DrbId_FiveGdrbId{
+					FiveGdrbId: &e2sm_rsm.FiveGDrbId{
+						Value: drbID.GetFiveGdrbId().GetValue(),
+					},
+				},
+			},
+		},
+	}
+	if drbID.GetFiveGdrbId().GetQfi() != nil {
+		bearerID.GetDrbId().GetFiveGdrbId().Qfi = &e2sm_rsm.Qfi{
+			Value: drbID.GetFiveGdrbId().GetQfi().GetValue(),
+		}
+	}
+	for _, flow := range drbID.GetFiveGdrbId().GetFlowsMapToDrb() {
+		var param *e2sm_rsm.QoSflowLevelParameters
+		if flow.GetNonDynamicFiveQi() != nil {
+			param = &e2sm_rsm.QoSflowLevelParameters{
+				QoSflowLevelParameters: &e2sm_rsm.QoSflowLevelParameters_NonDynamicFiveQi{
+					NonDynamicFiveQi: &e2sm_rsm.NonDynamicFiveQi{
+						FiveQi: &e2sm_v2_ies.FiveQi{
+							Value: flow.GetNonDynamicFiveQi().GetFiveQi().GetValue(),
+						},
+					},
+				},
+			}
+		}
+		if flow.GetDynamicFiveQi() != nil {
+			param = &e2sm_rsm.QoSflowLevelParameters{
+				QoSflowLevelParameters: &e2sm_rsm.QoSflowLevelParameters_DynamicFiveQi{
+					DynamicFiveQi: &e2sm_rsm.DynamicFiveQi{
+						PriorityLevel:     flow.GetDynamicFiveQi().GetPriorityLevel(),
+						PacketDelayBudget: flow.GetDynamicFiveQi().GetPacketDelayBudge(),
+						PacketErrorRate:   flow.GetDynamicFiveQi().GetPacketErrorRate(),
+					},
+				},
+			}
+		}
+		if param != nil {
+			bearerID.GetDrbId().GetFiveGdrbId().FlowsMapToDrb = append(bearerID.GetDrbId().GetFiveGdrbId().FlowsMapToDrb, param)
+		}
+	}
+	return bearerID
+}
+
+// uenibDrbIDFromTopo converts a DRB ID stored in onos-topo to the UE-NIB DRB ID
+func uenibDrbIDFromTopo(drbID *topoapi.DrbId) *uenib_api.DrbId {
+	if drbID.GetFourGdrbId() != nil {
+		uenibDrbID := &uenib_api.DrbId{
+			DrbId: &uenib_api.DrbId_FourGdrbId{
+				FourGdrbId: &uenib_api.FourGDrbId{
+					Value: drbID.GetFourGdrbId().GetValue(),
+				},
+			},
+		}
+		if drbID.GetFourGdrbId().GetQci() != nil {
+			uenibDrbID.GetFourGdrbId().Qci = &uenib_api.Qci{
+				Value: drbID.GetFourGdrbId().GetQci().GetValue(),
+			}
+		}
+		return uenibDrbID
+	}
+
+	uenibDrbID := &uenib_api.DrbId{
+		DrbId: &uenib_api.DrbId_FiveGdrbId{
+			FiveGdrbId: &uenib_api.FiveGDrbId{
+				Value: drbID.GetFiveGdrbId().GetValue(),
+			},
+		},
+	}
+	if drbID.GetFiveGdrbId().GetQfi() != nil {
+		uenibDrbID.GetFiveGdrbId().Qfi = &uenib_api.Qfi{
+			Value: drbID.GetFiveGdrbId().GetQfi().GetValue(),
+		}
+	}
+	for _, flow := range drbID.GetFiveGdrbId().GetFlowsMapToDrb() {
+		var param *uenib_api.QoSflowLevelParameters
+		if flow.GetNonDynamicFiveQi() != nil {
+			param = &uenib_api.QoSflowLevelParameters{
+				QosFlowLevelParameters: &uenib_api.QoSflowLevelParameters_NonDynamicFiveQi{
+					NonDynamicFiveQi: &uenib_api.NonDynamicFiveQi{
+						FiveQi: &uenib_api.FiveQi{
+							Value: flow.GetNonDynamicFiveQi().GetFiveQi().GetValue(),
+						},
+					},
+				},
+			}
+		}
+		if flow.GetDynamicFiveQi() != nil {
+			param = &uenib_api.QoSflowLevelParameters{
+				QosFlowLevelParameters: &uenib_api.QoSflowLevelParameters_DynamicFiveQi{
+					DynamicFiveQi: &uenib_api.DynamicFiveQi{
+						PriorityLevel:    flow.GetDynamicFiveQi().GetPriorityLevel(),
+						PacketDelayBudge: flow.GetDynamicFiveQi().GetPacketDelayBudge(),
+						PacketErrorRate:  flow.GetDynamicFiveQi().GetPacketErrorRate(),
+					},
+				},
+			}
+		}
+		if param != nil {
+			uenibDrbID.GetFiveGdrbId().FlowsMapToDrb = append(uenibDrbID.GetFiveGdrbId().FlowsMapToDrb, param)
+		}
+	}
+	return uenibDrbID
+}
+
+// bearerIDFromUenib builds the E2SM-RSM bearer ID of a DRB stored in UE-NIB
+func bearerIDFromUenib(drbID *uenib_api.DrbId) *e2sm_rsm.BearerId {
+	if drbID.GetFourGdrbId() != nil {
+		bearerID := &e2sm_rsm.BearerId{
+			BearerId: &e2sm_rsm.BearerId_DrbId{
+				DrbId: &e2sm_rsm.DrbId{
+					DrbId: &e2sm_rsm.DrbId_FourGdrbId{
+						FourGdrbId: &e2sm_rsm.FourGDrbId{
+							Value: drbID.GetFourGdrbId().GetValue(),
+						},
+					},
+				},
+			},
+		}
+		if drbID.GetFourGdrbId().GetQci() != nil {
+			bearerID.GetDrbId().GetFourGdrbId().Qci = &e2sm_v2_ies.Qci{
+				Value: drbID.GetFourGdrbId().GetQci().GetValue(),
+			}
+		}
+		return bearerID
+	}
+
+	bearerID := &e2sm_rsm.BearerId{
+		BearerId: &e2sm_rsm.BearerId_DrbId{
+			DrbId: &e2sm_rsm.DrbId{
+				DrbId: &e2sm_rsm.DrbId_FiveGdrbId{
+					FiveGdrbId: &e2sm_rsm.FiveGDrbId{
+						Value: drbID.GetFiveGdrbId().GetValue(),
+					},
+				},
+			},
+		},
+	}
+	if drbID.GetFiveGdrbId().GetQfi() != nil {
+		bearerID.GetDrbId().GetFiveGdrbId().Qfi = &e2sm_rsm.Qfi{
+			Value: drbID.GetFiveGdrbId().GetQfi().GetValue(),
+		}
+	}
+	for _, flow := range drbID.GetFiveGdrbId().GetFlowsMapToDrb() {
+		var param *e2sm_rsm.QoSflowLevelParameters
+		if flow.GetNonDynamicFiveQi() != nil {
+			param = &e2sm_rsm.QoSflowLevelParameters{
+				QoSflowLevelParameters: &e2sm_rsm.QoSflowLevelParameters_NonDynamicFiveQi{
+					NonDynamicFiveQi: &e2sm_rsm.NonDynamicFiveQi{
+						FiveQi: &e2sm_v2_ies.FiveQi{
+							Value: flow.GetNonDynamicFiveQi().GetFiveQi().GetValue(),
+						},
+					},
+				},
+			}
+		}
+		if flow.GetDynamicFiveQi() != nil {
+			param = &e2sm_rsm.QoSflowLevelParameters{
+				QoSflowLevelParameters: &e2sm_rsm.QoSflowLevelParameters_DynamicFiveQi{
+					DynamicFiveQi: &e2sm_rsm.DynamicFiveQi{
+						PriorityLevel:     flow.GetDynamicFiveQi().GetPriorityLevel(),
+						PacketDelayBudget: flow.GetDynamicFiveQi().GetPacketDelayBudge(),
+						PacketErrorRate:   flow.GetDynamicFiveQi().GetPacketErrorRate(),
+					},
+				},
+			}
+		}
+		if param != nil {
+			bearerID.GetDrbId().GetFiveGdrbId().FlowsMapToDrb = append(bearerID.GetDrbId().GetFiveGdrbId().FlowsMapToDrb, param)
+		}
+	}
+	return bearerID
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"context"
+	goerrors "errors"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1/e2errors"
+	"github.com/onosproject/onos-rsm/api"
+)
+
+// Outcome is the outcome of a control message
+type Outcome string
+
+const (
+	// OutcomeSuccess is a control message acknowledged by the E2 node
+	OutcomeSuccess Outcome = "success"
+	// OutcomeFailure is a control message rejected by the E2 node with an E2AP control failure
+	OutcomeFailure Outcome = "failure"
+	// OutcomeError is a control message which did not reach the E2 node
+	OutcomeError Outcome = "error"
+	// OutcomeTimeout is a control message which was not acknowledged in time
+	OutcomeTimeout Outcome = "timeout"
+)
+
+// CauseGroup is the group of an E2AP cause
+type CauseGroup string
+
+const (
+	CauseGroupRIC        CauseGroup = "ric"
+	CauseGroupRICService CauseGroup = "ric-service"
+	CauseGroupProtocol   CauseGroup = "protocol"
+	CauseGroupMisc       CauseGroup = "misc"
+)
+
+type e2apCause struct {
+	group     CauseGroup
+	name      string
+	retryable bool
+}
+
+// e2apCauses has the causes of the E2AP errors; only the overload and resource limit causes are worth a retry
+var e2apCauses = map[e2errors.E2APType]e2apCause{
+	e2errors.RICUnspecified:                                       {CauseGroupRIC, "unspecified", false},
+	e2errors.RICRANFunctionIDInvalid:                              {CauseGroupRIC, "ran-function-id-invalid", false},
+	e2errors.RICActionNotSupported:                                {CauseGroupRIC, "action-not-supported", false},
+	e2errors.RICExcessiveActions:                                  {CauseGroupRIC, "excessive-actions", false},
+	e2errors.RICDuplicateAction:                                   {CauseGroupRIC, "duplicate-action", false},
+	e2errors.RICDuplicateEvent:                                    {CauseGroupRIC, "duplicate-event", false},
+	e2errors.RICFunctionResourceLimit:                             {CauseGroupRIC, "function-resource-limit", true},
+	e2errors.RICRequestIDUnknown:                                  {CauseGroupRIC, "request-id-unknown", false},
+	e2errors.RICInconsistentActionSubsequentActionSequence:        {CauseGroupRIC, "inconsistent-action-subsequent-action-sequence", false},
+	e2errors.RICControlMessageInvalid:                             {CauseGroupRIC, "control-message-invalid", false},
+	e2errors.RICCallProcessIDInvalid:                              {CauseGroupRIC, "call-process-id-invalid", false},
+	e2errors.RICServiceUnspecified:                                {CauseGroupRICService, "unspecified", false},
+	e2errors.RICServiceFunctionNotRequired:                        {CauseGroupRICService, "function-not-required", false},
+	e2errors.RICServiceExcessiveFunctions:                         {CauseGroupRICService, "excessive-functions", false},
+	e2errors.RICServiceRICResourceLimit:                           {CauseGroupRICService, "ric-resource-limit", true},
+	e2errors.ProtocolUnspecified:                                  {CauseGroupProtocol, "unspecified", false},
+	e2errors.ProtocolTransferSyntaxError:                          {CauseGroupProtocol, "transfer-syntax-error", false},
+	e2errors.ProtocolAbstractSyntaxErrorReject:                    {CauseGroupProtocol, "abstract-syntax-error-reject", false},
+	e2errors.ProtocolAbstractSyntaxErrorIgnoreAndNotify:           {CauseGroupProtocol, "abstract-syntax-error-ignore-and-notify", false},
+	e2errors.ProtocolMessageNotCompatibleWithReceiverState:        {CauseGroupProtocol, "message-not-compatible-with-receiver-state", true},
+	e2errors.ProtocolSemanticError:                                {CauseGroupProtocol, "semantic-error", false},
+	e2errors.ProtocolAbstractSyntaxErrorFalselyConstructedMessage: {CauseGroupProtocol, "abstract-syntax-error-falsely-constructed-message", false},
+	e2errors.MiscUnspecified:                                      {CauseGroupMisc, "unspecified", false},
+	e2errors.MiscControlProcessingOverload:                        {CauseGroupMisc, "control-processing-overload", true},
+	e2errors.MiscHardwareFailure:                                  {CauseGroupMisc, "hardware-failure", false},
+	e2errors.MiscOMIntervention:                                   {CauseGroupMisc, "om-intervention", false},
+}
+
+// NewAck builds the ACK of a control message from the control outcome or the error returned by the E2 node
+func NewAck(outcome *e2api.ControlOutcome, err error) Ack {
+	if err == nil && outcome != nil {
+		return Ack{
+			Success:        true,
+			Outcome:        OutcomeSuccess,
+			OutcomePayload: outcome.GetPayload(),
+		}
+	}
+	if err == nil {
+		return Ack{
+			Success:   false,
+			Reason:    "Ctrl Resp message is nil",
+			Outcome:   OutcomeError,
+			Retryable: true,
+		}
+	}
+
+	ack := Ack{
+		Success: false,
+		Reason:  err.Error(),
+		Outcome: OutcomeError,
+	}
+	var e2apErr *e2errors.TypedError
+	switch {
+	case goerrors.As(err, &e2apErr) && e2apErr.E2APType != e2errors.Unknown:
+		ack.Outcome = OutcomeFailure
+		if cause, ok := e2apCauses[e2apErr.E2APType]; ok {
+			ack.CauseGroup = cause.group
+			ack.Cause = cause.name
+			ack.Retryable = cause.retryable
+		}
+	case errors.IsTimeout(err) || goerrors.Is(err, context.DeadlineExceeded):
+		ack.Outcome = OutcomeTimeout
+		ack.Retryable = true
+	case errors.IsUnavailable(err):
+		ack.Retryable = true
+	}
+	return ack
+}
+
+// NewTimeoutAck is the ACK of a control message which was not acknowledged within the ACK timer
+func NewTimeoutAck(reason string) Ack {
+	return Ack{
+		Success:   false,
+		Reason:    reason,
+		Outcome:   OutcomeTimeout,
+		Retryable: true,
+	}
+}
+
+// ControlOutcome returns the northbound view of the ACK
+func (a Ack) ControlOutcome(nodeID string) *api.ControlOutcome {
+	outcome := &api.ControlOutcome{
+		E2NodeID:   nodeID,
+		Outcome:    string(a.Outcome),
+		CauseGroup: string(a.CauseGroup),
+		Cause:      a.Cause,
+		Retryable:  a.Retryable,
+		Retries:    a.Retries,
+		Message:    a.Reason,
+	}
+	if outcome.Outcome == "" {
+		outcome.Outcome = string(OutcomeError)
+		if a.Success {
+			outcome.Outcome = string(OutcomeSuccess)
+		}
+	}
+	return outcome
+}
@@ -0,0 +1,80 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"sort"
+	"sync"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// Codec encodes the E2SM-RSM messages sent to the E2 nodes and decodes the ones they send for a service model version.
+// The app works with the v1 messages; the codec of another version translates them to and from its own encoding.
+type Codec interface {
+	// Version is the service model version of the codec
+	Version() ServiceModelVersion
+	// OID is the OID the E2 nodes advertise for the service model version
+	OID() string
+	// ControlHeader encodes the control header of the command
+	ControlHeader(cmdType e2sm_rsm.E2SmRsmCommand) ([]byte, error)
+	// ControlPayload encodes the control message of the command
+	ControlPayload(cmdType e2sm_rsm.E2SmRsmCommand, sliceConfig *e2sm_rsm.SliceConfig, sliceAssoc *e2sm_rsm.SliceAssociate) ([]byte, error)
+	// EventTrigger encodes the event trigger definition of a subscription
+	EventTrigger(triggerType e2sm_rsm.RsmRicindicationTriggerType, reportingPeriodMs int32) ([]byte, error)
+	// DecodeControl decodes the header and the message of a control request
+	DecodeControl(header []byte, payload []byte) (*e2sm_rsm.E2SmRsmControlHeader, *e2sm_rsm.E2SmRsmControlMessage, error)
+	// DecodeIndication decodes the header and the message of an indication
+	DecodeIndication(indication e2api.Indication) (*e2sm_rsm.E2SmRsmIndicationHeader, *e2sm_rsm.E2SmRsmIndicationMessage, error)
+}
+
+// CodecResolver returns the codec of the service model version advertised by an E2 node
+type CodecResolver interface {
+	Codec(nodeID topoapi.ID) (Codec, error)
+}
+
+var codecs = struct {
+	mu       sync.RWMutex
+	versions map[ServiceModelVersion]Codec
+}{
+	versions: map[ServiceModelVersion]Codec{
+		v1Version: NewV1Codec(),
+	},
+}
+
+// RegisterCodec registers the codec of a service model version; the codec registered before for the version is replaced
+func RegisterCodec(codec Codec) {
+	codecs.mu.Lock()
+	defer codecs.mu.Unlock()
+	codecs.versions[codec.Version()] = codec
+}
+
+// GetCodec returns the codec of the service model version
+func GetCodec(version ServiceModelVersion) (Codec, error) {
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+	codec, ok := codecs.versions[version]
+	if !ok {
+		return nil, errors.NewNotSupported("no codec for service model version %v", version)
+	}
+	return codec, nil
+}
+
+// registeredCodecs returns the registered codecs in version order
+func registeredCodecs() []Codec {
+	codecs.mu.RLock()
+	defer codecs.mu.RUnlock()
+	list := make([]Codec, 0, len(codecs.versions))
+	for _, codec := range codecs.versions {
+		list = append(list, codec)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version() < list[j].Version()
+	})
+	return list
+}
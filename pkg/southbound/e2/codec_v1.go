@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"fmt"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	"github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/pdubuilder"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"google.golang.org/protobuf/proto"
+)
+
+const v1Version ServiceModelVersion = "v1"
+
+// NewV1Codec creates the codec of E2SM-RSM v1
+func NewV1Codec() Codec {
+	return v1Codec{}
+}
+
+type v1Codec struct{}
+
+func (c v1Codec) Version() ServiceModelVersion {
+	return v1Version
+}
+
+func (c v1Codec) OID() string {
+	return oid
+}
+
+func (c v1Codec) ControlHeader(cmdType e2sm_rsm.E2SmRsmCommand) ([]byte, error) {
+	hdr, err := pdubuilder.CreateE2SmRsmControlHeader(cmdType)
+	if err != nil {
+		return nil, err
+	}
+	hdrProtoBytes, err := proto.Marshal(hdr)
+	if err != nil {
+		return nil, err
+	}
+	return hdrProtoBytes, nil
+}
+
+func (c v1Codec) ControlPayload(cmdType e2sm_rsm.E2SmRsmCommand, sliceConfig *e2sm_rsm.SliceConfig, sliceAssoc *e2sm_rsm.SliceAssociate) ([]byte, error) {
+	var err error
+	var msg *e2sm_rsm.E2SmRsmControlMessage
+	var msgProtoBytes []byte
+	switch cmdType {
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE:
+		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceCreate(sliceConfig)
+		if err != nil {
+			return nil, err
+		}
+		msgProtoBytes, err = proto.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE:
+		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceUpdate(sliceConfig)
+		if err != nil {
+			return nil, err
+		}
+		msgProtoBytes, err = proto.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE:
+		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceDelete(sliceConfig.GetSliceId().GetValue(), sliceConfig.GetSliceType())
+		if err != nil {
+			return nil, err
+		}
+		msgProtoBytes, err = proto.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE:
+		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceAssociate(sliceAssoc)
+		if err != nil {
+			return nil, err
+		}
+		msgProtoBytes, err = proto.Marshal(msg)
+		if err != nil {
+			return nil, err
+		}
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_EVENT_TRIGGERS:
+		// ToDo: check what it is for
+		err := fmt.Errorf("%s (%v)", "Unsupported message type", cmdType)
+		log.Error(err)
+	default:
+		err := fmt.Errorf("%s (%v)", "wrong E2SmRsmCommand type", cmdType)
+		log.Error(err)
+	}
+
+	return msgProtoBytes, err
+}
+
+func (c v1Codec) EventTrigger(triggerType e2sm_rsm.RsmRicindicationTriggerType, reportingPeriodMs int32) ([]byte, error) {
+	eventTriggerDef, err := pdubuilder.CreateE2SmRsmEventTriggerDefinitionFormat1(triggerType)
+	if err != nil {
+		return nil, err
+	}
+	if triggerType == e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_PERIODIC_METRICS {
+		eventTriggerDef.GetEventDefinitionFormats().GetEventDefinitionFormat1().ReportingPeriodMs = &reportingPeriodMs
+	}
+
+	protoBytes, err := proto.Marshal(eventTriggerDef)
+	if err != nil {
+		return nil, err
+	}
+	return protoBytes, nil
+}
+
+func (c v1Codec) DecodeControl(header []byte, payload []byte) (*e2sm_rsm.E2SmRsmControlHeader, *e2sm_rsm.E2SmRsmControlMessage, error) {
+	ctrlHeader := &e2sm_rsm.E2SmRsmControlHeader{}
+	ctrlMessage := &e2sm_rsm.E2SmRsmControlMessage{}
+
+	err := proto.Unmarshal(header, ctrlHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = proto.Unmarshal(payload, ctrlMessage)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ctrlHeader, ctrlMessage, nil
+}
+
+func (c v1Codec) DecodeIndication(indication e2api.Indication) (*e2sm_rsm.E2SmRsmIndicationHeader, *e2sm_rsm.E2SmRsmIndicationMessage, error) {
+	indHeader := &e2sm_rsm.E2SmRsmIndicationHeader{}
+	indPayload := &e2sm_rsm.E2SmRsmIndicationMessage{}
+
+	err := proto.Unmarshal(indication.Header, indHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = proto.Unmarshal(indication.Payload, indPayload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return indHeader, indPayload, nil
+}
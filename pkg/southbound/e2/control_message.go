@@ -5,30 +5,37 @@
 package e2
 
 import (
-	"fmt"
 	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
-	"github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/pdubuilder"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
 	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
-	"google.golang.org/protobuf/proto"
 )
 
-// NewControlMessageHandler creates the new control message handler
-func NewControlMessageHandler() ControlMessageHandler {
-	return ControlMessageHandler{}
+// NewControlMessageHandler creates the new control message handler; the messages are encoded with the codec
+// the resolver returns for the node, or with the v1 codec without a resolver
+func NewControlMessageHandler(codecs CodecResolver) ControlMessageHandler {
+	return ControlMessageHandler{
+		codecs: codecs,
+	}
 }
 
 // ControlMessageHandler is a struct to handle control message
 type ControlMessageHandler struct {
+	codecs CodecResolver
 }
 
-// CreateControlRequest returns the control request message
-func (c *ControlMessageHandler) CreateControlRequest(cmdType e2sm_rsm.E2SmRsmCommand, sliceConfig *e2sm_rsm.SliceConfig, sliceAssoc *e2sm_rsm.SliceAssociate) (*e2api.ControlMessage, error) {
-	hdr, err := c.CreateControlHeader(cmdType)
+// CreateControlRequest returns the control request message for the node
+func (c *ControlMessageHandler) CreateControlRequest(nodeID topoapi.ID, cmdType e2sm_rsm.E2SmRsmCommand, sliceConfig *e2sm_rsm.SliceConfig, sliceAssoc *e2sm_rsm.SliceAssociate) (*e2api.ControlMessage, error) {
+	codec, err := c.codec(nodeID)
 	if err != nil {
 		return nil, err
 	}
 
-	payload, err := c.CreateControlPayload(cmdType, sliceConfig, sliceAssoc)
+	hdr, err := codec.ControlHeader(cmdType)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := codec.ControlPayload(cmdType, sliceConfig, sliceAssoc)
 	if err != nil {
 		return nil, err
 	}
@@ -39,69 +46,9 @@ func (c *ControlMessageHandler) CreateControlRequest(cmdType e2sm_rsm.E2SmRsmCom
 	}, nil
 }
 
-// CreateControlHeader creates the control message header
-func (c *ControlMessageHandler) CreateControlHeader(cmdType e2sm_rsm.E2SmRsmCommand) ([]byte, error) {
-	hdr, err := pdubuilder.CreateE2SmRsmControlHeader(cmdType)
-	if err != nil {
-		return nil, err
-	}
-	hdrProtoBytes, err := proto.Marshal(hdr)
-	if err != nil {
-		return nil, err
-	}
-	return hdrProtoBytes, nil
-}
-
-// CreateControlPayload creates the control message payload
-func (c *ControlMessageHandler) CreateControlPayload(cmdType e2sm_rsm.E2SmRsmCommand, sliceConfig *e2sm_rsm.SliceConfig, sliceAssoc *e2sm_rsm.SliceAssociate) ([]byte, error) {
-	var err error
-	var msg *e2sm_rsm.E2SmRsmControlMessage
-	var msgProtoBytes []byte
-	switch cmdType {
-	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE:
-		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceCreate(sliceConfig)
-		if err != nil {
-			return nil, err
-		}
-		msgProtoBytes, err = proto.Marshal(msg)
-		if err != nil {
-			return nil, err
-		}
-	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE:
-		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceUpdate(sliceConfig)
-		if err != nil {
-			return nil, err
-		}
-		msgProtoBytes, err = proto.Marshal(msg)
-		if err != nil {
-			return nil, err
-		}
-	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE:
-		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceDelete(sliceConfig.GetSliceId().GetValue(), sliceConfig.GetSliceType())
-		if err != nil {
-			return nil, err
-		}
-		msgProtoBytes, err = proto.Marshal(msg)
-		if err != nil {
-			return nil, err
-		}
-	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE:
-		msg, err = pdubuilder.CreateE2SmRsmControlMessageSliceAssociate(sliceAssoc)
-		if err != nil {
-			return nil, err
-		}
-		msgProtoBytes, err = proto.Marshal(msg)
-		if err != nil {
-			return nil, err
-		}
-	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_EVENT_TRIGGERS:
-		// ToDo: check what it is for
-		err := fmt.Errorf("%s (%v)", "Unsupported message type", cmdType)
-		log.Error(err)
-	default:
-		err := fmt.Errorf("%s (%v)", "wrong E2SmRsmCommand type", cmdType)
-		log.Error(err)
+func (c *ControlMessageHandler) codec(nodeID topoapi.ID) (Codec, error) {
+	if c.codecs == nil {
+		return NewV1Codec(), nil
 	}
-
-	return msgProtoBytes, err
+	return c.codecs.Codec(nodeID)
 }
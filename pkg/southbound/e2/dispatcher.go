@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// ControlHandler sends a control message to the E2 node and acknowledges it on the message's ACK channel
+type ControlHandler func(ctx context.Context, nodeID topoapi.ID, msg *CtrlMsg)
+
+// NewControlDispatcher creates a control dispatcher without any node
+func NewControlDispatcher() *ControlDispatcher {
+	return &ControlDispatcher{
+		nodes:   make(map[topoapi.ID]*controlNode),
+		workers: &sync.WaitGroup{},
+	}
+}
+
+// ControlDispatcher passes control messages to the workers of the connected E2 nodes; there is one worker
+// per node and command, so that the messages of a command are sent to a node one at a time and in order
+type ControlDispatcher struct {
+	mu      sync.RWMutex
+	nodes   map[topoapi.ID]*controlNode
+	workers *sync.WaitGroup
+}
+
+type controlNode struct {
+	codec  Codec
+	queues map[e2sm_rsm.E2SmRsmCommand]chan *CtrlMsg
+	// done is closed when the node is unregistered
+	done chan struct{}
+}
+
+// Register starts the workers of the node for the commands the node supports; a node registered before is unregistered first.
+// The codec is the one of the service model version the node advertises.
+func (d *ControlDispatcher) Register(ctx context.Context, nodeID topoapi.ID, codec Codec, commands []e2sm_rsm.E2SmRsmCommand, handler ControlHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unregister(nodeID)
+
+	node := &controlNode{
+		codec:  codec,
+		queues: make(map[e2sm_rsm.E2SmRsmCommand]chan *CtrlMsg),
+		done:   make(chan struct{}),
+	}
+	for _, command := range commands {
+		queue := make(chan *CtrlMsg)
+		node.queues[command] = queue
+		d.workers.Add(1)
+		go d.work(ctx, nodeID, queue, node.done, handler)
+	}
+	d.nodes[nodeID] = node
+	log.Debugf("Registered E2 node %v for control commands %v", nodeID, commands)
+}
+
+// Unregister stops the workers of the node; the messages not yet taken by a worker are rejected
+func (d *ControlDispatcher) Unregister(nodeID topoapi.ID) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unregister(nodeID)
+}
+
+func (d *ControlDispatcher) unregister(nodeID topoapi.ID) {
+	node, ok := d.nodes[nodeID]
+	if !ok {
+		return
+	}
+	close(node.done)
+	delete(d.nodes, nodeID)
+	log.Debugf("Unregistered E2 node %v", nodeID)
+}
+
+// Codec returns the codec of the node
+func (d *ControlDispatcher) Codec(nodeID topoapi.ID) (Codec, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	node, ok := d.nodes[nodeID]
+	if !ok {
+		return nil, errors.NewUnavailable(fmt.Sprintf("E2 node %v is not connected", nodeID))
+	}
+	return node.codec, nil
+}
+
+// Check returns an error if the node is not connected or did not advertise the command
+func (d *ControlDispatcher) Check(nodeID topoapi.ID, command e2sm_rsm.E2SmRsmCommand) error {
+	_, _, err := d.queue(nodeID, command)
+	return err
+}
+
+func (d *ControlDispatcher) queue(nodeID topoapi.ID, command e2sm_rsm.E2SmRsmCommand) (*controlNode, chan *CtrlMsg, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	node, ok := d.nodes[nodeID]
+	if !ok {
+		return nil, nil, errors.NewUnavailable(fmt.Sprintf("E2 node %v is not connected", nodeID))
+	}
+	queue, ok := node.queues[command]
+	if !ok {
+		return nil, nil, errors.NewNotSupported(fmt.Sprintf("E2 node %v did not advertise %v", nodeID, command))
+	}
+	return node, queue, nil
+}
+
+// Send passes the control message to the worker of the node for the command.
+// It fails if the node is not connected or did not advertise the command.
+func (d *ControlDispatcher) Send(ctx context.Context, nodeID topoapi.ID, command e2sm_rsm.E2SmRsmCommand, msg *CtrlMsg) error {
+	node, queue, err := d.queue(nodeID, command)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case queue <- msg:
+		return nil
+	case <-node.done:
+		return errors.NewUnavailable(fmt.Sprintf("E2 node %v is disconnected", nodeID))
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Close unregisters all nodes and waits for their workers to stop
+func (d *ControlDispatcher) Close(ctx context.Context) error {
+	d.mu.Lock()
+	for nodeID := range d.nodes {
+		d.unregister(nodeID)
+	}
+	d.mu.Unlock()
+
+	stopped := make(chan struct{})
+	go func() {
+		d.workers.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("control message workers did not stop: %v", ctx.Err())
+	}
+}
+
+var _ CodecResolver = &ControlDispatcher{}
+
+func (d *ControlDispatcher) work(ctx context.Context, nodeID topoapi.ID, queue chan *CtrlMsg, done chan struct{}, handler ControlHandler) {
+	defer d.workers.Done()
+	for {
+		select {
+		case msg := <-queue:
+			handler(ctx, nodeID, msg)
+		case <-done:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package fake has an in-process stand-in for onos-e2t and scriptable RSM E2 nodes, so that the E2 manager
+// can be run without a cluster, e.g. in go test with e2.WithE2Client
+package fake
+
+import (
+	"context"
+	"sync"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
+)
+
+var log = logging.GetLogger()
+
+// NewE2T creates a fake E2T with the nodes connected
+func NewE2T(nodes ...*Node) *E2T {
+	e2t := &E2T{
+		nodes: make(map[e2client.NodeID]*Node),
+	}
+	for _, node := range nodes {
+		e2t.Connect(node)
+	}
+	return e2t
+}
+
+// E2T is a fake E2 client which passes the subscriptions and control messages to the connected fake nodes
+type E2T struct {
+	mu    sync.RWMutex
+	nodes map[e2client.NodeID]*Node
+}
+
+// Connect connects the node to the E2T
+func (t *E2T) Connect(node *Node) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nodes[node.ID()] = node
+}
+
+// Disconnect disconnects the node from the E2T; its subscriptions are closed
+func (t *E2T) Disconnect(nodeID e2client.NodeID) {
+	t.mu.Lock()
+	node, ok := t.nodes[nodeID]
+	delete(t.nodes, nodeID)
+	t.mu.Unlock()
+	if ok {
+		node.closeSubscriptions()
+	}
+}
+
+// Node returns the connected node; the calls to a node which is not connected fail with Unavailable
+func (t *E2T) Node(nodeID e2client.NodeID) e2client.Node {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if node, ok := t.nodes[nodeID]; ok {
+		return node
+	}
+	return disconnectedNode{id: nodeID}
+}
+
+var _ e2client.Client = &E2T{}
+
+type disconnectedNode struct {
+	id e2client.NodeID
+}
+
+func (n disconnectedNode) ID() e2client.NodeID {
+	return n.id
+}
+
+func (n disconnectedNode) Context() context.Context {
+	return context.Background()
+}
+
+func (n disconnectedNode) Subscribe(context.Context, string, e2api.SubscriptionSpec, chan<- e2api.Indication, ...e2client.SubscribeOption) (e2api.ChannelID, error) {
+	return "", errors.NewUnavailable("E2 node %v is not connected", n.id)
+}
+
+func (n disconnectedNode) Unsubscribe(context.Context, string) error {
+	return errors.NewUnavailable("E2 node %v is not connected", n.id)
+}
+
+func (n disconnectedNode) Control(context.Context, *e2api.ControlMessage, []byte) (*e2api.ControlOutcome, error) {
+	return nil, errors.NewUnavailable("E2 node %v is not connected", n.id)
+}
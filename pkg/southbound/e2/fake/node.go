@@ -0,0 +1,318 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package fake
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	"github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/pdubuilder"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	e2sm_v2_ies "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-v2-ies"
+	"github.com/onosproject/onos-lib-go/api/asn1/v1/asn1"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
+	"github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1/e2errors"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"google.golang.org/protobuf/proto"
+)
+
+// UE is a UE attached to a fake node
+type UE struct {
+	CuUeF1apID  int64
+	DuUeF1apID  int64
+	RanUeNgapID int64
+	AmfUeNgapID int64
+	DrbID       int32
+	Qfi         int32
+	FiveQI      int32
+}
+
+type sliceKey struct {
+	id        int64
+	sliceType e2sm_rsm.SliceType
+}
+
+type subscription struct {
+	channelID e2api.ChannelID
+	trigger   e2sm_rsm.RsmRicindicationTriggerType
+	ch        chan<- e2api.Indication
+}
+
+// NewNode creates a fake E2 node with the RSM service model v1; it plays the DU role for the control messages
+// and the CU role for the EMM indications, as the DU and the CU of a real deployment do
+func NewNode(nodeID e2client.NodeID) *Node {
+	return &Node{
+		id:            nodeID,
+		codec:         e2.NewV1Codec(),
+		slices:        make(map[sliceKey]*e2sm_rsm.SliceConfig),
+		associations:  make(map[int64]*e2sm_rsm.SliceAssociate),
+		subscriptions: make(map[string]*subscription),
+		controlErrors: make(map[e2sm_rsm.E2SmRsmCommand]error),
+	}
+}
+
+// Node is a fake RSM E2 node which keeps its own slice table and emits EMM indications on demand
+type Node struct {
+	id            e2client.NodeID
+	codec         e2.Codec
+	mu            sync.Mutex
+	slices        map[sliceKey]*e2sm_rsm.SliceConfig
+	associations  map[int64]*e2sm_rsm.SliceAssociate
+	subscriptions map[string]*subscription
+	controlErrors map[e2sm_rsm.E2SmRsmCommand]error
+	controls      int
+}
+
+func (n *Node) ID() e2client.NodeID {
+	return n.id
+}
+
+func (n *Node) Context() context.Context {
+	return context.Background()
+}
+
+// FailControl makes the control messages of the command fail with the error, e.g. an e2errors error
+// for an E2AP control failure; a nil error lets them succeed again
+func (n *Node) FailControl(command e2sm_rsm.E2SmRsmCommand, err error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if err == nil {
+		delete(n.controlErrors, command)
+		return
+	}
+	n.controlErrors[command] = err
+}
+
+// Controls returns the number of control messages the node received
+func (n *Node) Controls() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.controls
+}
+
+// Slices returns the slices of the node
+func (n *Node) Slices() []*e2sm_rsm.SliceConfig {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	slices := make([]*e2sm_rsm.SliceConfig, 0, len(n.slices))
+	for _, slice := range n.slices {
+		slices = append(slices, proto.Clone(slice).(*e2sm_rsm.SliceConfig))
+	}
+	return slices
+}
+
+// Association returns the last slice association of the UE, or nil
+func (n *Node) Association(duUeF1apID int64) *e2sm_rsm.SliceAssociate {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if assoc, ok := n.associations[duUeF1apID]; ok {
+		return proto.Clone(assoc).(*e2sm_rsm.SliceAssociate)
+	}
+	return nil
+}
+
+func (n *Node) Control(_ context.Context, message *e2api.ControlMessage, _ []byte) (*e2api.ControlOutcome, error) {
+	header, payload, err := n.codec.DecodeControl(message.GetHeader(), message.GetPayload())
+	if err != nil {
+		return nil, e2errors.NewProtocolAbstractSyntaxErrorFalselyConstructedMessage("invalid control message: %v", err)
+	}
+	command := header.GetRsmCommand()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.controls++
+	if err, ok := n.controlErrors[command]; ok {
+		return nil, err
+	}
+
+	switch command {
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE:
+		config := payload.GetSliceCreate()
+		key := sliceKey{id: config.GetSliceId().GetValue(), sliceType: config.GetSliceType()}
+		if _, ok := n.slices[key]; ok {
+			return nil, e2errors.NewRICControlMessageInvalid("slice %v already exists", key.id)
+		}
+		n.slices[key] = config
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE:
+		config := payload.GetSliceUpdate()
+		key := sliceKey{id: config.GetSliceId().GetValue(), sliceType: config.GetSliceType()}
+		if _, ok := n.slices[key]; !ok {
+			return nil, e2errors.NewRICControlMessageInvalid("slice %v does not exist", key.id)
+		}
+		n.slices[key] = config
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE:
+		del := payload.GetSliceDelete()
+		key := sliceKey{id: del.GetSliceId().GetValue(), sliceType: del.GetSliceType()}
+		if _, ok := n.slices[key]; !ok {
+			return nil, e2errors.NewRICControlMessageInvalid("slice %v does not exist", key.id)
+		}
+		delete(n.slices, key)
+	case e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE:
+		assoc := payload.GetSliceAssociate()
+		if dl := assoc.GetDownLinkSliceId(); dl != nil {
+			if _, ok := n.slices[sliceKey{id: dl.GetValue(), sliceType: e2sm_rsm.SliceType_SLICE_TYPE_DL_SLICE}]; !ok {
+				return nil, e2errors.NewRICControlMessageInvalid("DL slice %v does not exist", dl.GetValue())
+			}
+		}
+		if ul := assoc.GetUplinkSliceId(); ul != nil {
+			if _, ok := n.slices[sliceKey{id: ul.GetValue(), sliceType: e2sm_rsm.SliceType_SLICE_TYPE_UL_SLICE}]; !ok {
+				return nil, e2errors.NewRICControlMessageInvalid("UL slice %v does not exist", ul.GetValue())
+			}
+		}
+		n.associations[assoc.GetUeId().GetDuUeF1ApId().GetValue()] = assoc
+	default:
+		return nil, e2errors.NewRICControlMessageInvalid("unsupported command %v", command)
+	}
+	return &e2api.ControlOutcome{}, nil
+}
+
+func (n *Node) Subscribe(_ context.Context, name string, spec e2api.SubscriptionSpec, ch chan<- e2api.Indication, _ ...e2client.SubscribeOption) (e2api.ChannelID, error) {
+	trigger := &e2sm_rsm.E2SmRsmEventTriggerDefinition{}
+	if err := proto.Unmarshal(spec.EventTrigger.Payload, trigger); err != nil {
+		return "", e2errors.NewProtocolAbstractSyntaxErrorFalselyConstructedMessage("invalid event trigger: %v", err)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if _, ok := n.subscriptions[name]; ok {
+		return "", errors.NewAlreadyExists("subscription %v already exists", name)
+	}
+	sub := &subscription{
+		channelID: e2api.ChannelID(fmt.Sprintf("%s-%s", n.id, name)),
+		trigger:   trigger.GetEventDefinitionFormats().GetEventDefinitionFormat1().GetTriggerType(),
+		ch:        ch,
+	}
+	n.subscriptions[name] = sub
+	log.Debugf("Fake E2 node %v: subscribed %v (%v)", n.id, name, sub.trigger)
+	return sub.channelID, nil
+}
+
+func (n *Node) Unsubscribe(_ context.Context, name string) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	sub, ok := n.subscriptions[name]
+	if !ok {
+		return errors.NewNotFound("subscription %v not found", name)
+	}
+	close(sub.ch)
+	delete(n.subscriptions, name)
+	return nil
+}
+
+func (n *Node) closeSubscriptions() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for name, sub := range n.subscriptions {
+		close(sub.ch)
+		delete(n.subscriptions, name)
+	}
+}
+
+// AttachUE emits an EMM attach indication of the UE
+func (n *Node) AttachUE(ctx context.Context, ue UE) error {
+	return n.emitEmmEvent(ctx, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_ATTACH, ue)
+}
+
+// DetachUE emits an EMM detach indication of the UE
+func (n *Node) DetachUE(ctx context.Context, ue UE) error {
+	return n.emitEmmEvent(ctx, e2sm_rsm.RsmEmmTriggerType_RSM_EMM_TRIGGER_TYPE_UE_DETACH, ue)
+}
+
+func (n *Node) emitEmmEvent(ctx context.Context, triggerType e2sm_rsm.RsmEmmTriggerType, ue UE) error {
+	indication, err := newEmmIndication(triggerType, ue)
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for _, sub := range n.subscriptions {
+		if sub.trigger != e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_UPON_EMM_EVENT {
+			continue
+		}
+		select {
+		case sub.ch <- indication:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+func newEmmIndication(triggerType e2sm_rsm.RsmEmmTriggerType, ue UE) (e2api.Indication, error) {
+	cgi, err := pdubuilder.CreateNrCGI([]byte{0x00, 0xf1, 0x10}, &asn1.BitString{
+		Value: []byte{0x00, 0x00, 0x00, 0x00, 0x10},
+		Len:   36,
+	})
+	if err != nil {
+		return e2api.Indication{}, err
+	}
+	header, err := pdubuilder.CreateE2SmRsmIndicationHeaderFormat1(cgi)
+	if err != nil {
+		return e2api.Indication{}, err
+	}
+
+	ueIDs := make([]*e2sm_rsm.UeIdentity, 0)
+	for _, create := range []func() (*e2sm_rsm.UeIdentity, error){
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDCuUeF1ApID(ue.CuUeF1apID) },
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDDuUeF1ApID(ue.DuUeF1apID) },
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDRanUeNgapID(ue.RanUeNgapID) },
+		func() (*e2sm_rsm.UeIdentity, error) { return pdubuilder.CreateUeIDAmfUeNgapID(ue.AmfUeNgapID) },
+	} {
+		ueID, err := create()
+		if err != nil {
+			return e2api.Indication{}, err
+		}
+		ueIDs = append(ueIDs, ueID)
+	}
+
+	bearer := &e2sm_rsm.BearerId{
+		BearerId: &e2sm_rsm.BearerId_DrbId{
+			DrbId: &e2sm_rsm.DrbId{
+				DrbId: &e2sm_rsm.DrbId_FiveGdrbId{
+					FiveGdrbId: &e2sm_rsm.FiveGDrbId{
+						Value: ue.DrbID,
+						Qfi: &e2sm_rsm.Qfi{
+							Value: ue.Qfi,
+						},
+						FlowsMapToDrb: []*e2sm_rsm.QoSflowLevelParameters{
+							{
+								QoSflowLevelParameters: &e2sm_rsm.QoSflowLevelParameters_NonDynamicFiveQi{
+									NonDynamicFiveQi: &e2sm_rsm.NonDynamicFiveQi{
+										FiveQi: &e2sm_v2_ies.FiveQi{
+											Value: ue.FiveQI,
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	message, err := pdubuilder.CreateE2SmRsmIndicationMessageFormat2(triggerType, ueIDs, e2sm_rsm.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, []*e2sm_rsm.BearerId{bearer})
+	if err != nil {
+		return e2api.Indication{}, err
+	}
+
+	headerBytes, err := proto.Marshal(header)
+	if err != nil {
+		return e2api.Indication{}, err
+	}
+	messageBytes, err := proto.Marshal(message)
+	if err != nil {
+		return e2api.Indication{}, err
+	}
+	return e2api.Indication{
+		Header:  headerBytes,
+		Payload: messageBytes,
+	}, nil
+}
+
+var _ e2client.Node = &Node{}
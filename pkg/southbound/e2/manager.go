@@ -9,27 +9,30 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
-	prototypes "github.com/gogo/protobuf/types"
 	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
 	topoapi "github.com/onosproject/onos-api/go/onos/topo"
-	"github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/pdubuilder"
 	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
 	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
 	"github.com/onosproject/onos-rsm/pkg/broker"
 	appConfig "github.com/onosproject/onos-rsm/pkg/config"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
 	"github.com/onosproject/onos-rsm/pkg/monitoring"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
-	"google.golang.org/protobuf/proto"
+	"github.com/onosproject/onos-rsm/pkg/recorder"
 )
 
 var log = logging.GetLogger()
 
 const (
 	oid = "1.3.6.1.4.1.53148.1.1.2.102"
+
+	closeStreamTimeout = 10 * time.Second
 )
 
 type Node interface {
@@ -38,17 +41,28 @@ type Node interface {
 }
 
 type Manager struct {
-	appID                 string
-	e2Client              e2client.Client
-	rnibClient            rnib.TopoClient
-	uenibClient           uenib.Client
-	serviceModel          ServiceModelOptions
-	appConfig             *appConfig.AppConfig
-	streams               broker.Broker
-	ctrlReqChsSliceCreate map[string]chan *CtrlMsg
-	ctrlReqChsSliceUpdate map[string]chan *CtrlMsg
-	ctrlReqChsSliceDelete map[string]chan *CtrlMsg
-	ctrlReqChsUeAssociate map[string]chan *CtrlMsg
+	appID              string
+	e2Clients          *e2Clients
+	rnibClient         rnib.TopoClient
+	capabilities       *rnib.CapabilityCache
+	uenibClient        uenib.Client
+	serviceModel       ServiceModelOptions
+	appConfig          *appConfig.AppConfig
+	streams            broker.Broker
+	ctrlDispatcher     *ControlDispatcher
+	ctrlRetryPolicy    RetryPolicy
+	recorder           *recorder.Recorder
+	nodeEventCh        chan NodeEvent
+	indicationObserver monitoring.IndicationObserver
+	handoverTracker    *monitoring.HandoverTracker
+	identityResolver   *monitoring.UEIdentityResolver
+	kpiStore           *kpi.Store
+	ctx                context.Context
+	cancel             context.CancelFunc
+	// watchDone is closed once the E2 connection watch has returned
+	watchDone     chan struct{}
+	subscriptions *subscriptionSupervisor
+	connections   *nodeConnections
 }
 
 func NewManager(opts ...Option) (Manager, error) {
@@ -59,42 +73,50 @@ func NewManager(opts ...Option) (Manager, error) {
 		opt.apply(&options)
 	}
 
-	serviceModelName := e2client.ServiceModelName(options.ServiceModel.Name)
-	serviceModelVersion := e2client.ServiceModelVersion(options.ServiceModel.Version)
-	appID := e2client.AppID(options.App.AppID)
-	e2Client := e2client.NewClient(
-		e2client.WithServiceModel(serviceModelName, serviceModelVersion),
-		e2client.WithAppID(appID),
-		e2client.WithE2TAddress(options.E2TService.Host, options.E2TService.Port))
+	e2Clients := newE2Clients(options)
+	if _, err := GetCodec(options.ServiceModel.Version); err != nil {
+		log.Warnf("Service model version %v is not supported - the nodes will use the version they advertise", options.ServiceModel.Version)
+	}
 
+	ctx, cancel := context.WithCancel(context.Background())
 	return Manager{
-		appID:       options.App.AppID,
-		e2Client:    e2Client,
-		rnibClient:  options.App.RnibClient,
-		uenibClient: options.App.UenibClient,
+		appID:        options.App.AppID,
+		e2Clients:    e2Clients,
+		rnibClient:   options.App.RnibClient,
+		capabilities: options.App.CapabilityCache,
+		uenibClient:  options.App.UenibClient,
 		serviceModel: ServiceModelOptions{
 			Name:    options.ServiceModel.Name,
 			Version: options.ServiceModel.Version,
 		},
-		appConfig:             options.App.AppConfig,
-		streams:               options.App.Broker,
-		ctrlReqChsSliceCreate: options.App.CtrlReqChsSliceCreate,
-		ctrlReqChsSliceUpdate: options.App.CtrlReqChsSliceUpdate,
-		ctrlReqChsSliceDelete: options.App.CtrlReqChsSliceDelete,
-		ctrlReqChsUeAssociate: options.App.CtrlReqChsUeAssociate,
+		appConfig:          options.App.AppConfig,
+		streams:            options.App.Broker,
+		ctrlDispatcher:     options.App.CtrlDispatcher,
+		ctrlRetryPolicy:    options.App.CtrlRetryPolicy,
+		recorder:           options.App.Recorder,
+		nodeEventCh:        options.App.NodeEventCh,
+		indicationObserver: options.App.IndicationObserver,
+		handoverTracker:    options.App.HandoverTracker,
+		identityResolver:   options.App.IdentityResolver,
+		kpiStore:           options.App.KpiStore,
+		ctx:                ctx,
+		cancel:             cancel,
+		watchDone:          make(chan struct{}),
+		subscriptions:      newSubscriptionSupervisor(),
+		connections:        newNodeConnections(),
 	}, nil
 }
 
 func (m *Manager) Start() error {
 	log.Info("Start E2 Manager")
 	go func() {
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		err := m.watchE2Connections(ctx)
+		defer close(m.watchDone)
+		err := m.watchE2Connections(m.ctx)
 		if err != nil {
 			return
 		}
 	}()
+	go m.watchReportPeriod(m.ctx)
 	return nil
 }
 
@@ -112,69 +134,85 @@ func (m *Manager) watchE2Connections(ctx context.Context) error {
 		case topoapi.EventType_ADDED, topoapi.EventType_NONE:
 			relation := topoEvent.Object.Obj.(*topoapi.Object_Relation)
 			e2NodeID := relation.Relation.TgtEntityID
-			if !m.rnibClient.HasRSMRANFunction(ctx, e2NodeID, oid) {
-				log.Debugf("Received topo event does not have RSM RAN function - %v", topoEvent)
+			codec, err := m.selectCodec(ctx, e2NodeID)
+			if err != nil {
+				log.Debugf("Received topo event does not have RSM RAN function of a supported version - %v: %v", topoEvent, err)
 				continue
 			}
 
-			log.Debugf("New E2NodeID %v connected", e2NodeID)
-			rsmSupportedCfgs, err := m.rnibClient.GetSupportedSlicingConfigTypes(ctx, e2NodeID)
+			log.Debugf("New E2NodeID %v connected with service model %v", e2NodeID, codec.Version())
+			rsmSupportedCfgs, err := m.supportedSlicingConfigTypes(ctx, e2NodeID)
 			if err != nil {
 				log.Warn(err)
 				return err
 			}
+			nodeCtx, replaced := m.connections.connect(ctx, e2NodeID, topoEvent.Object.ID)
+			if replaced {
+				m.teardownNode(ctx, e2NodeID)
+			}
 
 			log.Debugf("RSM supported configs: %v", rsmSupportedCfgs)
+			supportsSlicing := false
+			supportsEventTriggers := false
+			commands := make([]e2sm_rsm.E2SmRsmCommand, 0)
 			for _, cfg := range rsmSupportedCfgs {
 				switch cfg.SlicingConfigType {
 				case topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_EVENT_TRIGGERS:
-					go func() {
-						err := m.createSubscription(ctx, e2NodeID, e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_UPON_EMM_EVENT)
-						if err != nil {
-							log.Warn(err)
-						}
-					}()
+					m.superviseSubscription(nodeCtx, e2NodeID, e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_UPON_EMM_EVENT, 0)
+					supportsEventTriggers = true
 				case topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE:
-					m.ctrlReqChsSliceCreate[string(e2NodeID)] = make(chan *CtrlMsg)
-					go m.watchCtrlSliceCreated(ctx, e2NodeID)
+					commands = append(commands, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE)
+					supportsSlicing = true
 				case topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE:
-					m.ctrlReqChsSliceUpdate[string(e2NodeID)] = make(chan *CtrlMsg)
-					go m.watchCtrlSliceUpdated(ctx, e2NodeID)
+					commands = append(commands, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE)
 				case topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE:
-					m.ctrlReqChsSliceDelete[string(e2NodeID)] = make(chan *CtrlMsg)
-					go m.watchCtrlSliceDeleted(ctx, e2NodeID)
+					commands = append(commands, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE)
 				case topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE:
-					m.ctrlReqChsUeAssociate[string(e2NodeID)] = make(chan *CtrlMsg)
-					go m.watchCtrlUEAssociate(ctx, e2NodeID)
+					commands = append(commands, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE)
 				}
 			}
+
+			if len(commands) > 0 {
+				m.ctrlDispatcher.Register(nodeCtx, e2NodeID, codec, commands, m.sendControl)
+			}
+
+			if supportsSlicing && supportsEventTriggers {
+				// slice metrics are reported by the DUs
+				m.subscriptions.addMetricsNode(e2NodeID)
+				if period := m.reportPeriodMs(); period > 0 {
+					m.superviseSubscription(nodeCtx, e2NodeID, e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_PERIODIC_METRICS, period)
+				}
+			}
+
+			if supportsSlicing {
+				m.sendNodeEvent(ctx, NodeConnected, e2NodeID)
+			}
 		case topoapi.EventType_REMOVED:
 			relation := topoEvent.Object.Obj.(*topoapi.Object_Relation)
 			e2NodeID := relation.Relation.TgtEntityID
-			if !m.rnibClient.HasRSMRANFunction(ctx, e2NodeID, oid) {
-				log.Debugf("Received topo event does not have RSM RAN function - %v", topoEvent)
+			if _, err := m.selectCodec(ctx, e2NodeID); err != nil {
+				log.Debugf("Received topo event does not have RSM RAN function of a supported version - %v: %v", topoEvent, err)
 				continue
 			}
 
+			if !m.connections.disconnect(e2NodeID, topoEvent.Object.ID) {
+				log.Infof("Ignoring the removal of connection %v of E2 node %v, which connected again", topoEvent.Object.ID, e2NodeID)
+				continue
+			}
 			log.Infof("E2 node %v is disconnected", e2NodeID)
+			m.teardownNode(ctx, e2NodeID)
 			// Clean up slice information from onos-topo
-			duE2NodeID, err := m.rnibClient.GetTargetDUE2NodeID(ctx, e2NodeID)
-			hasDU := true
+			duE2NodeIDs, err := m.rnibClient.GetDUE2NodeIDs(ctx, e2NodeID)
 			if err != nil {
-				log.Debugf("e2Node %v was not connected to DU - maybe e2Node %v is DU", e2NodeID, e2NodeID)
-				hasDU = false
+				log.Debugf("e2Node %v was not connected to DU - maybe e2Node %v is DU: %v", e2NodeID, e2NodeID, err)
+				duE2NodeIDs = []topoapi.ID{e2NodeID}
 			}
 
-			if hasDU {
+			for _, duE2NodeID := range duE2NodeIDs {
 				err = m.rnibClient.DeleteRsmSliceList(ctx, duE2NodeID)
 				if err != nil {
 					log.Warn(err)
 				}
-			} else {
-				err = m.rnibClient.DeleteRsmSliceList(ctx, e2NodeID)
-				if err != nil {
-					log.Warn(err)
-				}
 			}
 
 			// Clean up UE information from uenib
@@ -182,35 +220,50 @@ func (m *Manager) watchE2Connections(ctx context.Context) error {
 			if err != nil {
 				log.Warn(err)
 			}
+
+			m.sendNodeEvent(ctx, NodeDisconnected, e2NodeID)
 		}
 	}
 
 	return nil
 }
 
-func (m *Manager) createSubscription(ctx context.Context, e2nodeID topoapi.ID, eventTrigger e2sm_rsm.RsmRicindicationTriggerType) error {
-	log.Info("Creating subscription for E2 node ID with: ", e2nodeID)
-	eventTriggerData, err := m.createEventTrigger(eventTrigger)
-	if err != nil {
-		log.Warn(err)
-		return err
+func (m *Manager) sendNodeEvent(ctx context.Context, eventType NodeEventType, e2NodeID topoapi.ID) {
+	if m.nodeEventCh == nil {
+		return
+	}
+	select {
+	case m.nodeEventCh <- NodeEvent{
+		Type:   eventType,
+		NodeID: e2NodeID,
+	}:
+	case <-ctx.Done():
 	}
+}
 
-	aspects, err := m.rnibClient.GetE2NodeAspects(ctx, e2nodeID)
+// createSubscription subscribes to the node and processes its indications until the monitor stops; onActive is called
+// once the indications are being received. Unless the manager is stopping, the subscription is deleted before returning.
+func (m *Manager) createSubscription(ctx context.Context, e2nodeID topoapi.ID, eventTrigger e2sm_rsm.RsmRicindicationTriggerType, reportingPeriodMs int32, onActive func()) error {
+	log.Info("Creating subscription for E2 node ID with: ", e2nodeID)
+	codec, err := m.selectCodec(ctx, e2nodeID)
 	if err != nil {
 		log.Warn(err)
 		return err
 	}
 
-	_, err = m.getRanFunction(aspects.ServiceModels)
+	eventTriggerData, err := codec.EventTrigger(eventTrigger, reportingPeriodMs)
 	if err != nil {
 		log.Warn(err)
 		return err
 	}
 
 	ch := make(chan e2api.Indication)
-	node := m.e2Client.Node(e2client.NodeID(e2nodeID))
+	node := m.e2Clients.node(e2nodeID, codec)
 	subName := fmt.Sprintf("%s-subscription-%s-%s", m.appID, e2nodeID, eventTrigger)
+	if reportingPeriodMs > 0 {
+		// a subscription with a new period does not share its name with the one it replaces
+		subName = fmt.Sprintf("%s-%dms", subName, reportingPeriodMs)
+	}
 	subSpec := e2api.SubscriptionSpec{
 		EventTrigger: e2api.EventTrigger{
 			Payload: eventTriggerData,
@@ -236,44 +289,31 @@ func (m *Manager) createSubscription(ctx context.Context, e2nodeID topoapi.ID, e
 		monitoring.WithNode(node),
 		monitoring.WithNodeID(e2nodeID),
 		monitoring.WithStreamReader(streamReader),
+		monitoring.WithIndicationDecoder(codec),
 		monitoring.WithRNIBClient(m.rnibClient),
 		monitoring.WithUENIBClient(m.uenibClient),
-		monitoring.WithRicIndicationTriggerType(eventTrigger))
+		monitoring.WithRicIndicationTriggerType(eventTrigger),
+		monitoring.WithIndicationObserver(m.indicationObserver),
+		monitoring.WithHandoverTracker(m.handoverTracker),
+		monitoring.WithUEIdentityResolver(m.identityResolver),
+		monitoring.WithKpiStore(m.kpiStore))
 
+	onActive()
 	err = monitor.Start(ctx)
 	if err != nil {
 		log.Warn(err)
-		return err
 	}
 
-	return nil
-}
-
-func (m *Manager) createEventTrigger(triggerType e2sm_rsm.RsmRicindicationTriggerType) ([]byte, error) {
-	// period is not defined yet
-	//reportPeriodMs := int32(0)
-	//period, err := m.appConfig.GetReportPeriod()
-	//if err != nil {
-	//	return nil, err
-	//}
-	//
-	//if triggerType == e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_PERIODIC_METRICS {
-	//	reportPeriodMs = int32(period)
-	//}
-
-	eventTriggerDef, err := pdubuilder.CreateE2SmRsmEventTriggerDefinitionFormat1(triggerType)
-	if err != nil {
-		log.Warn(err)
-		return nil, err
-	}
-
-	protoBytes, err := proto.Marshal(eventTriggerDef)
-	if err != nil {
-		log.Warn(err)
-		return nil, err
+	if m.ctx.Err() == nil {
+		// the stream is closed here so that the next subscription opens a new one
+		closeCtx, cancel := context.WithTimeout(m.ctx, closeStreamTimeout)
+		defer cancel()
+		// the streams of a disconnected node are closed by its teardown
+		if _, closeErr := m.streams.CloseStream(closeCtx, channelID); closeErr != nil && !errors.IsNotFound(closeErr) {
+			log.Warnf("Failed to close the stream of subscription %v: %v", subName, closeErr)
+		}
 	}
-
-	return protoBytes, nil
+	return err
 }
 
 func (m *Manager) createSubscriptionActions() []e2api.Action {
@@ -291,24 +331,62 @@ func (m *Manager) createSubscriptionActions() []e2api.Action {
 	return actions
 }
 
-func (m *Manager) getRanFunction(serviceModelsInfo map[string]*topoapi.ServiceModelInfo) (*topoapi.RSMRanFunction, error) {
-	for _, sm := range serviceModelsInfo {
-		smName := strings.ToLower(sm.Name)
-		if smName == string(m.serviceModel.Name) && sm.OID == oid {
-			rsmRanFunction := &topoapi.RSMRanFunction{}
-			for _, ranFunction := range sm.RanFunctions {
-				if ranFunction.TypeUrl == ranFunction.GetTypeUrl() {
-					err := prototypes.UnmarshalAny(ranFunction, rsmRanFunction)
-					if err != nil {
-						return nil, err
-					}
-					return rsmRanFunction, nil
-				}
+// selectCodec returns the codec of the RSM service model version the node advertises;
+// the configured version is preferred if the node advertises more than one
+func (m *Manager) selectCodec(ctx context.Context, nodeID topoapi.ID) (Codec, error) {
+	aspects, err := m.rnibClient.GetE2NodeAspects(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected Codec
+	for _, codec := range registeredCodecs() {
+		for _, sm := range aspects.GetServiceModels() {
+			if strings.ToLower(sm.Name) != string(m.serviceModel.Name) || sm.OID != codec.OID() {
+				continue
+			}
+			if codec.Version() == m.serviceModel.Version {
+				return codec, nil
 			}
+			selected = codec
 		}
 	}
-	return nil, errors.New(errors.NotFound, "cannot retrieve ran functions")
+	if selected == nil {
+		return nil, errors.New(errors.NotFound, "cannot retrieve ran functions")
+	}
+	return selected, nil
+}
+
+// e2Clients has an E2 client per service model version
+type e2Clients struct {
+	mu      sync.Mutex
+	options Options
+	clients map[ServiceModelVersion]e2client.Client
+}
 
+func newE2Clients(options Options) *e2Clients {
+	return &e2Clients{
+		options: options,
+		clients: make(map[ServiceModelVersion]e2client.Client),
+	}
+}
+
+// node returns the node of the E2 client of the codec's version
+func (c *e2Clients) node(nodeID topoapi.ID, codec Codec) e2client.Node {
+	if c.options.E2TService.Client != nil {
+		return c.options.E2TService.Client.Node(e2client.NodeID(nodeID))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	client, ok := c.clients[codec.Version()]
+	if !ok {
+		client = e2client.NewClient(
+			e2client.WithServiceModel(e2client.ServiceModelName(c.options.ServiceModel.Name), e2client.ServiceModelVersion(codec.Version())),
+			e2client.WithAppID(e2client.AppID(c.options.App.AppID)),
+			e2client.WithE2TAddress(c.options.E2TService.Host, c.options.E2TService.Port))
+		c.clients[codec.Version()] = client
+	}
+	return client.Node(e2client.NodeID(nodeID))
 }
 
 func (m *Manager) sendIndicationOnStream(streamID broker.StreamID, ch chan e2api.Indication) {
@@ -327,127 +405,93 @@ func (m *Manager) sendIndicationOnStream(streamID broker.StreamID, ch chan e2api
 	}
 }
 
-func (m *Manager) watchCtrlSliceCreated(ctx context.Context, e2NodeID topoapi.ID) {
-	for ctrlReqMsg := range m.ctrlReqChsSliceCreate[string(e2NodeID)] {
-		log.Debugf("ctrlReqMsg: %v", ctrlReqMsg)
-		node := m.e2Client.Node(e2client.NodeID(e2NodeID))
-		ctrlRespMsg, err := node.Control(ctx, ctrlReqMsg.CtrlMsg, nil)
-		if err != nil {
-			log.Warnf("Error sending control message - %v", err)
-			ack := Ack{
-				Success: false,
-				Reason:  err.Error(),
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		} else if ctrlRespMsg == nil {
-			log.Warn(" Ctrl Resp message is nil")
-			ack := Ack{
-				Success: false,
-				Reason:  "Ctrl Resp message is nil",
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		}
-		ack := Ack{
-			Success: true,
-		}
-		ctrlReqMsg.AckCh <- ack
+// sendControl sends the control message to the E2 node, again as per the retry policy if it fails;
+// it is the control dispatcher's handler of the node
+func (m *Manager) sendControl(ctx context.Context, e2NodeID topoapi.ID, ctrlReqMsg *CtrlMsg) {
+	log.Debugf("ctrlReqMsg: %v", ctrlReqMsg)
+	codec, err := m.ctrlDispatcher.Codec(e2NodeID)
+	if err != nil {
+		ctrlReqMsg.AckCh <- NewAck(nil, err)
+		return
 	}
-}
-
-func (m *Manager) watchCtrlSliceUpdated(ctx context.Context, e2NodeID topoapi.ID) {
-	for ctrlReqMsg := range m.ctrlReqChsSliceUpdate[string(e2NodeID)] {
-		log.Debugf("ctrlReqMsg: %v", ctrlReqMsg)
-		node := m.e2Client.Node(e2client.NodeID(e2NodeID))
+	node := m.e2Clients.node(e2NodeID, codec)
+	ack := m.ctrlRetryPolicy.send(ctx, func(ctx context.Context) Ack {
 		ctrlRespMsg, err := node.Control(ctx, ctrlReqMsg.CtrlMsg, nil)
 		log.Debugf("ctrlRespMsg: %v", ctrlRespMsg)
-		if err != nil {
-			log.Warnf("Error sending control message - %v", err)
-			ack := Ack{
-				Success: false,
-				Reason:  err.Error(),
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		} else if ctrlRespMsg == nil {
-			log.Warn(" Ctrl Resp message is nil")
-			ack := Ack{
-				Success: false,
-				Reason:  "Ctrl Resp message is nil",
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		}
-		ack := Ack{
-			Success: true,
+		m.recordControl(e2NodeID, codec, ctrlReqMsg.CtrlMsg, ctrlRespMsg, err)
+		ack := NewAck(ctrlRespMsg, err)
+		if !ack.Success {
+			log.Warnf("Error sending control message to %v - outcome %v, cause %v/%v, retryable %v: %v", e2NodeID, ack.Outcome, ack.CauseGroup, ack.Cause, ack.Retryable, ack.Reason)
 		}
-		ctrlReqMsg.AckCh <- ack
+		return ack
+	})
+	if !ack.Success && ctx.Err() != nil {
+		// the node disconnected while the message was being sent
+		retries := ack.Retries
+		ack = NewAck(nil, errors.NewUnavailable(fmt.Sprintf("E2 node %v is disconnected", e2NodeID)))
+		ack.Retries = retries
 	}
+	ctrlReqMsg.AckCh <- ack
 }
 
-func (m *Manager) watchCtrlSliceDeleted(ctx context.Context, e2NodeID topoapi.ID) {
-	for ctrlReqMsg := range m.ctrlReqChsSliceDelete[string(e2NodeID)] {
-		log.Debugf("ctrlReqMsg: %v", ctrlReqMsg)
-		node := m.e2Client.Node(e2client.NodeID(e2NodeID))
-		ctrlRespMsg, err := node.Control(ctx, ctrlReqMsg.CtrlMsg, nil)
-		log.Debugf("ctrlRespMsg: %v", ctrlRespMsg)
-		if err != nil {
-			log.Warnf("Error sending control message - %v", err)
-			ack := Ack{
-				Success: false,
-				Reason:  err.Error(),
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		} else if ctrlRespMsg == nil {
-			log.Warn(" Ctrl Resp message is nil")
-			ack := Ack{
-				Success: false,
-				Reason:  "Ctrl Resp message is nil",
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		}
-		ack := Ack{
-			Success: true,
-		}
-		ctrlReqMsg.AckCh <- ack
+// recordControl records a control request and the response of the node
+func (m *Manager) recordControl(nodeID topoapi.ID, codec Codec, request *e2api.ControlMessage, outcome *e2api.ControlOutcome, err error) {
+	if m.recorder == nil {
+		return
+	}
+	record := recorder.Record{
+		Kind:                recorder.KindControl,
+		NodeID:              string(nodeID),
+		ServiceModelVersion: string(codec.Version()),
+		Header:              request.GetHeader(),
+		Payload:             request.GetPayload(),
+		OutcomePayload:      outcome.GetPayload(),
 	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	m.recorder.Record(record)
 }
 
-func (m *Manager) watchCtrlUEAssociate(ctx context.Context, e2NodeID topoapi.ID) {
-	for ctrlReqMsg := range m.ctrlReqChsUeAssociate[string(e2NodeID)] {
-		log.Debugf("ctrlReqMsg: %v", ctrlReqMsg)
-		node := m.e2Client.Node(e2client.NodeID(e2NodeID))
-		ctrlRespMsg, err := node.Control(ctx, ctrlReqMsg.CtrlMsg, nil)
-		log.Debugf("ctrlRespMsg: %v", ctrlRespMsg)
-		if err != nil {
-			log.Warnf("Error sending control message - %v", err)
-			ack := Ack{
-				Success: false,
-				Reason:  err.Error(),
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		} else if ctrlRespMsg == nil {
-			log.Warn(" Ctrl Resp message is nil")
-			ack := Ack{
-				Success: false,
-				Reason:  "Ctrl Resp message is nil",
-			}
-			ctrlReqMsg.AckCh <- ack
-			continue
-		}
-		ack := Ack{
-			Success: true,
+func (m *Manager) Stop() error {
+	return m.Shutdown(context.Background())
+}
+
+// Shutdown stops watching E2 connections, unregisters the nodes from the control dispatcher and waits for their workers,
+// then deletes the E2 subscriptions and closes the indication streams.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	log.Info("Stop E2 Manager")
+	m.cancel()
+	select {
+	case <-m.watchDone:
+	case <-ctx.Done():
+		return fmt.Errorf("E2 connection watch did not stop: %v", ctx.Err())
+	}
+
+	if err := m.ctrlDispatcher.Close(ctx); err != nil {
+		return err
+	}
+
+	var err error
+	for _, channelID := range m.streams.ChannelIDs() {
+		if _, e := m.streams.CloseStream(ctx, channelID); e != nil {
+			log.Warnf("Failed to delete subscription %v: %v", channelID, e)
+			err = e
 		}
-		ctrlReqMsg.AckCh <- ack
 	}
+	if e := m.streams.Close(); e != nil {
+		log.Warn(e)
+		err = e
+	}
+	return err
 }
 
-func (m *Manager) Stop() error {
-	panic("implement me")
+// supportedSlicingConfigTypes returns the slicing configurations the node supports, from the capability cache if there is one
+func (m *Manager) supportedSlicingConfigTypes(ctx context.Context, nodeID topoapi.ID) ([]*topoapi.RSMSupportedSlicingConfigItem, error) {
+	if m.capabilities != nil {
+		return m.capabilities.GetSupportedSlicingConfigTypes(ctx, nodeID)
+	}
+	return m.rnibClient.GetSupportedSlicingConfigTypes(ctx, nodeID)
 }
 
 var _ Node = &Manager{}
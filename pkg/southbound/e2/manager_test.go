@@ -0,0 +1,245 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	rsmapi "github.com/onosproject/onos-api/go/onos/rsm"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	uenib_api "github.com/onosproject/onos-api/go/onos/uenib"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
+	"github.com/onosproject/onos-rsm/pkg/broker"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
+	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
+	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/onosproject/onos-rsm/pkg/northbound"
+	"github.com/onosproject/onos-rsm/pkg/slicing"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2"
+	"github.com/onosproject/onos-rsm/pkg/southbound/e2/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	cuNodeID = topoapi.ID("e2:4/e00/2/c8")
+	duNodeID = topoapi.ID("e2:4/e00/3/c8")
+	smName   = "oran-e2sm-rsm"
+)
+
+// addNode adds the E2 node with the slicing configurations to the R-NIB and connects it
+func addNode(ctx context.Context, t *testing.T, store *rnib.MemoryStore, nodeID topoapi.ID, configs ...topoapi.E2SmRsmCommand) {
+	supported := make([]*topoapi.RSMSupportedSlicingConfigItem, 0, len(configs))
+	for _, config := range configs {
+		supported = append(supported, &topoapi.RSMSupportedSlicingConfigItem{SlicingConfigType: config})
+	}
+	ranFunction, err := proto.Marshal(&topoapi.RSMRanFunction{
+		RicSlicingNodeCapabilityList: []*topoapi.RSMNodeSlicingCapabilityItem{
+			{
+				SupportedConfig: supported,
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	oid := e2.NewV1Codec().OID()
+	node := &topoapi.Object{
+		ID:   nodeID,
+		Type: topoapi.Object_ENTITY,
+		Obj: &topoapi.Object_Entity{
+			Entity: &topoapi.Entity{
+				KindID: topoapi.E2NODE,
+			},
+		},
+	}
+	require.NoError(t, node.SetAspect(&topoapi.E2Node{
+		ServiceModels: map[string]*topoapi.ServiceModelInfo{
+			oid: {
+				OID:          oid,
+				Name:         smName,
+				RanFunctions: []*types.Any{{TypeUrl: "onos.topo.RSMRanFunction", Value: ranFunction}},
+			},
+		},
+	}))
+	require.NoError(t, store.Create(ctx, node))
+
+	require.NoError(t, store.Create(ctx, &topoapi.Object{
+		ID:   topoapi.ID("e2t-" + nodeID),
+		Type: topoapi.Object_RELATION,
+		Obj: &topoapi.Object_Relation{
+			Relation: &topoapi.Relation{
+				KindID:      topoapi.CONTROLS,
+				SrcEntityID: "e2t",
+				TgtEntityID: nodeID,
+			},
+		},
+	}))
+}
+
+// request sends the NBI request to the slicing manager and returns its ack
+func request(ctx context.Context, t *testing.T, rsmMsgCh chan *northbound.RsmMsg, nodeID topoapi.ID, message interface{}) northbound.Ack {
+	ackCh := make(chan northbound.Ack)
+	select {
+	case rsmMsgCh <- &northbound.RsmMsg{NodeID: nodeID, Message: message, AckCh: ackCh}:
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	select {
+	case ack := <-ackCh:
+		return ack
+	case <-ctx.Done():
+		t.Fatal(ctx.Err())
+	}
+	return northbound.Ack{}
+}
+
+func TestManagerWithFakeNodes(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	rnibStore, err := rnib.NewMemoryStore("")
+	require.NoError(t, err)
+	uenibStore, err := uenib.NewMemoryStore("")
+	require.NoError(t, err)
+	rnibClient := rnib.NewMemoryClient(ctx, rnibStore)
+	uenibClient := uenib.NewMemoryClient(uenibStore)
+
+	cu := fake.NewNode(e2client.NodeID(cuNodeID))
+	du := fake.NewNode(e2client.NodeID(duNodeID))
+	e2t := fake.NewE2T(cu, du)
+
+	ctrlDispatcher := e2.NewControlDispatcher()
+	identityResolver := monitoring.NewUEIdentityResolver(uenibClient)
+	e2Manager, err := e2.NewManager(
+		e2.WithE2Client(e2t),
+		e2.WithServiceModel(smName, "v1"),
+		e2.WithAppID("onos-rsm"),
+		e2.WithBroker(broker.NewBroker()),
+		e2.WithRnibClient(rnibClient),
+		e2.WithUenibClient(uenibClient),
+		e2.WithControlDispatcher(ctrlDispatcher),
+		e2.WithUEIdentityResolver(identityResolver),
+	)
+	require.NoError(t, err)
+	require.NoError(t, e2Manager.Start())
+	defer func() {
+		assert.NoError(t, e2Manager.Shutdown(context.Background()))
+	}()
+
+	rsmMsgCh := make(chan *northbound.RsmMsg)
+	slicingManager := slicing.NewManager(
+		slicing.WithRnibClient(rnibClient),
+		slicing.WithUenibClient(uenibClient),
+		slicing.WithControlDispatcher(ctrlDispatcher),
+		slicing.WithNbiReqChs(rsmMsgCh),
+		slicing.WithAckTimer(5),
+		slicing.WithUEIdentityResolver(identityResolver),
+	)
+	go slicingManager.Run(ctx)
+
+	addNode(ctx, t, rnibStore, cuNodeID, topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_EVENT_TRIGGERS)
+	addNode(ctx, t, rnibStore, duNodeID,
+		topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_CREATE,
+		topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_UPDATE,
+		topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_SLICE_DELETE,
+		topoapi.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE)
+	// the CU contains its DU
+	require.NoError(t, rnibStore.Create(ctx, &topoapi.Object{
+		ID:   topoapi.ID(cuNodeID + "-" + duNodeID),
+		Type: topoapi.Object_RELATION,
+		Obj: &topoapi.Object_Relation{
+			Relation: &topoapi.Relation{
+				KindID:      topoapi.CONTAINS,
+				SrcEntityID: cuNodeID,
+				TgtEntityID: duNodeID,
+			},
+		},
+	}))
+	require.Eventually(t, func() bool {
+		return ctrlDispatcher.Check(duNodeID, e2sm_rsm.E2SmRsmCommand_E2_SM_RSM_COMMAND_UE_ASSOCIATE) == nil
+	}, 10*time.Second, 10*time.Millisecond)
+
+	// the CU reports the attach of the UE, which is added to the UE-NIB on the DU of the CU
+	ue := fake.UE{CuUeF1apID: 10, DuUeF1apID: 20, RanUeNgapID: 30, AmfUeNgapID: 40, DrbID: 5, Qfi: 1, FiveQI: 9}
+	var attached *uenib_api.RsmUeInfo
+	require.Eventually(t, func() bool {
+		// the attach is emitted again until the CU subscription is there
+		if err := cu.AttachUE(ctx, ue); err != nil {
+			return false
+		}
+		ueInfo, err := uenibClient.GetUEWithPreferredID(ctx, string(cuNodeID), uenib_api.UeIdType_UE_ID_TYPE_CU_UE_F1_AP_ID, ue.CuUeF1apID)
+		if err != nil {
+			return false
+		}
+		attached = ueInfo
+		return true
+	}, 10*time.Second, 100*time.Millisecond)
+	assert.Equal(t, string(duNodeID), attached.GetDuE2NodeId())
+	assert.Equal(t, ue.DuUeF1apID, attached.GetUeIdList().GetDuUeF1apID().GetValue())
+	assert.Equal(t, ue.RanUeNgapID, attached.GetUeIdList().GetRANUeNgapID().GetValue())
+	require.Len(t, attached.GetBearerIdList(), 1)
+	assert.Equal(t, ue.DrbID, attached.GetBearerIdList()[0].GetDrbId().GetFiveGdrbId().GetValue())
+
+	// the slice is created in the DU's slice table and in the R-NIB
+	ack := request(ctx, t, rsmMsgCh, duNodeID, &rsmapi.CreateSliceRequest{
+		E2NodeId:      string(duNodeID),
+		SliceId:       "1",
+		SchedulerType: rsmapi.SchedulerType_SCHEDULER_TYPE_ROUND_ROBIN,
+		Weight:        "30",
+		SliceType:     rsmapi.SliceType_SLICE_TYPE_DL_SLICE,
+	})
+	require.True(t, ack.Success, ack.Reason)
+	slices := du.Slices()
+	require.Len(t, slices, 1)
+	assert.Equal(t, int64(1), slices[0].GetSliceId().GetValue())
+	assert.Equal(t, int32(30), slices[0].GetSliceConfigParameters().GetWeight())
+	assert.Equal(t, e2sm_rsm.SliceType_SLICE_TYPE_DL_SLICE, slices[0].GetSliceType())
+	assert.Empty(t, cu.Slices())
+	items, err := rnibClient.GetRsmSliceItemAspects(ctx, duNodeID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, "1", items[0].GetID())
+
+	// the UE is associated with the slice on the DU and in the UE-NIB
+	ack = request(ctx, t, rsmMsgCh, duNodeID, &rsmapi.SetUeSliceAssociationRequest{
+		E2NodeId: string(duNodeID),
+		UeId: []*rsmapi.UeId{
+			{
+				UeId: "20",
+				Type: rsmapi.UeIdType_UE_ID_TYPE_DU_UE_F1_AP_ID,
+			},
+		},
+		DlSliceId: "1",
+		DrbId:     "5",
+	})
+	require.True(t, ack.Success, ack.Reason)
+	assoc := du.Association(ue.DuUeF1apID)
+	require.NotNil(t, assoc)
+	assert.Equal(t, int64(1), assoc.GetDownLinkSliceId().GetValue())
+	require.Len(t, assoc.GetBearerId(), 1)
+	assert.Equal(t, ue.DrbID, assoc.GetBearerId()[0].GetDrbId().GetFiveGdrbId().GetValue())
+
+	associated, err := uenibClient.GetUEWithGlobalID(ctx, attached.GetGlobalUeID())
+	require.NoError(t, err)
+	require.Len(t, associated.GetSliceList(), 1)
+	assert.Equal(t, "1", associated.GetSliceList()[0].GetID())
+	items, err = rnibClient.GetRsmSliceItemAspects(ctx, duNodeID)
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	require.Len(t, items[0].GetUeIdList(), 1)
+	assert.Equal(t, ue.DuUeF1apID, items[0].GetUeIdList()[0].GetDuUeF1apID().GetValue())
+
+	// the detach removes the UE from the UE-NIB
+	require.NoError(t, cu.DetachUE(ctx, ue))
+	assert.Eventually(t, func() bool {
+		_, err := uenibClient.GetUEWithGlobalID(ctx, attached.GetGlobalUeID())
+		return err != nil
+	}, 10*time.Second, 10*time.Millisecond)
+}
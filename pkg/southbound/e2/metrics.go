@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"context"
+	"math"
+
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-ric-sdk-go/pkg/config/event"
+)
+
+// reportPeriodMs returns the configured reporting period of the slice metrics; 0 disables the periodic metrics subscriptions
+func (m *Manager) reportPeriodMs() int32 {
+	if m.appConfig == nil {
+		return 0
+	}
+	period, err := m.appConfig.GetReportPeriod()
+	if err != nil {
+		log.Warnf("Failed to get the report period: %v", err)
+		return 0
+	}
+	if period > math.MaxInt32 {
+		log.Warnf("Report period %v is too long, using %v ms", period, math.MaxInt32)
+		return math.MaxInt32
+	}
+	return int32(period)
+}
+
+// watchReportPeriod creates, replaces or deletes the periodic metrics subscriptions when the configured reporting period changes
+func (m *Manager) watchReportPeriod(ctx context.Context) {
+	if m.appConfig == nil {
+		return
+	}
+	ch := make(chan event.Event)
+	err := m.appConfig.Watch(ctx, ch)
+	if err != nil {
+		log.Warn(err)
+		return
+	}
+
+	for e := range ch {
+		log.Debugf("Received config event: %v", e)
+		period := m.reportPeriodMs()
+		trigger := e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_PERIODIC_METRICS
+		for _, nodeID := range m.subscriptions.staleMetricsNodes(period) {
+			if period == 0 {
+				log.Infof("Report period is 0, deleting the periodic metrics subscription of E2 node %v", nodeID)
+				m.subscriptions.removeTrigger(nodeID, trigger)
+				continue
+			}
+			// the subscription belongs to the current connection of the node, so that it stops when the node disconnects
+			nodeCtx, ok := m.connections.context(nodeID)
+			if !ok {
+				log.Debugf("E2 node %v is no longer connected, not subscribing to its metrics", nodeID)
+				continue
+			}
+			log.Infof("Report period is changed to %v ms, subscribing to the metrics of E2 node %v", period, nodeID)
+			m.superviseSubscription(nodeCtx, nodeID, trigger, period)
+		}
+	}
+}
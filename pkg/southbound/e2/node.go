@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"context"
+	"sync"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
+)
+
+// nodeConnections tracks the E2 connection of each node; the control workers and subscriptions of a node
+// run in the context of its connection, which is canceled when the node disconnects or connects again
+type nodeConnections struct {
+	mu    sync.Mutex
+	nodes map[topoapi.ID]*nodeConnection
+}
+
+type nodeConnection struct {
+	// relationID is the ID of the E2 connection relation in onos-topo
+	relationID topoapi.ID
+	ctx        context.Context
+	cancel     context.CancelFunc
+}
+
+func newNodeConnections() *nodeConnections {
+	return &nodeConnections{
+		nodes: make(map[topoapi.ID]*nodeConnection),
+	}
+}
+
+// connect returns the context of the new connection of the node; the previous connection of the node is canceled
+// and reported as replaced
+func (c *nodeConnections) connect(ctx context.Context, nodeID topoapi.ID, relationID topoapi.ID) (context.Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prev, replaced := c.nodes[nodeID]
+	if replaced {
+		log.Infof("E2 node %v connected again - replacing connection %v with %v", nodeID, prev.relationID, relationID)
+		prev.cancel()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	c.nodes[nodeID] = &nodeConnection{
+		relationID: relationID,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+	return ctx, replaced
+}
+
+// context returns the context of the current connection of the node; false if the node is not connected
+func (c *nodeConnections) context(nodeID topoapi.ID) (context.Context, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn, ok := c.nodes[nodeID]
+	if !ok || conn.ctx.Err() != nil {
+		return nil, false
+	}
+	return conn.ctx, true
+}
+
+// disconnect cancels the connection of the node; it returns false if the relation is not the node's current connection,
+// i.e. the removal of a connection which was already replaced by a reconnection
+func (c *nodeConnections) disconnect(nodeID topoapi.ID, relationID topoapi.ID) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	conn, ok := c.nodes[nodeID]
+	if ok && conn.relationID != relationID {
+		return false
+	}
+	if ok {
+		conn.cancel()
+		delete(c.nodes, nodeID)
+	}
+	return true
+}
+
+// teardownNode stops the control workers and the subscriptions of a disconnected node and closes its streams;
+// the control requests waiting for the node fail with Unavailable
+func (m *Manager) teardownNode(ctx context.Context, nodeID topoapi.ID) {
+	m.subscriptions.remove(nodeID)
+	m.ctrlDispatcher.Unregister(nodeID)
+	closeCtx, cancel := context.WithTimeout(ctx, closeStreamTimeout)
+	defer cancel()
+	if err := m.streams.CloseNodeStreams(closeCtx, e2client.NodeID(nodeID)); err != nil {
+		log.Warnf("Failed to close the streams of E2 node %v: %v", nodeID, err)
+	}
+}
@@ -5,10 +5,14 @@
 package e2
 
 import (
+	e2client "github.com/onosproject/onos-ric-sdk-go/pkg/e2/v1beta1"
 	"github.com/onosproject/onos-rsm/pkg/broker"
 	appConfig "github.com/onosproject/onos-rsm/pkg/config"
+	"github.com/onosproject/onos-rsm/pkg/kpi"
+	"github.com/onosproject/onos-rsm/pkg/monitoring"
 	"github.com/onosproject/onos-rsm/pkg/nib/rnib"
 	"github.com/onosproject/onos-rsm/pkg/nib/uenib"
+	"github.com/onosproject/onos-rsm/pkg/recorder"
 )
 
 type Options struct {
@@ -28,20 +32,32 @@ type AppOptions struct {
 
 	RnibClient rnib.TopoClient
 
+	CapabilityCache *rnib.CapabilityCache
+
 	UenibClient uenib.Client
 
-	CtrlReqChsSliceCreate map[string]chan *CtrlMsg
+	CtrlDispatcher *ControlDispatcher
+
+	NodeEventCh chan NodeEvent
+
+	IndicationObserver monitoring.IndicationObserver
 
-	CtrlReqChsSliceUpdate map[string]chan *CtrlMsg
+	HandoverTracker *monitoring.HandoverTracker
 
-	CtrlReqChsSliceDelete map[string]chan *CtrlMsg
+	IdentityResolver *monitoring.UEIdentityResolver
 
-	CtrlReqChsUeAssociate map[string]chan *CtrlMsg
+	CtrlRetryPolicy RetryPolicy
+
+	Recorder *recorder.Recorder
+
+	KpiStore *kpi.Store
 }
 
 type ServiceOptions struct {
 	Host string
 	Port int
+	// Client is the E2 client of all service model versions; the clients connecting to Host and Port are used if it is nil
+	Client e2client.Client
 }
 
 // ServiceModelName is a service model identifier
@@ -84,6 +100,13 @@ func WithE2TAddress(host string, port int) Option {
 	})
 }
 
+// WithE2Client sets the E2 client used instead of connecting to the E2T, e.g. an in-process fake
+func WithE2Client(client e2client.Client) Option {
+	return newOption(func(options *Options) {
+		options.E2TService.Client = client
+	})
+}
+
 func WithServiceModel(name ServiceModelName, version ServiceModelVersion) Option {
 	return newOption(func(options *Options) {
 		options.ServiceModel = ServiceModelOptions{
@@ -117,20 +140,69 @@ func WithRnibClient(rnibClient rnib.TopoClient) Option {
 	})
 }
 
+// WithCapabilityCache sets the cache the supported slicing configurations of the E2 nodes are read from
+func WithCapabilityCache(cache *rnib.CapabilityCache) Option {
+	return newOption(func(options *Options) {
+		options.App.CapabilityCache = cache
+	})
+}
+
 func WithUenibClient(uenibClient uenib.Client) Option {
 	return newOption(func(options *Options) {
 		options.App.UenibClient = uenibClient
 	})
 }
 
-func WithCtrlReqChs(ctrlReqChsSliceCreate map[string]chan *CtrlMsg,
-	ctrlReqChsSliceUpdate map[string]chan *CtrlMsg,
-	ctrlReqChsSliceDelete map[string]chan *CtrlMsg,
-	ctrlReqChsUeAssociate map[string]chan *CtrlMsg) Option {
+func WithControlDispatcher(ctrlDispatcher *ControlDispatcher) Option {
+	return newOption(func(options *Options) {
+		options.App.CtrlDispatcher = ctrlDispatcher
+	})
+}
+
+func WithNodeEventCh(nodeEventCh chan NodeEvent) Option {
+	return newOption(func(options *Options) {
+		options.App.NodeEventCh = nodeEventCh
+	})
+}
+
+// WithIndicationObserver sets the observer of the UE state reported in indications
+func WithIndicationObserver(observer monitoring.IndicationObserver) Option {
+	return newOption(func(options *Options) {
+		options.App.IndicationObserver = observer
+	})
+}
+
+// WithHandoverTracker sets the tracker correlating hand-outs and hand-ins
+func WithHandoverTracker(tracker *monitoring.HandoverTracker) Option {
+	return newOption(func(options *Options) {
+		options.App.HandoverTracker = tracker
+	})
+}
+
+// WithUEIdentityResolver sets the resolver of the global UE IDs of attaching UEs
+func WithUEIdentityResolver(resolver *monitoring.UEIdentityResolver) Option {
+	return newOption(func(options *Options) {
+		options.App.IdentityResolver = resolver
+	})
+}
+
+// WithControlRetryPolicy sets the policy for sending again the control messages which failed
+func WithControlRetryPolicy(policy RetryPolicy) Option {
+	return newOption(func(options *Options) {
+		options.App.CtrlRetryPolicy = policy
+	})
+}
+
+// WithRecorder sets the recorder of the control requests and their responses
+func WithRecorder(r *recorder.Recorder) Option {
+	return newOption(func(options *Options) {
+		options.App.Recorder = r
+	})
+}
+
+// WithKpiStore sets the store of the slice metrics reported by the DUs
+func WithKpiStore(store *kpi.Store) Option {
 	return newOption(func(options *Options) {
-		options.App.CtrlReqChsSliceCreate = ctrlReqChsSliceCreate
-		options.App.CtrlReqChsSliceUpdate = ctrlReqChsSliceUpdate
-		options.App.CtrlReqChsSliceDelete = ctrlReqChsSliceDelete
-		options.App.CtrlReqChsUeAssociate = ctrlReqChsUeAssociate
+		options.App.KpiStore = store
 	})
 }
@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy is the policy for sending again the control messages which failed with a retryable ACK,
+// e.g. a timeout or an unavailable E2T; the rejections of the E2 node's semantic checks are never sent again
+type RetryPolicy struct {
+	// Deadline is the time after the first attempt after which no attempt starts; zero disables the retries
+	Deadline time.Duration
+	// InitialBackoff is the backoff before the first retry; it doubles up to MaxBackoff on every retry
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AttemptTimeout bounds each attempt; zero leaves the attempts unbounded
+	AttemptTimeout time.Duration
+}
+
+// backoff returns the jittered backoff before the given retry, between half and all of the exponential backoff
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < retry && backoff < p.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if p.MaxBackoff > 0 && backoff > p.MaxBackoff {
+		backoff = p.MaxBackoff
+	}
+	if backoff <= 0 {
+		return 0
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// send calls the attempt until it succeeds, fails with an ACK which is not retryable or the deadline expires
+func (p RetryPolicy) send(ctx context.Context, attempt func(ctx context.Context) Ack) Ack {
+	deadline := time.Now().Add(p.Deadline)
+	for retry := 0; ; retry++ {
+		attemptCtx, cancel := ctx, context.CancelFunc(func() {})
+		if p.AttemptTimeout > 0 {
+			attemptCtx, cancel = context.WithTimeout(ctx, p.AttemptTimeout)
+		}
+		ack := attempt(attemptCtx)
+		cancel()
+		ack.Retries = retry
+		if ack.Success || !ack.Retryable {
+			return ack
+		}
+
+		backoff := p.backoff(retry + 1)
+		if time.Now().Add(backoff).After(deadline) {
+			return ack
+		}
+		log.Debugf("Retrying control message in %v after %v (retry %d)", backoff, ack.Reason, retry+1)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return ack
+		}
+	}
+}
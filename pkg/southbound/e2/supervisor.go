@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2020-present Open Networking Foundation <info@opennetworking.org>
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package e2
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+	e2sm_rsm "github.com/onosproject/onos-e2-sm/servicemodels/e2sm_rsm/v1/e2sm-rsm-ies"
+	"github.com/onosproject/onos-rsm/api"
+)
+
+const (
+	resubscribeInitialBackoff = time.Second
+	// resubscribeMaxBackoff is also the time a subscription has to stay active for the backoff to be reset
+	resubscribeMaxBackoff = time.Minute
+)
+
+// subscriptionSupervisor keeps the E2 subscriptions of each node and their health
+type subscriptionSupervisor struct {
+	mu            sync.RWMutex
+	subscriptions map[subscriptionKey]*supervisedSubscription
+	// metricsNodes are the connected DUs which can report slice metrics
+	metricsNodes map[topoapi.ID]bool
+}
+
+type subscriptionKey struct {
+	nodeID       topoapi.ID
+	eventTrigger e2sm_rsm.RsmRicindicationTriggerType
+}
+
+type supervisedSubscription struct {
+	health            api.SubscriptionHealth
+	reportingPeriodMs int32
+	cancel            context.CancelFunc
+}
+
+func newSubscriptionSupervisor() *subscriptionSupervisor {
+	return &subscriptionSupervisor{
+		subscriptions: make(map[subscriptionKey]*supervisedSubscription),
+		metricsNodes:  make(map[topoapi.ID]bool),
+	}
+}
+
+// add starts tracking the subscription; the subscription tracked before for the same node and trigger is canceled
+func (s *subscriptionSupervisor) add(key subscriptionKey, reportingPeriodMs int32, cancel context.CancelFunc) *supervisedSubscription {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if prev, ok := s.subscriptions[key]; ok {
+		prev.cancel()
+	}
+	sub := &supervisedSubscription{
+		health: api.SubscriptionHealth{
+			E2NodeID:          string(key.nodeID),
+			TriggerType:       key.eventTrigger.String(),
+			ReportingPeriodMs: reportingPeriodMs,
+			State:             api.SubscriptionSubscribing,
+			Since:             time.Now(),
+		},
+		reportingPeriodMs: reportingPeriodMs,
+		cancel:            cancel,
+	}
+	s.subscriptions[key] = sub
+	return sub
+}
+
+// remove cancels the subscriptions of the node and stops tracking them
+func (s *subscriptionSupervisor) remove(nodeID topoapi.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.metricsNodes, nodeID)
+	for key, sub := range s.subscriptions {
+		if key.nodeID == nodeID {
+			sub.cancel()
+			delete(s.subscriptions, key)
+		}
+	}
+}
+
+// removeTrigger cancels the subscription of the node with the trigger and stops tracking it
+func (s *subscriptionSupervisor) removeTrigger(nodeID topoapi.ID, eventTrigger e2sm_rsm.RsmRicindicationTriggerType) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := subscriptionKey{nodeID: nodeID, eventTrigger: eventTrigger}
+	if sub, ok := s.subscriptions[key]; ok {
+		sub.cancel()
+		delete(s.subscriptions, key)
+	}
+}
+
+func (s *subscriptionSupervisor) addMetricsNode(nodeID topoapi.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metricsNodes[nodeID] = true
+}
+
+// staleMetricsNodes returns the DUs whose periodic metrics subscription does not have the reporting period
+func (s *subscriptionSupervisor) staleMetricsNodes(reportingPeriodMs int32) []topoapi.ID {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	nodeIDs := make([]topoapi.ID, 0)
+	for nodeID := range s.metricsNodes {
+		period := int32(0)
+		if sub, ok := s.subscriptions[subscriptionKey{nodeID: nodeID, eventTrigger: e2sm_rsm.RsmRicindicationTriggerType_RSM_RICINDICATION_TRIGGER_TYPE_PERIODIC_METRICS}]; ok {
+			period = sub.reportingPeriodMs
+		}
+		if period != reportingPeriodMs {
+			nodeIDs = append(nodeIDs, nodeID)
+		}
+	}
+	return nodeIDs
+}
+
+func (s *subscriptionSupervisor) update(sub *supervisedSubscription, f func(health *api.SubscriptionHealth)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(&sub.health)
+}
+
+func (s *subscriptionSupervisor) list() []api.SubscriptionHealth {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	health := make([]api.SubscriptionHealth, 0, len(s.subscriptions))
+	for _, sub := range s.subscriptions {
+		health = append(health, sub.health)
+	}
+	sort.Slice(health, func(i, j int) bool {
+		if health[i].E2NodeID != health[j].E2NodeID {
+			return health[i].E2NodeID < health[j].E2NodeID
+		}
+		return health[i].TriggerType < health[j].TriggerType
+	})
+	return health
+}
+
+// SubscriptionHealth returns the health of the E2 subscriptions of the connected nodes
+func (m *Manager) SubscriptionHealth() []api.SubscriptionHealth {
+	return m.subscriptions.list()
+}
+
+// superviseSubscription subscribes to the node and subscribes again with exponential backoff whenever
+// the subscription fails, until the context is canceled or the node is disconnected.
+// A subscription of the node with the same trigger is replaced.
+func (m *Manager) superviseSubscription(ctx context.Context, nodeID topoapi.ID, eventTrigger e2sm_rsm.RsmRicindicationTriggerType, reportingPeriodMs int32) {
+	ctx, cancel := context.WithCancel(ctx)
+	sub := m.subscriptions.add(subscriptionKey{nodeID: nodeID, eventTrigger: eventTrigger}, reportingPeriodMs, cancel)
+	go m.runSubscription(ctx, sub, nodeID, eventTrigger)
+}
+
+func (m *Manager) runSubscription(ctx context.Context, sub *supervisedSubscription, nodeID topoapi.ID, eventTrigger e2sm_rsm.RsmRicindicationTriggerType) {
+	backoff := resubscribeInitialBackoff
+	for attempt := 0; ; attempt++ {
+		m.subscriptions.update(sub, func(health *api.SubscriptionHealth) {
+			if attempt > 0 {
+				health.Resubscriptions++
+			}
+			health.State = api.SubscriptionSubscribing
+			health.Since = time.Now()
+			health.NextAttempt = nil
+		})
+
+		var activeSince time.Time
+		err := m.createSubscription(ctx, nodeID, eventTrigger, sub.reportingPeriodMs, func() {
+			activeSince = time.Now()
+			m.subscriptions.update(sub, func(health *api.SubscriptionHealth) {
+				health.State = api.SubscriptionActive
+				health.Since = activeSince
+			})
+		})
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !activeSince.IsZero() && time.Since(activeSince) >= resubscribeMaxBackoff {
+			backoff = resubscribeInitialBackoff
+			m.subscriptions.update(sub, func(health *api.SubscriptionHealth) {
+				health.Failures = 0
+			})
+		}
+		nextAttempt := time.Now().Add(backoff)
+		m.subscriptions.update(sub, func(health *api.SubscriptionHealth) {
+			health.State = api.SubscriptionBackoff
+			health.Since = time.Now()
+			health.Failures++
+			health.NextAttempt = &nextAttempt
+			if err != nil {
+				health.LastError = err.Error()
+			}
+		})
+		log.Warnf("Subscription of E2 node %v failed, subscribing again in %v: %v", nodeID, backoff, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > resubscribeMaxBackoff {
+			backoff = resubscribeMaxBackoff
+		}
+	}
+}
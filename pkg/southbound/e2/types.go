@@ -4,14 +4,43 @@
 
 package e2
 
-import e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+import (
+	e2api "github.com/onosproject/onos-api/go/onos/e2t/e2/v1beta1"
+	topoapi "github.com/onosproject/onos-api/go/onos/topo"
+)
 
 type Ack struct {
 	Success bool
 	Reason  string
+	Outcome Outcome
+	// CauseGroup and Cause are the E2AP cause of a control failure
+	CauseGroup CauseGroup
+	Cause      string
+	// Retryable is true if the control message may succeed when sent again
+	Retryable bool
+	// Retries is the number of times the control message was sent again before this ACK
+	Retries int
+	// OutcomePayload is the control outcome sent by the E2 node; E2SM-RSM does not define its content
+	OutcomePayload []byte
 }
 
 type CtrlMsg struct {
 	CtrlMsg *e2api.ControlMessage
 	AckCh   chan Ack
 }
+
+// NodeEventType is a type of E2 node lifecycle event
+type NodeEventType int
+
+const (
+	// NodeConnected is sent once the control channels of a node which supports slicing are ready
+	NodeConnected NodeEventType = iota
+	// NodeDisconnected is sent when a node is disconnected
+	NodeDisconnected
+)
+
+// NodeEvent is an E2 node lifecycle event
+type NodeEvent struct {
+	Type   NodeEventType
+	NodeID topoapi.ID
+}
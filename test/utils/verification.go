@@ -15,12 +15,14 @@ import (
 )
 
 func VerifySliceInitValuesForAllDUs(numSlices int) error {
-	rnibClient, err := rnib.NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rnibClient, err := rnib.NewClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(context.Background())
+	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
 	if err != nil {
 		return err
 	}
@@ -57,12 +59,14 @@ func VerifySliceInitValuesForAllDUs(numSlices int) error {
 }
 
 func VerifySliceUpdatedValuesForAllDUs(numSlices int) error {
-	rnibClient, err := rnib.NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rnibClient, err := rnib.NewClient(ctx)
 	if err != nil {
 		return err
 	}
 
-	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(context.Background())
+	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
 	if err != nil {
 		return err
 	}
@@ -99,16 +103,18 @@ func VerifySliceUpdatedValuesForAllDUs(numSlices int) error {
 }
 
 func VerifyUESliceAssociationForAllDUsAndUEs(numSlices int) error {
-	rnibClient, err := rnib.NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rnibClient, err := rnib.NewClient(ctx)
 	if err != nil {
 		return err
 	}
-	uenibClient, err := uenib.NewClient(context.Background(), TLSCrtPath, TLSKeyPath, "onos-uenib:5150")
+	uenibClient, err := uenib.NewClient(ctx, TLSCrtPath, TLSKeyPath, "onos-uenib:5150")
 	if err != nil {
 		return err
 	}
 
-	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(context.Background())
+	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
 	if err != nil {
 		return err
 	}
@@ -136,7 +142,7 @@ func VerifyUESliceAssociationForAllDUsAndUEs(numSlices int) error {
 		}
 	}
 
-	rsmUEInfoAspects, err := uenibClient.GetUEs(context.Background())
+	rsmUEInfoAspects, err := uenibClient.GetUEs(ctx)
 	if err != nil {
 		return err
 	}
@@ -221,16 +227,18 @@ func VerifyUESliceAssociationForAllDUsAndUEs(numSlices int) error {
 }
 
 func VerifySliceDeletedForAllDUsAfterUEAssociation() error {
-	rnibClient, err := rnib.NewClient()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	rnibClient, err := rnib.NewClient(ctx)
 	if err != nil {
 		return err
 	}
-	uenibClient, err := uenib.NewClient(context.Background(), TLSCrtPath, TLSKeyPath, "onos-uenib:5150")
+	uenibClient, err := uenib.NewClient(ctx, TLSCrtPath, TLSKeyPath, "onos-uenib:5150")
 	if err != nil {
 		return err
 	}
 
-	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(context.Background())
+	rsmAspects, err := rnibClient.GetRSMSliceItemAspectsForAllDUs(ctx)
 	if err != nil {
 		return err
 	}
@@ -241,7 +249,7 @@ func VerifySliceDeletedForAllDUsAfterUEAssociation() error {
 		}
 	}
 
-	rsmUEInfoAspects, err := uenibClient.GetUEs(context.Background())
+	rsmUEInfoAspects, err := uenibClient.GetUEs(ctx)
 	if err != nil {
 		return err
 	}